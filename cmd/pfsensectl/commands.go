@@ -0,0 +1,674 @@
+package main
+
+import (
+	"context"
+	"net/netip"
+
+	"github.com/markphelps/optional"
+
+	"github.com/sjafferali/pfsense-api-goclient/v2/pfsenseapi"
+)
+
+// resources is the command tree. Each resource maps onto a group of methods
+// of one of the client's services.
+var resources = []*resource{
+	{
+		name:  "interface",
+		usage: "Manage interface assignments",
+		commands: append(interfaceCRUD.commands(), &command{
+			name:  "apply",
+			usage: "Apply pending interface changes",
+			run: func(ctx context.Context, c *pfsenseapi.Client, _ []string, _ fieldValues) (any, error) {
+				return nil, c.Interface.Apply(ctx)
+			},
+		}),
+	},
+	{
+		name:     "vlan",
+		usage:    "Manage VLANs",
+		commands: vlanCRUD.commands(),
+	},
+	{
+		name:     "ifgroup",
+		usage:    "Manage interface groups",
+		commands: interfaceGroupCRUD.commands(),
+	},
+	{
+		name:     "bridge",
+		usage:    "Manage interface bridges",
+		commands: interfaceBridgeCRUD.commands(),
+	},
+	{
+		name:  "rule",
+		usage: "Manage firewall rules",
+		commands: append(firewallRuleCRUD.commands(), &command{
+			name:  "apply",
+			usage: "Apply pending firewall changes",
+			run: func(ctx context.Context, c *pfsenseapi.Client, _ []string, _ fieldValues) (any, error) {
+				return nil, c.Firewall.Apply(ctx)
+			},
+		}),
+	},
+	{
+		name:  "alias",
+		usage: "Manage firewall aliases",
+		commands: append(firewallAliasCRUD.commands(),
+			&command{
+				name:    "add-entry",
+				usage:   "Add an entry to the alias with the given name",
+				args:    []string{"name", "address"},
+				columns: firewallAliasCRUD.columns,
+				flags:   []flagDef{{name: "detail", usage: "description of the entry"}},
+				run: func(ctx context.Context, c *pfsenseapi.Client, args []string, values fieldValues) (any, error) {
+					return c.Firewall.AddAliasEntries(ctx, args[0], pfsenseapi.AliasEntry{Address: args[1], Detail: values["detail"]})
+				},
+			},
+			&command{
+				name:    "remove-entry",
+				usage:   "Remove an entry from the alias with the given name",
+				args:    []string{"name", "address"},
+				columns: firewallAliasCRUD.columns,
+				run: func(ctx context.Context, c *pfsenseapi.Client, args []string, _ fieldValues) (any, error) {
+					return c.Firewall.RemoveAliasEntries(ctx, args[0], args[1])
+				},
+			},
+			&command{
+				name:    "resolve",
+				usage:   "Expand the alias with the given name, including nested aliases",
+				args:    []string{"name"},
+				columns: []string{"name", "prefixes", "ports", "unresolved"},
+				run: func(ctx context.Context, c *pfsenseapi.Client, args []string, _ fieldValues) (any, error) {
+					resolver, err := c.Firewall.ResolveAliases(ctx)
+					if err != nil {
+						return nil, err
+					}
+					return resolver.Resolve(args[0])
+				},
+			},
+		),
+	},
+	{
+		name:     "portforward",
+		usage:    "Manage NAT port forwards",
+		commands: portForwardCRUD.commands(),
+	},
+	{
+		name:  "outbound",
+		usage: "Manage outbound NAT mappings and mode",
+		commands: append(outboundMappingCRUD.commands(),
+			&command{
+				name:    "get-mode",
+				usage:   "Show the outbound NAT mode",
+				columns: []string{"mode"},
+				run: func(ctx context.Context, c *pfsenseapi.Client, _ []string, _ fieldValues) (any, error) {
+					mode, err := c.NAT.GetOutboundNATMode(ctx)
+					return map[string]string{"mode": string(mode)}, err
+				},
+			},
+			&command{
+				name:    "set-mode",
+				usage:   "Switch the outbound NAT mode: automatic, hybrid, advanced (manual) or disabled",
+				args:    []string{"mode"},
+				columns: []string{"mode"},
+				run: func(ctx context.Context, c *pfsenseapi.Client, args []string, _ fieldValues) (any, error) {
+					mode, err := c.NAT.SetOutboundNATMode(ctx, pfsenseapi.OutboundNATMode(args[0]))
+					return map[string]string{"mode": string(mode)}, err
+				},
+			},
+		),
+	},
+	{
+		name:     "onetoone",
+		usage:    "Manage 1:1 NAT mappings",
+		commands: oneToOneMappingCRUD.commands(),
+	},
+	{
+		name:     "schedule",
+		usage:    "Manage firewall schedules",
+		commands: scheduleCRUD.commands(),
+	},
+	{
+		name:  "state",
+		usage: "Inspect and kill firewall states",
+		commands: []*command{
+			{
+				name:    "list",
+				usage:   "List the states matching the given filters",
+				columns: stateColumns,
+				flags:   stateFilterFlags,
+				run: func(ctx context.Context, c *pfsenseapi.Client, _ []string, values fieldValues) (any, error) {
+					return c.Firewall.ListStates(ctx, stateFilter(values))
+				},
+			},
+			{
+				name:    "kill",
+				usage:   "Kill the states matching the given filters; at least one is required",
+				columns: stateColumns,
+				flags:   stateFilterFlags,
+				run: func(ctx context.Context, c *pfsenseapi.Client, _ []string, values fieldValues) (any, error) {
+					return c.Firewall.KillStates(ctx, stateFilter(values))
+				},
+			},
+			{
+				name:    "kill-host",
+				usage:   "Kill every state from or to the given address",
+				args:    []string{"address"},
+				columns: stateColumns,
+				run: func(ctx context.Context, c *pfsenseapi.Client, args []string, _ fieldValues) (any, error) {
+					addr, err := netip.ParseAddr(args[0])
+					if err != nil {
+						return nil, err
+					}
+					return c.Firewall.KillHostStates(ctx, addr)
+				},
+			},
+			{
+				name:    "size",
+				usage:   "Show the number of states and the state table limit",
+				columns: []string{"currentstates", "maximumstates", "defaultmaximumstates"},
+				run: func(ctx context.Context, c *pfsenseapi.Client, _ []string, _ fieldValues) (any, error) {
+					return c.Firewall.GetStateTableSize(ctx)
+				},
+			},
+		},
+	},
+	{
+		name:  "vip",
+		usage: "Manage virtual IPs (CARP, IP alias, Proxy ARP, Other)",
+		commands: append(virtualIPCRUD.commands(), &command{
+			name:  "apply",
+			usage: "Apply pending virtual IP changes",
+			run: func(ctx context.Context, c *pfsenseapi.Client, _ []string, _ fieldValues) (any, error) {
+				return nil, c.VirtualIP.Apply(ctx)
+			},
+		}),
+	},
+	{
+		name:     "user",
+		usage:    "Manage users",
+		commands: userCRUD.commands(),
+	},
+	{
+		name:     "group",
+		usage:    "Manage user groups",
+		commands: userGroupCRUD.commands(),
+	},
+}
+
+var interfaceCRUD = crud[pfsenseapi.Interface, pfsenseapi.InterfaceRequest, string]{
+	noun:      "interface",
+	article:   "an",
+	plural:    "interfaces",
+	columns:   []string{"id", "if", "descr", "enable", "typev4", "ipaddr", "subnet", "typev6", "ipaddrv6", "subnetv6"},
+	parseID:   parseStringID,
+	toRequest: func(v *pfsenseapi.Interface) pfsenseapi.InterfaceRequest { return v.InterfaceRequest },
+	fields: []requestField[pfsenseapi.InterfaceRequest]{
+		stringField("if", "physical interface, e.g. igb1", func(r *pfsenseapi.InterfaceRequest) *string { return &r.If }),
+		optBoolPtrField("enable", "enable the interface", func(r *pfsenseapi.InterfaceRequest) **pfsenseapi.FlexOptionalBool { return &r.Enable }),
+		stringField("descr", "description", func(r *pfsenseapi.InterfaceRequest) *string { return &r.Descr }),
+		optStringPtrField("spoofmac", "MAC address to spoof", func(r *pfsenseapi.InterfaceRequest) **optional.String { return &r.Spoofmac }),
+		optInt32PtrField("mtu", "MTU", func(r *pfsenseapi.InterfaceRequest) **pfsenseapi.FlexOptionalInt32 { return &r.Mtu }),
+		optInt32PtrField("mss", "MSS clamping", func(r *pfsenseapi.InterfaceRequest) **pfsenseapi.FlexOptionalInt32 { return &r.Mss }),
+		optBoolPtrField("blockpriv", "block private networks", func(r *pfsenseapi.InterfaceRequest) **pfsenseapi.FlexOptionalBool { return &r.Blockpriv }),
+		optBoolPtrField("blockbogons", "block bogon networks", func(r *pfsenseapi.InterfaceRequest) **pfsenseapi.FlexOptionalBool { return &r.Blockbogons }),
+		stringField("typev4", "IPv4 configuration type", func(r *pfsenseapi.InterfaceRequest) *string { return &r.Typev4 }),
+		stringField("ipaddr", "IPv4 address", func(r *pfsenseapi.InterfaceRequest) *string { return &r.Ipaddr }),
+		int32Field("subnet", "IPv4 prefix length", func(r *pfsenseapi.InterfaceRequest) *pfsenseapi.FlexInt32 { return &r.Subnet }),
+		optStringPtrField("gateway", "IPv4 gateway", func(r *pfsenseapi.InterfaceRequest) **optional.String { return &r.Gateway }),
+		optStringPtrField("typev6", "IPv6 configuration type", func(r *pfsenseapi.InterfaceRequest) **optional.String { return &r.Typev6 }),
+		stringField("ipaddrv6", "IPv6 address", func(r *pfsenseapi.InterfaceRequest) *string { return &r.Ipaddrv6 }),
+		int32Field("subnetv6", "IPv6 prefix length", func(r *pfsenseapi.InterfaceRequest) *pfsenseapi.FlexInt32 { return &r.Subnetv6 }),
+		optStringPtrField("gatewayv6", "IPv6 gateway", func(r *pfsenseapi.InterfaceRequest) **optional.String { return &r.Gatewayv6 }),
+	},
+	list: func(ctx context.Context, c *pfsenseapi.Client) ([]*pfsenseapi.Interface, error) {
+		return c.Interface.ListInterfaces(ctx)
+	},
+	get: func(ctx context.Context, c *pfsenseapi.Client, id string) (*pfsenseapi.Interface, error) {
+		return c.Interface.GetInterface(ctx, id)
+	},
+	create: func(ctx context.Context, c *pfsenseapi.Client, req pfsenseapi.InterfaceRequest) (*pfsenseapi.Interface, error) {
+		return c.Interface.CreateInterface(ctx, req)
+	},
+	update: func(ctx context.Context, c *pfsenseapi.Client, id string, req pfsenseapi.InterfaceRequest) (*pfsenseapi.Interface, error) {
+		return c.Interface.UpdateInterface(ctx, id, req)
+	},
+	delete: func(ctx context.Context, c *pfsenseapi.Client, id string) (*pfsenseapi.Interface, error) {
+		return c.Interface.DeleteInterface(ctx, id)
+	},
+}
+
+var vlanCRUD = crud[pfsenseapi.VLAN, pfsenseapi.VLANRequest, int]{
+	noun:      "VLAN",
+	article:   "a",
+	plural:    "VLANs",
+	columns:   []string{"id", "if", "tag", "vlanif", "pcp", "descr"},
+	parseID:   parseIntID,
+	toRequest: func(v *pfsenseapi.VLAN) pfsenseapi.VLANRequest { return v.VLANRequest },
+	fields: []requestField[pfsenseapi.VLANRequest]{
+		stringField("if", "parent interface, e.g. igb1", func(r *pfsenseapi.VLANRequest) *string { return &r.If }),
+		intField("tag", "VLAN tag", func(r *pfsenseapi.VLANRequest) *int { return &r.Tag }),
+		optIntPtrField("pcp", "802.1p priority", func(r *pfsenseapi.VLANRequest) **optional.Int { return &r.Pcp }),
+		optStringPtrField("descr", "description", func(r *pfsenseapi.VLANRequest) **optional.String { return &r.Descr }),
+	},
+	list: func(ctx context.Context, c *pfsenseapi.Client) ([]*pfsenseapi.VLAN, error) {
+		return c.Interface.ListVLANs(ctx)
+	},
+	get: func(ctx context.Context, c *pfsenseapi.Client, id int) (*pfsenseapi.VLAN, error) {
+		return c.Interface.GetVLAN(ctx, id)
+	},
+	create: func(ctx context.Context, c *pfsenseapi.Client, req pfsenseapi.VLANRequest) (*pfsenseapi.VLAN, error) {
+		return c.Interface.CreateVLAN(ctx, req)
+	},
+	update: func(ctx context.Context, c *pfsenseapi.Client, id int, req pfsenseapi.VLANRequest) (*pfsenseapi.VLAN, error) {
+		return c.Interface.UpdateVLAN(ctx, id, req)
+	},
+	delete: func(ctx context.Context, c *pfsenseapi.Client, id int) (*pfsenseapi.VLAN, error) {
+		return c.Interface.DeleteVLAN(ctx, id)
+	},
+}
+
+var interfaceGroupCRUD = crud[pfsenseapi.InterfaceGroup, pfsenseapi.InterfaceGroupRequest, int]{
+	noun:      "interface group",
+	article:   "an",
+	plural:    "interface groups",
+	columns:   []string{"id", "ifname", "members", "descr"},
+	parseID:   parseIntID,
+	toRequest: func(v *pfsenseapi.InterfaceGroup) pfsenseapi.InterfaceGroupRequest { return v.InterfaceGroupRequest },
+	fields: []requestField[pfsenseapi.InterfaceGroupRequest]{
+		stringField("ifname", "group name", func(r *pfsenseapi.InterfaceGroupRequest) *string { return &r.Ifname }),
+		listField("members", "member interfaces", func(r *pfsenseapi.InterfaceGroupRequest) *pfsenseapi.FlexStringList { return &r.Members }),
+		stringField("descr", "description", func(r *pfsenseapi.InterfaceGroupRequest) *string { return &r.Descr }),
+	},
+	list: func(ctx context.Context, c *pfsenseapi.Client) ([]*pfsenseapi.InterfaceGroup, error) {
+		return c.Interface.ListInterfaceGroups(ctx)
+	},
+	get: func(ctx context.Context, c *pfsenseapi.Client, id int) (*pfsenseapi.InterfaceGroup, error) {
+		return c.Interface.GetInterfaceGroup(ctx, id)
+	},
+	create: func(ctx context.Context, c *pfsenseapi.Client, req pfsenseapi.InterfaceGroupRequest) (*pfsenseapi.InterfaceGroup, error) {
+		return c.Interface.CreateInterfaceGroup(ctx, req)
+	},
+	update: func(ctx context.Context, c *pfsenseapi.Client, id int, req pfsenseapi.InterfaceGroupRequest) (*pfsenseapi.InterfaceGroup, error) {
+		return c.Interface.UpdateInterfaceGroup(ctx, id, req)
+	},
+	delete: func(ctx context.Context, c *pfsenseapi.Client, id int) (*pfsenseapi.InterfaceGroup, error) {
+		return c.Interface.DeleteInterfaceGroup(ctx, id)
+	},
+}
+
+var interfaceBridgeCRUD = crud[pfsenseapi.InterfaceBridge, pfsenseapi.InterfaceBridgeRequest, string]{
+	noun:      "bridge",
+	article:   "a",
+	plural:    "bridges",
+	columns:   []string{"id", "bridgeif", "members", "descr"},
+	parseID:   parseStringID,
+	toRequest: func(v *pfsenseapi.InterfaceBridge) pfsenseapi.InterfaceBridgeRequest { return v.InterfaceBridgeRequest },
+	fields: []requestField[pfsenseapi.InterfaceBridgeRequest]{
+		listField("members", "member interfaces", func(r *pfsenseapi.InterfaceBridgeRequest) *pfsenseapi.FlexStringList { return &r.Members }),
+		stringField("descr", "description", func(r *pfsenseapi.InterfaceBridgeRequest) *string { return &r.Descr }),
+		stringField("bridgeif", "bridge interface name, e.g. bridge0", func(r *pfsenseapi.InterfaceBridgeRequest) *string { return &r.Bridgeif }),
+	},
+	list: func(ctx context.Context, c *pfsenseapi.Client) ([]*pfsenseapi.InterfaceBridge, error) {
+		return c.Interface.ListInterfaceBridges(ctx)
+	},
+	get: func(ctx context.Context, c *pfsenseapi.Client, id string) (*pfsenseapi.InterfaceBridge, error) {
+		return c.Interface.GetInterfaceBridge(ctx, id)
+	},
+	create: func(ctx context.Context, c *pfsenseapi.Client, req pfsenseapi.InterfaceBridgeRequest) (*pfsenseapi.InterfaceBridge, error) {
+		return c.Interface.CreateInterfaceBridge(ctx, req)
+	},
+	update: func(ctx context.Context, c *pfsenseapi.Client, id string, req pfsenseapi.InterfaceBridgeRequest) (*pfsenseapi.InterfaceBridge, error) {
+		return c.Interface.UpdateInterfaceBridge(ctx, id, req)
+	},
+	delete: func(ctx context.Context, c *pfsenseapi.Client, id string) (*pfsenseapi.InterfaceBridge, error) {
+		return c.Interface.DeleteInterfaceBridge(ctx, id)
+	},
+}
+
+var userCRUD = crud[pfsenseapi.User, pfsenseapi.UserRequest, int]{
+	noun:    "user",
+	article: "a",
+	plural:  "users",
+	columns: []string{"id", "uid", "name", "scope", "disabled", "descr", "priv"},
+	parseID: parseIntID,
+	toRequest: func(v *pfsenseapi.User) pfsenseapi.UserRequest {
+		// The firewall returns the password hash; never send it back as the
+		// new password.
+		req := v.UserRequest
+		req.Password = ""
+		return req
+	},
+	fields: []requestField[pfsenseapi.UserRequest]{
+		stringField("name", "username", func(r *pfsenseapi.UserRequest) *string { return &r.Name }),
+		stringField("password", "password", func(r *pfsenseapi.UserRequest) *string { return &r.Password }),
+		stringField("scope", "user scope", func(r *pfsenseapi.UserRequest) *string { return &r.Scope }),
+		listField("priv", "privileges", func(r *pfsenseapi.UserRequest) *pfsenseapi.FlexStringList { return &r.Priv }),
+		boolField("disabled", "disable the user", func(r *pfsenseapi.UserRequest) *pfsenseapi.FlexBool { return &r.Disabled }),
+		stringField("descr", "full name or description", func(r *pfsenseapi.UserRequest) *string { return &r.Descr }),
+		optStringField("expires", "expiration date (MM/DD/YYYY)", func(r *pfsenseapi.UserRequest) *optional.String { return &r.Expires }),
+		listField("cert", "certificate reference IDs", func(r *pfsenseapi.UserRequest) *pfsenseapi.FlexStringList { return &r.Cert }),
+		optStringField("authorizedkeys", "base64 encoded SSH authorized keys", func(r *pfsenseapi.UserRequest) *optional.String { return &r.AuthorizedKeys }),
+		optStringField("ipsecpsk", "IPsec pre-shared key", func(r *pfsenseapi.UserRequest) *optional.String { return &r.IPSecPSK }),
+	},
+	list: func(ctx context.Context, c *pfsenseapi.Client) ([]*pfsenseapi.User, error) {
+		return c.User.ListUsers(ctx)
+	},
+	get: func(ctx context.Context, c *pfsenseapi.Client, id int) (*pfsenseapi.User, error) {
+		return c.User.GetUser(ctx, id)
+	},
+	create: func(ctx context.Context, c *pfsenseapi.Client, req pfsenseapi.UserRequest) (*pfsenseapi.User, error) {
+		return c.User.CreateUser(ctx, req)
+	},
+	update: func(ctx context.Context, c *pfsenseapi.Client, id int, req pfsenseapi.UserRequest) (*pfsenseapi.User, error) {
+		return c.User.UpdateUser(ctx, id, req)
+	},
+	delete: func(ctx context.Context, c *pfsenseapi.Client, id int) (*pfsenseapi.User, error) {
+		return c.User.DeleteUser(ctx, id)
+	},
+}
+
+var userGroupCRUD = crud[pfsenseapi.UserGroup, pfsenseapi.UserGroupRequest, int]{
+	noun:      "user group",
+	article:   "a",
+	plural:    "user groups",
+	columns:   []string{"id", "gid", "name", "scope", "description", "member"},
+	parseID:   parseIntID,
+	toRequest: func(v *pfsenseapi.UserGroup) pfsenseapi.UserGroupRequest { return v.UserGroupRequest },
+	fields: []requestField[pfsenseapi.UserGroupRequest]{
+		stringField("name", "group name", func(r *pfsenseapi.UserGroupRequest) *string { return &r.Name }),
+		stringField("scope", "group scope", func(r *pfsenseapi.UserGroupRequest) *string { return &r.Scope }),
+		stringField("description", "description", func(r *pfsenseapi.UserGroupRequest) *string { return &r.Description }),
+		listField("member", "member user IDs", func(r *pfsenseapi.UserGroupRequest) *pfsenseapi.FlexStringList { return &r.Member }),
+		listField("priv", "privileges", func(r *pfsenseapi.UserGroupRequest) *pfsenseapi.FlexStringList { return &r.Priv }),
+	},
+	list: func(ctx context.Context, c *pfsenseapi.Client) ([]*pfsenseapi.UserGroup, error) {
+		return c.User.ListUserGroups(ctx)
+	},
+	get: func(ctx context.Context, c *pfsenseapi.Client, id int) (*pfsenseapi.UserGroup, error) {
+		return c.User.GetUserGroup(ctx, id)
+	},
+	create: func(ctx context.Context, c *pfsenseapi.Client, req pfsenseapi.UserGroupRequest) (*pfsenseapi.UserGroup, error) {
+		return c.User.CreateUserGroup(ctx, req)
+	},
+	update: func(ctx context.Context, c *pfsenseapi.Client, id int, req pfsenseapi.UserGroupRequest) (*pfsenseapi.UserGroup, error) {
+		return c.User.UpdateUserGroup(ctx, id, req)
+	},
+	delete: func(ctx context.Context, c *pfsenseapi.Client, id int) (*pfsenseapi.UserGroup, error) {
+		return c.User.DeleteUserGroup(ctx, id)
+	},
+}
+
+var firewallRuleCRUD = crud[pfsenseapi.FirewallRule, pfsenseapi.FirewallRuleRequest, int]{
+	noun:      "firewall rule",
+	article:   "a",
+	plural:    "firewall rules",
+	columns:   []string{"id", "type", "interface", "protocol", "source", "source_port", "destination", "destination_port", "descr", "disabled"},
+	parseID:   parseIntID,
+	toRequest: func(v *pfsenseapi.FirewallRule) pfsenseapi.FirewallRuleRequest { return v.FirewallRuleRequest },
+	fields: []requestField[pfsenseapi.FirewallRuleRequest]{
+		stringField("type", "action: pass, block or reject", func(r *pfsenseapi.FirewallRuleRequest) *pfsenseapi.RuleType { return &r.Type }),
+		listField("interface", "interfaces the rule applies to", func(r *pfsenseapi.FirewallRuleRequest) *[]string { return &r.Interface }),
+		stringField("ipprotocol", "address family: inet, inet6 or inet46", func(r *pfsenseapi.FirewallRuleRequest) *pfsenseapi.IPProtocol { return &r.Ipprotocol }),
+		stringField("protocol", "protocol, e.g. tcp, udp or icmp; empty for any", func(r *pfsenseapi.FirewallRuleRequest) *pfsenseapi.RuleProtocol { return &r.Protocol }),
+		addressField("source", "source address", func(r *pfsenseapi.FirewallRuleRequest) *pfsenseapi.RuleAddress { return &r.Source }),
+		optStringPtrField("source_port", "source port or range", func(r *pfsenseapi.FirewallRuleRequest) **optional.String { return &r.SourcePort }),
+		addressField("destination", "destination address", func(r *pfsenseapi.FirewallRuleRequest) *pfsenseapi.RuleAddress { return &r.Destination }),
+		optStringPtrField("destination_port", "destination port or range", func(r *pfsenseapi.FirewallRuleRequest) **optional.String { return &r.DestinationPort }),
+		optStringPtrField("descr", "description", func(r *pfsenseapi.FirewallRuleRequest) **optional.String { return &r.Descr }),
+		boolField("disabled", "disable the rule", func(r *pfsenseapi.FirewallRuleRequest) *bool { return &r.Disabled }),
+		boolField("log", "log matching packets", func(r *pfsenseapi.FirewallRuleRequest) *bool { return &r.Log }),
+		optStringPtrField("gateway", "gateway for policy routing", func(r *pfsenseapi.FirewallRuleRequest) **optional.String { return &r.Gateway }),
+		optStringPtrField("sched", "schedule name", func(r *pfsenseapi.FirewallRuleRequest) **optional.String { return &r.Sched }),
+		boolField("floating", "make the rule floating", func(r *pfsenseapi.FirewallRuleRequest) *bool { return &r.Floating }),
+		boolField("quick", "apply a floating rule immediately on match", func(r *pfsenseapi.FirewallRuleRequest) *bool { return &r.Quick }),
+		stringField("direction", "direction of a floating rule: any, in or out", func(r *pfsenseapi.FirewallRuleRequest) *pfsenseapi.RuleDirection { return &r.Direction }),
+	},
+	list: func(ctx context.Context, c *pfsenseapi.Client) ([]*pfsenseapi.FirewallRule, error) {
+		return c.Firewall.ListRules(ctx)
+	},
+	get: func(ctx context.Context, c *pfsenseapi.Client, id int) (*pfsenseapi.FirewallRule, error) {
+		return c.Firewall.GetRule(ctx, id)
+	},
+	create: func(ctx context.Context, c *pfsenseapi.Client, req pfsenseapi.FirewallRuleRequest) (*pfsenseapi.FirewallRule, error) {
+		return c.Firewall.CreateRule(ctx, req)
+	},
+	update: func(ctx context.Context, c *pfsenseapi.Client, id int, req pfsenseapi.FirewallRuleRequest) (*pfsenseapi.FirewallRule, error) {
+		return c.Firewall.UpdateRule(ctx, id, req)
+	},
+	delete: func(ctx context.Context, c *pfsenseapi.Client, id int) (*pfsenseapi.FirewallRule, error) {
+		return c.Firewall.DeleteRule(ctx, id)
+	},
+}
+
+var firewallAliasCRUD = crud[pfsenseapi.Alias, pfsenseapi.AliasRequest, int]{
+	noun:      "firewall alias",
+	article:   "a",
+	plural:    "firewall aliases",
+	columns:   []string{"id", "name", "type", "address", "descr"},
+	parseID:   parseIntID,
+	toRequest: func(v *pfsenseapi.Alias) pfsenseapi.AliasRequest { return v.AliasRequest },
+	fields: []requestField[pfsenseapi.AliasRequest]{
+		stringField("name", "alias name", func(r *pfsenseapi.AliasRequest) *string { return &r.Name }),
+		stringField("type", "alias type: host, network, port, url or urltable", func(r *pfsenseapi.AliasRequest) *pfsenseapi.AliasType { return &r.Type }),
+		optStringPtrField("descr", "description", func(r *pfsenseapi.AliasRequest) **optional.String { return &r.Descr }),
+		aliasAddressField("address", "entries of the alias, replacing the current ones"),
+		aliasDetailField("detail", "descriptions of the entries, in the same order"),
+	},
+	list: func(ctx context.Context, c *pfsenseapi.Client) ([]*pfsenseapi.Alias, error) {
+		return c.Firewall.ListAliases(ctx)
+	},
+	get: func(ctx context.Context, c *pfsenseapi.Client, id int) (*pfsenseapi.Alias, error) {
+		return c.Firewall.GetAlias(ctx, id)
+	},
+	create: func(ctx context.Context, c *pfsenseapi.Client, req pfsenseapi.AliasRequest) (*pfsenseapi.Alias, error) {
+		return c.Firewall.CreateAlias(ctx, req)
+	},
+	update: func(ctx context.Context, c *pfsenseapi.Client, id int, req pfsenseapi.AliasRequest) (*pfsenseapi.Alias, error) {
+		return c.Firewall.UpdateAlias(ctx, id, req)
+	},
+	delete: func(ctx context.Context, c *pfsenseapi.Client, id int) (*pfsenseapi.Alias, error) {
+		return c.Firewall.DeleteAlias(ctx, id)
+	},
+}
+
+var portForwardCRUD = crud[pfsenseapi.PortForward, pfsenseapi.PortForwardRequest, int]{
+	noun:      "port forward",
+	article:   "a",
+	plural:    "port forwards",
+	columns:   []string{"id", "interface", "protocol", "source", "destination", "destination_port", "target", "local_port", "descr", "associated_rule_id"},
+	parseID:   parseIntID,
+	toRequest: func(v *pfsenseapi.PortForward) pfsenseapi.PortForwardRequest { return v.PortForwardRequest },
+	fields: []requestField[pfsenseapi.PortForwardRequest]{
+		stringField("interface", "interface the traffic arrives on", func(r *pfsenseapi.PortForwardRequest) *string { return &r.Interface }),
+		stringField("ipprotocol", "address family: inet, inet6 or inet46", func(r *pfsenseapi.PortForwardRequest) *pfsenseapi.IPProtocol { return &r.Ipprotocol }),
+		stringField("protocol", "protocol, e.g. tcp, udp or tcp/udp", func(r *pfsenseapi.PortForwardRequest) *pfsenseapi.RuleProtocol { return &r.Protocol }),
+		addressField("source", "source address", func(r *pfsenseapi.PortForwardRequest) *pfsenseapi.RuleAddress { return &r.Source }),
+		optStringPtrField("source_port", "source port or range", func(r *pfsenseapi.PortForwardRequest) **optional.String { return &r.SourcePort }),
+		addressField("destination", "external address", func(r *pfsenseapi.PortForwardRequest) *pfsenseapi.RuleAddress { return &r.Destination }),
+		optStringPtrField("destination_port", "external port or range", func(r *pfsenseapi.PortForwardRequest) **optional.String { return &r.DestinationPort }),
+		stringField("target", "internal address to forward to", func(r *pfsenseapi.PortForwardRequest) *string { return &r.Target }),
+		optStringPtrField("local_port", "internal port to forward to", func(r *pfsenseapi.PortForwardRequest) **optional.String { return &r.LocalPort }),
+		boolField("disabled", "disable the port forward", func(r *pfsenseapi.PortForwardRequest) *bool { return &r.Disabled }),
+		boolField("nordr", "exclude the traffic from redirection", func(r *pfsenseapi.PortForwardRequest) *bool { return &r.Nordr }),
+		boolField("nosync", "do not sync to HA peers", func(r *pfsenseapi.PortForwardRequest) *bool { return &r.Nosync }),
+		optStringPtrField("descr", "description", func(r *pfsenseapi.PortForwardRequest) **optional.String { return &r.Descr }),
+		stringField("natreflection", "NAT reflection: enable, disable or purenat; empty for the system default", func(r *pfsenseapi.PortForwardRequest) *pfsenseapi.NATReflection { return &r.Natreflection }),
+		stringField("associated_rule", "filter rule to add on create: new, pass or empty for none", func(r *pfsenseapi.PortForwardRequest) *pfsenseapi.AssociatedRule { return &r.AssociatedRule }),
+	},
+	list: func(ctx context.Context, c *pfsenseapi.Client) ([]*pfsenseapi.PortForward, error) {
+		return c.NAT.ListPortForwards(ctx)
+	},
+	get: func(ctx context.Context, c *pfsenseapi.Client, id int) (*pfsenseapi.PortForward, error) {
+		return c.NAT.GetPortForward(ctx, id)
+	},
+	create: func(ctx context.Context, c *pfsenseapi.Client, req pfsenseapi.PortForwardRequest) (*pfsenseapi.PortForward, error) {
+		return c.NAT.CreatePortForward(ctx, req)
+	},
+	update: func(ctx context.Context, c *pfsenseapi.Client, id int, req pfsenseapi.PortForwardRequest) (*pfsenseapi.PortForward, error) {
+		return c.NAT.UpdatePortForward(ctx, id, req)
+	},
+	delete: func(ctx context.Context, c *pfsenseapi.Client, id int) (*pfsenseapi.PortForward, error) {
+		return c.NAT.DeletePortForward(ctx, id)
+	},
+}
+
+var outboundMappingCRUD = crud[pfsenseapi.OutboundMapping, pfsenseapi.OutboundMappingRequest, int]{
+	noun:      "outbound NAT mapping",
+	article:   "an",
+	plural:    "outbound NAT mappings",
+	columns:   []string{"id", "interface", "protocol", "source", "destination", "target", "static_nat_port", "nonat", "poolopts", "descr"},
+	parseID:   parseIntID,
+	toRequest: func(v *pfsenseapi.OutboundMapping) pfsenseapi.OutboundMappingRequest { return v.OutboundMappingRequest },
+	fields: []requestField[pfsenseapi.OutboundMappingRequest]{
+		stringField("interface", "interface the traffic leaves on", func(r *pfsenseapi.OutboundMappingRequest) *string { return &r.Interface }),
+		stringField("protocol", "protocol, e.g. tcp or udp; empty for any", func(r *pfsenseapi.OutboundMappingRequest) *pfsenseapi.RuleProtocol { return &r.Protocol }),
+		addressField("source", "source network", func(r *pfsenseapi.OutboundMappingRequest) *pfsenseapi.RuleAddress { return &r.Source }),
+		optStringPtrField("source_port", "source port or range", func(r *pfsenseapi.OutboundMappingRequest) **optional.String { return &r.SourcePort }),
+		addressField("destination", "destination network", func(r *pfsenseapi.OutboundMappingRequest) *pfsenseapi.RuleAddress { return &r.Destination }),
+		optStringPtrField("destination_port", "destination port or range", func(r *pfsenseapi.OutboundMappingRequest) **optional.String { return &r.DestinationPort }),
+		optStringPtrField("target", "translation address; empty for the interface address", func(r *pfsenseapi.OutboundMappingRequest) **optional.String { return &r.Target }),
+		optIntPtrField("target_subnet", "prefix length of the translation address", func(r *pfsenseapi.OutboundMappingRequest) **optional.Int { return &r.TargetSubnet }),
+		optStringPtrField("nat_port", "translation port or range", func(r *pfsenseapi.OutboundMappingRequest) **optional.String { return &r.NatPort }),
+		boolField("static_nat_port", "keep the source port", func(r *pfsenseapi.OutboundMappingRequest) *bool { return &r.StaticNatPort }),
+		boolField("nonat", "exclude the traffic from translation", func(r *pfsenseapi.OutboundMappingRequest) *bool { return &r.Nonat }),
+		boolField("disabled", "disable the mapping", func(r *pfsenseapi.OutboundMappingRequest) *bool { return &r.Disabled }),
+		boolField("nosync", "do not sync to HA peers", func(r *pfsenseapi.OutboundMappingRequest) *bool { return &r.Nosync }),
+		stringField("poolopts", "pool option, e.g. round-robin or source-hash", func(r *pfsenseapi.OutboundMappingRequest) *pfsenseapi.PoolOption { return &r.Poolopts }),
+		optStringPtrField("source_hash_key", "key for the source-hash pool option", func(r *pfsenseapi.OutboundMappingRequest) **optional.String { return &r.SourceHashKey }),
+		optStringPtrField("descr", "description", func(r *pfsenseapi.OutboundMappingRequest) **optional.String { return &r.Descr }),
+	},
+	list: func(ctx context.Context, c *pfsenseapi.Client) ([]*pfsenseapi.OutboundMapping, error) {
+		return c.NAT.ListOutboundMappings(ctx)
+	},
+	get: func(ctx context.Context, c *pfsenseapi.Client, id int) (*pfsenseapi.OutboundMapping, error) {
+		return c.NAT.GetOutboundMapping(ctx, id)
+	},
+	create: func(ctx context.Context, c *pfsenseapi.Client, req pfsenseapi.OutboundMappingRequest) (*pfsenseapi.OutboundMapping, error) {
+		return c.NAT.CreateOutboundMapping(ctx, req)
+	},
+	update: func(ctx context.Context, c *pfsenseapi.Client, id int, req pfsenseapi.OutboundMappingRequest) (*pfsenseapi.OutboundMapping, error) {
+		return c.NAT.UpdateOutboundMapping(ctx, id, req)
+	},
+	delete: func(ctx context.Context, c *pfsenseapi.Client, id int) (*pfsenseapi.OutboundMapping, error) {
+		return c.NAT.DeleteOutboundMapping(ctx, id)
+	},
+}
+
+var oneToOneMappingCRUD = crud[pfsenseapi.OneToOneMapping, pfsenseapi.OneToOneMappingRequest, int]{
+	noun:      "1:1 NAT mapping",
+	article:   "a",
+	plural:    "1:1 NAT mappings",
+	columns:   []string{"id", "interface", "external", "source", "destination", "natreflection", "disabled", "descr"},
+	parseID:   parseIntID,
+	toRequest: func(v *pfsenseapi.OneToOneMapping) pfsenseapi.OneToOneMappingRequest { return v.OneToOneMappingRequest },
+	fields: []requestField[pfsenseapi.OneToOneMappingRequest]{
+		stringField("interface", "interface the external addresses are on", func(r *pfsenseapi.OneToOneMappingRequest) *string { return &r.Interface }),
+		stringField("ipprotocol", "address family: inet or inet6", func(r *pfsenseapi.OneToOneMappingRequest) *pfsenseapi.IPProtocol { return &r.Ipprotocol }),
+		stringField("external", "first external address of the block", func(r *pfsenseapi.OneToOneMappingRequest) *string { return &r.External }),
+		addressField("source", "internal address or subnet", func(r *pfsenseapi.OneToOneMappingRequest) *pfsenseapi.RuleAddress { return &r.Source }),
+		addressField("destination", "destination the mapping applies to", func(r *pfsenseapi.OneToOneMappingRequest) *pfsenseapi.RuleAddress { return &r.Destination }),
+		boolField("disabled", "disable the mapping", func(r *pfsenseapi.OneToOneMappingRequest) *bool { return &r.Disabled }),
+		boolField("nobinat", "exclude the traffic from the mapping", func(r *pfsenseapi.OneToOneMappingRequest) *bool { return &r.Nobinat }),
+		stringField("natreflection", "NAT reflection: enable or disable; empty for the system default", func(r *pfsenseapi.OneToOneMappingRequest) *pfsenseapi.NATReflection { return &r.Natreflection }),
+		optStringPtrField("descr", "description", func(r *pfsenseapi.OneToOneMappingRequest) **optional.String { return &r.Descr }),
+	},
+	list: func(ctx context.Context, c *pfsenseapi.Client) ([]*pfsenseapi.OneToOneMapping, error) {
+		return c.NAT.ListOneToOneMappings(ctx)
+	},
+	get: func(ctx context.Context, c *pfsenseapi.Client, id int) (*pfsenseapi.OneToOneMapping, error) {
+		return c.NAT.GetOneToOneMapping(ctx, id)
+	},
+	create: func(ctx context.Context, c *pfsenseapi.Client, req pfsenseapi.OneToOneMappingRequest) (*pfsenseapi.OneToOneMapping, error) {
+		return c.NAT.CreateOneToOneMapping(ctx, req)
+	},
+	update: func(ctx context.Context, c *pfsenseapi.Client, id int, req pfsenseapi.OneToOneMappingRequest) (*pfsenseapi.OneToOneMapping, error) {
+		return c.NAT.UpdateOneToOneMapping(ctx, id, req)
+	},
+	delete: func(ctx context.Context, c *pfsenseapi.Client, id int) (*pfsenseapi.OneToOneMapping, error) {
+		return c.NAT.DeleteOneToOneMapping(ctx, id)
+	},
+}
+
+var scheduleCRUD = crud[pfsenseapi.Schedule, pfsenseapi.ScheduleRequest, int]{
+	noun:      "schedule",
+	article:   "a",
+	plural:    "schedules",
+	columns:   []string{"id", "name", "descr", "timerange", "active"},
+	parseID:   parseIntID,
+	toRequest: func(v *pfsenseapi.Schedule) pfsenseapi.ScheduleRequest { return v.ScheduleRequest },
+	fields: []requestField[pfsenseapi.ScheduleRequest]{
+		stringField("name", "schedule name, as referenced by rules", func(r *pfsenseapi.ScheduleRequest) *string { return &r.Name }),
+		optStringPtrField("descr", "description", func(r *pfsenseapi.ScheduleRequest) **optional.String { return &r.Descr }),
+		scheduleRangeField("range", "time ranges, replacing the existing ones"),
+	},
+	list: func(ctx context.Context, c *pfsenseapi.Client) ([]*pfsenseapi.Schedule, error) {
+		return c.Firewall.ListSchedules(ctx)
+	},
+	get: func(ctx context.Context, c *pfsenseapi.Client, id int) (*pfsenseapi.Schedule, error) {
+		return c.Firewall.GetSchedule(ctx, id)
+	},
+	create: func(ctx context.Context, c *pfsenseapi.Client, req pfsenseapi.ScheduleRequest) (*pfsenseapi.Schedule, error) {
+		return c.Firewall.CreateSchedule(ctx, req)
+	},
+	update: func(ctx context.Context, c *pfsenseapi.Client, id int, req pfsenseapi.ScheduleRequest) (*pfsenseapi.Schedule, error) {
+		return c.Firewall.UpdateSchedule(ctx, id, req)
+	},
+	delete: func(ctx context.Context, c *pfsenseapi.Client, id int) (*pfsenseapi.Schedule, error) {
+		return c.Firewall.DeleteSchedule(ctx, id)
+	},
+}
+
+var stateColumns = []string{"id", "interface", "protocol", "direction", "source", "destination", "state", "age", "bytes_total"}
+
+var stateFilterFlags = []flagDef{
+	{name: "interface", usage: "interface the states are on"},
+	{name: "protocol", usage: "protocol, e.g. tcp or udp"},
+	{name: "source", usage: "source address; matches any source port"},
+	{name: "destination", usage: "destination address; matches any destination port"},
+}
+
+func stateFilter(values fieldValues) pfsenseapi.StateFilter {
+	return pfsenseapi.StateFilter{
+		Interface:   values["interface"],
+		Protocol:    values["protocol"],
+		Source:      values["source"],
+		Destination: values["destination"],
+	}
+}
+
+var virtualIPCRUD = crud[pfsenseapi.VirtualIP, pfsenseapi.VirtualIPRequest, int]{
+	noun:      "virtual IP",
+	article:   "a",
+	plural:    "virtual IPs",
+	columns:   []string{"id", "mode", "interface", "subnet", "subnet_bits", "vhid", "advskew", "descr"},
+	parseID:   parseIntID,
+	toRequest: func(v *pfsenseapi.VirtualIP) pfsenseapi.VirtualIPRequest { return v.VirtualIPRequest },
+	fields: []requestField[pfsenseapi.VirtualIPRequest]{
+		stringField("mode", "kind of virtual IP: carp, ipalias, proxyarp or other", func(r *pfsenseapi.VirtualIPRequest) *pfsenseapi.VirtualIPMode { return &r.Mode }),
+		stringField("interface", "interface the address is on", func(r *pfsenseapi.VirtualIPRequest) *string { return &r.Interface }),
+		stringField("type", "single or network; network only for proxyarp and other", func(r *pfsenseapi.VirtualIPRequest) *pfsenseapi.VirtualIPType { return &r.Type }),
+		stringField("subnet", "IP address", func(r *pfsenseapi.VirtualIPRequest) *string { return &r.Subnet }),
+		intField("subnet_bits", "prefix length", func(r *pfsenseapi.VirtualIPRequest) *int { return &r.SubnetBits }),
+		optStringPtrField("descr", "description", func(r *pfsenseapi.VirtualIPRequest) **optional.String { return &r.Descr }),
+		boolField("noexpand", "do not expand a network into single addresses", func(r *pfsenseapi.VirtualIPRequest) *bool { return &r.Noexpand }),
+		optIntPtrField("vhid", "CARP virtual host ID, unique per interface", func(r *pfsenseapi.VirtualIPRequest) **optional.Int { return &r.Vhid }),
+		optIntPtrField("advbase", "CARP advertisement base in seconds", func(r *pfsenseapi.VirtualIPRequest) **optional.Int { return &r.Advbase }),
+		optIntPtrField("advskew", "CARP advertisement skew; the lowest becomes master", func(r *pfsenseapi.VirtualIPRequest) **optional.Int { return &r.Advskew }),
+		stringField("carp_password", "CARP password, the same on every node", func(r *pfsenseapi.VirtualIPRequest) *string { return &r.Password }),
+	},
+	list: func(ctx context.Context, c *pfsenseapi.Client) ([]*pfsenseapi.VirtualIP, error) {
+		return c.VirtualIP.ListVirtualIPs(ctx)
+	},
+	get: func(ctx context.Context, c *pfsenseapi.Client, id int) (*pfsenseapi.VirtualIP, error) {
+		return c.VirtualIP.GetVirtualIP(ctx, id)
+	},
+	create: func(ctx context.Context, c *pfsenseapi.Client, req pfsenseapi.VirtualIPRequest) (*pfsenseapi.VirtualIP, error) {
+		return c.VirtualIP.CreateVirtualIP(ctx, req)
+	},
+	update: func(ctx context.Context, c *pfsenseapi.Client, id int, req pfsenseapi.VirtualIPRequest) (*pfsenseapi.VirtualIP, error) {
+		return c.VirtualIP.UpdateVirtualIP(ctx, id, req)
+	},
+	delete: func(ctx context.Context, c *pfsenseapi.Client, id int) (*pfsenseapi.VirtualIP, error) {
+		return c.VirtualIP.DeleteVirtualIP(ctx, id)
+	},
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// writeCompletion writes the completion script for the shell named in args.
+// The scripts are generated from the command tree so they never drift from
+// the commands that actually exist.
+func writeCompletion(w io.Writer, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: pfsensectl completion bash|zsh")
+	}
+
+	switch args[0] {
+	case "bash":
+		return writeBashCompletion(w)
+	case "zsh":
+		_, err := fmt.Fprint(w, "#compdef pfsensectl\n\nautoload -U +X bashcompinit && bashcompinit\n\n")
+		if err != nil {
+			return err
+		}
+		return writeBashCompletion(w)
+	default:
+		return fmt.Errorf("unsupported shell %q: use bash or zsh", args[0])
+	}
+}
+
+func writeBashCompletion(w io.Writer) error {
+	var b strings.Builder
+
+	globalFlags := flagNames(func(fs *flag.FlagSet) { new(options).register(fs) })
+
+	b.WriteString("# bash completion for pfsensectl\n")
+	b.WriteString("_pfsensectl() {\n")
+	b.WriteString("    local cur words=()\n")
+	b.WriteString("    cur=\"${COMP_WORDS[COMP_CWORD]}\"\n")
+	b.WriteString("    local i\n")
+	b.WriteString("    for ((i = 1; i < COMP_CWORD; i++)); do\n")
+	b.WriteString("        [[ ${COMP_WORDS[i]} != -* ]] && words+=(\"${COMP_WORDS[i]}\")\n")
+	b.WriteString("    done\n\n")
+
+	fmt.Fprintf(&b, "    if [[ $cur == -* ]]; then\n")
+	b.WriteString("        case \"${words[0]} ${words[1]}\" in\n")
+	for _, res := range resources {
+		for _, cmd := range res.commands {
+			names := append([]string{}, globalFlags...)
+			for _, def := range cmd.flags {
+				names = append(names, "--"+def.name)
+			}
+			fmt.Fprintf(&b, "            %q) COMPREPLY=($(compgen -W %q -- \"$cur\")) ;;\n",
+				res.name+" "+cmd.name, strings.Join(names, " "))
+		}
+	}
+	fmt.Fprintf(&b, "            *) COMPREPLY=($(compgen -W %q -- \"$cur\")) ;;\n", strings.Join(globalFlags, " "))
+	b.WriteString("        esac\n")
+	b.WriteString("        return\n")
+	b.WriteString("    fi\n\n")
+
+	b.WriteString("    case ${#words[@]} in\n")
+	fmt.Fprintf(&b, "        0) COMPREPLY=($(compgen -W %q -- \"$cur\")) ;;\n",
+		strings.Join(append(resourceNames(), "completion"), " "))
+	b.WriteString("        1)\n")
+	b.WriteString("            case \"${words[0]}\" in\n")
+	for _, res := range resources {
+		names := make([]string, 0, len(res.commands))
+		for _, cmd := range res.commands {
+			names = append(names, cmd.name)
+		}
+		fmt.Fprintf(&b, "                %s) COMPREPLY=($(compgen -W %q -- \"$cur\")) ;;\n", res.name, strings.Join(names, " "))
+	}
+	b.WriteString("                completion) COMPREPLY=($(compgen -W \"bash zsh\" -- \"$cur\")) ;;\n")
+	b.WriteString("            esac\n")
+	b.WriteString("            ;;\n")
+	b.WriteString("    esac\n")
+	b.WriteString("}\n\n")
+	b.WriteString("complete -F _pfsensectl pfsensectl\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// flagNames returns the names, with their leading dashes, of the flags that
+// register adds to a flag set.
+func flagNames(register func(fs *flag.FlagSet)) []string {
+	fs := flag.NewFlagSet("", flag.ContinueOnError)
+	register(fs)
+
+	var names []string
+	fs.VisitAll(func(f *flag.Flag) {
+		if len(f.Name) == 1 {
+			names = append(names, "-"+f.Name)
+		} else {
+			names = append(names, "--"+f.Name)
+		}
+	})
+	return names
+}
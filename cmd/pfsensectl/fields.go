@@ -0,0 +1,435 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/markphelps/optional"
+
+	"github.com/sjafferali/pfsense-api-goclient/v2/pfsenseapi"
+)
+
+// fieldValues holds the raw values of the request field flags that were set
+// on the command line, keyed by flag name.
+type fieldValues map[string]string
+
+// flagDef describes a request field flag.
+type flagDef struct {
+	name   string
+	usage  string
+	isBool bool
+}
+
+// command is a single verb of a resource, e.g. the "list" in "vlan list".
+type command struct {
+	name    string
+	usage   string
+	args    []string
+	columns []string
+	flags   []flagDef
+	run     func(ctx context.Context, client *pfsenseapi.Client, args []string, values fieldValues) (any, error)
+}
+
+// registerFields adds the command's request field flags to fs and returns
+// the map their values are collected into.
+func (c *command) registerFields(fs *flag.FlagSet) fieldValues {
+	values := make(fieldValues)
+	for _, def := range c.flags {
+		name := def.name
+		collect := func(v string) error {
+			values[name] = v
+			return nil
+		}
+		if def.isBool {
+			fs.BoolFunc(name, def.usage, collect)
+		} else {
+			fs.Func(name, def.usage, collect)
+		}
+	}
+	return values
+}
+
+// resource groups the commands for one kind of object.
+type resource struct {
+	name     string
+	usage    string
+	commands []*command
+}
+
+func (r *resource) find(name string) (*command, bool) {
+	for _, cmd := range r.commands {
+		if cmd.name == name {
+			return cmd, true
+		}
+	}
+	return nil, false
+}
+
+func findResource(name string) (*resource, bool) {
+	for _, res := range resources {
+		if res.name == name {
+			return res, true
+		}
+	}
+	return nil, false
+}
+
+// requestField binds a command line flag to a field of the request type R.
+type requestField[R any] struct {
+	flagDef
+	set func(req *R, value string) error
+}
+
+func flagDefs[R any](fields []requestField[R]) []flagDef {
+	defs := make([]flagDef, 0, len(fields))
+	for _, f := range fields {
+		defs = append(defs, f.flagDef)
+	}
+	return defs
+}
+
+// applyFields sets every field of req whose flag was given.
+func applyFields[R any](req *R, fields []requestField[R], values fieldValues) error {
+	for _, f := range fields {
+		value, ok := values[f.name]
+		if !ok {
+			continue
+		}
+		if err := f.set(req, value); err != nil {
+			return fmt.Errorf("invalid value %q for --%s: %w", value, f.name, err)
+		}
+	}
+	return nil
+}
+
+func stringField[R any, S ~string](name, usage string, target func(*R) *S) requestField[R] {
+	return requestField[R]{
+		flagDef: flagDef{name: name, usage: usage},
+		set: func(req *R, value string) error {
+			*target(req) = S(value)
+			return nil
+		},
+	}
+}
+
+func intField[R any](name, usage string, target func(*R) *int) requestField[R] {
+	return requestField[R]{
+		flagDef: flagDef{name: name, usage: usage},
+		set: func(req *R, value string) error {
+			v, err := strconv.Atoi(value)
+			if err != nil {
+				return err
+			}
+			*target(req) = v
+			return nil
+		},
+	}
+}
+
+func int32Field[R any, I ~int32](name, usage string, target func(*R) *I) requestField[R] {
+	return requestField[R]{
+		flagDef: flagDef{name: name, usage: usage},
+		set: func(req *R, value string) error {
+			v, err := strconv.ParseInt(value, 10, 32)
+			if err != nil {
+				return err
+			}
+			*target(req) = I(v)
+			return nil
+		},
+	}
+}
+
+func boolField[R any, B ~bool](name, usage string, target func(*R) *B) requestField[R] {
+	return requestField[R]{
+		flagDef: flagDef{name: name, usage: usage, isBool: true},
+		set: func(req *R, value string) error {
+			v, err := strconv.ParseBool(value)
+			if err != nil {
+				return err
+			}
+			*target(req) = B(v)
+			return nil
+		},
+	}
+}
+
+func listField[R any, L ~[]string](name, usage string, target func(*R) *L) requestField[R] {
+	return requestField[R]{
+		flagDef: flagDef{name: name, usage: usage + " (comma separated)"},
+		set: func(req *R, value string) error {
+			*target(req) = L(splitList(value))
+			return nil
+		},
+	}
+}
+
+func optStringField[R any](name, usage string, target func(*R) *optional.String) requestField[R] {
+	return requestField[R]{
+		flagDef: flagDef{name: name, usage: usage},
+		set: func(req *R, value string) error {
+			*target(req) = optional.NewString(value)
+			return nil
+		},
+	}
+}
+
+func optStringPtrField[R any](name, usage string, target func(*R) **optional.String) requestField[R] {
+	return requestField[R]{
+		flagDef: flagDef{name: name, usage: usage},
+		set: func(req *R, value string) error {
+			v := optional.NewString(value)
+			*target(req) = &v
+			return nil
+		},
+	}
+}
+
+func optIntPtrField[R any](name, usage string, target func(*R) **optional.Int) requestField[R] {
+	return requestField[R]{
+		flagDef: flagDef{name: name, usage: usage},
+		set: func(req *R, value string) error {
+			i, err := strconv.Atoi(value)
+			if err != nil {
+				return err
+			}
+			v := optional.NewInt(i)
+			*target(req) = &v
+			return nil
+		},
+	}
+}
+
+func optBoolPtrField[R any](name, usage string, target func(*R) **pfsenseapi.FlexOptionalBool) requestField[R] {
+	return requestField[R]{
+		flagDef: flagDef{name: name, usage: usage, isBool: true},
+		set: func(req *R, value string) error {
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				return err
+			}
+			v := pfsenseapi.NewFlexOptionalBool(b)
+			*target(req) = &v
+			return nil
+		},
+	}
+}
+
+func optInt32PtrField[R any](name, usage string, target func(*R) **pfsenseapi.FlexOptionalInt32) requestField[R] {
+	return requestField[R]{
+		flagDef: flagDef{name: name, usage: usage},
+		set: func(req *R, value string) error {
+			i, err := strconv.ParseInt(value, 10, 32)
+			if err != nil {
+				return err
+			}
+			v := pfsenseapi.NewFlexOptionalInt32(int32(i))
+			*target(req) = &v
+			return nil
+		},
+	}
+}
+
+func addressField[R any](name, usage string, target func(*R) *pfsenseapi.RuleAddress) requestField[R] {
+	return requestField[R]{
+		flagDef: flagDef{name: name, usage: usage + ", prefixed with ! to invert"},
+		set: func(req *R, value string) error {
+			*target(req) = pfsenseapi.ParseRuleAddress(value)
+			return nil
+		},
+	}
+}
+
+// aliasAddressField replaces the entries of an alias with the listed
+// addresses, dropping their details.
+func aliasAddressField(name, usage string) requestField[pfsenseapi.AliasRequest] {
+	return requestField[pfsenseapi.AliasRequest]{
+		flagDef: flagDef{name: name, usage: usage + " (comma separated)"},
+		set: func(req *pfsenseapi.AliasRequest, value string) error {
+			addresses := splitList(value)
+			req.Entries = make([]pfsenseapi.AliasEntry, len(addresses))
+			for i, address := range addresses {
+				req.Entries[i] = pfsenseapi.AliasEntry{Address: address}
+			}
+			return nil
+		},
+	}
+}
+
+// aliasDetailField sets the details of the entries of an alias in order. It
+// is applied after aliasAddressField, so both can be given together.
+func aliasDetailField(name, usage string) requestField[pfsenseapi.AliasRequest] {
+	return requestField[pfsenseapi.AliasRequest]{
+		flagDef: flagDef{name: name, usage: usage + " (comma separated)"},
+		set: func(req *pfsenseapi.AliasRequest, value string) error {
+			details := splitList(value)
+			if len(details) > len(req.Entries) {
+				return fmt.Errorf("%d details given for %d entries", len(details), len(req.Entries))
+			}
+			for i := range req.Entries {
+				req.Entries[i].Detail = ""
+				if i < len(details) {
+					req.Entries[i].Detail = details[i]
+				}
+			}
+			return nil
+		},
+	}
+}
+
+// scheduleRangeField replaces the time ranges of a schedule. Ranges are
+// separated by semicolons, each written as weekdays numbered from 1 for
+// Monday to 7 for Sunday followed by the hours, e.g. "1,2,3,4,5 9:00-17:00".
+func scheduleRangeField(name, usage string) requestField[pfsenseapi.ScheduleRequest] {
+	return requestField[pfsenseapi.ScheduleRequest]{
+		flagDef: flagDef{name: name, usage: usage + ` (e.g. "1,2,3,4,5 9:00-17:00; 6 10:00-14:00")`},
+		set: func(req *pfsenseapi.ScheduleRequest, value string) error {
+			req.Timerange = []pfsenseapi.ScheduleTimeRange{}
+			for _, spec := range strings.Split(value, ";") {
+				days, hours, ok := strings.Cut(strings.TrimSpace(spec), " ")
+				if !ok {
+					return fmt.Errorf("invalid time range %q: want weekdays and hours", spec)
+				}
+				encoded, err := json.Marshal(map[string]string{
+					"position": days,
+					"hour":     strings.TrimSpace(hours),
+				})
+				if err != nil {
+					return err
+				}
+				var timeRange pfsenseapi.ScheduleTimeRange
+				if err := json.Unmarshal(encoded, &timeRange); err != nil {
+					return fmt.Errorf("invalid time range %q: %w", spec, err)
+				}
+				req.Timerange = append(req.Timerange, timeRange)
+			}
+			return nil
+		},
+	}
+}
+
+func splitList(value string) []string {
+	if value == "" {
+		return []string{}
+	}
+
+	parts := strings.Split(value, ",")
+	for i, part := range parts {
+		parts[i] = strings.TrimSpace(part)
+	}
+	return parts
+}
+
+// crud builds the standard list, get, create, update and delete commands for
+// a resource with model type T, request type R and id type ID.
+type crud[T any, R any, ID any] struct {
+	// noun names a single resource in usage strings; article is the
+	// indefinite article that goes with it and plural its plural form.
+	noun, article, plural string
+
+	columns   []string
+	fields    []requestField[R]
+	parseID   func(string) (ID, error)
+	toRequest func(*T) R
+
+	list   func(ctx context.Context, c *pfsenseapi.Client) ([]*T, error)
+	get    func(ctx context.Context, c *pfsenseapi.Client, id ID) (*T, error)
+	create func(ctx context.Context, c *pfsenseapi.Client, req R) (*T, error)
+	update func(ctx context.Context, c *pfsenseapi.Client, id ID, req R) (*T, error)
+	delete func(ctx context.Context, c *pfsenseapi.Client, id ID) (*T, error)
+}
+
+func (r crud[T, R, ID]) commands() []*command {
+	return []*command{
+		{
+			name:    "list",
+			usage:   "List all " + r.plural,
+			columns: r.columns,
+			run: func(ctx context.Context, c *pfsenseapi.Client, _ []string, _ fieldValues) (any, error) {
+				return r.list(ctx, c)
+			},
+		},
+		{
+			name:    "get",
+			usage:   "Show a single " + r.noun,
+			args:    []string{"id"},
+			columns: r.columns,
+			run: func(ctx context.Context, c *pfsenseapi.Client, args []string, _ fieldValues) (any, error) {
+				id, err := r.parseID(args[0])
+				if err != nil {
+					return nil, err
+				}
+				return r.get(ctx, c, id)
+			},
+		},
+		{
+			name:    "create",
+			usage:   fmt.Sprintf("Create %s %s", r.article, r.noun),
+			columns: r.columns,
+			flags:   flagDefs(r.fields),
+			run: func(ctx context.Context, c *pfsenseapi.Client, _ []string, values fieldValues) (any, error) {
+				var req R
+				if err := applyFields(&req, r.fields, values); err != nil {
+					return nil, err
+				}
+				return r.create(ctx, c, req)
+			},
+		},
+		{
+			name:    "update",
+			usage:   fmt.Sprintf("Update %s %s; fields not given keep their current value", r.article, r.noun),
+			args:    []string{"id"},
+			columns: r.columns,
+			flags:   flagDefs(r.fields),
+			run: func(ctx context.Context, c *pfsenseapi.Client, args []string, values fieldValues) (any, error) {
+				id, err := r.parseID(args[0])
+				if err != nil {
+					return nil, err
+				}
+
+				current, err := r.get(ctx, c, id)
+				if err != nil {
+					return nil, err
+				}
+				if current == nil {
+					return nil, fmt.Errorf("%s %s not found", r.noun, args[0])
+				}
+
+				req := r.toRequest(current)
+				if err = applyFields(&req, r.fields, values); err != nil {
+					return nil, err
+				}
+				return r.update(ctx, c, id, req)
+			},
+		},
+		{
+			name:    "delete",
+			usage:   fmt.Sprintf("Delete %s %s", r.article, r.noun),
+			args:    []string{"id"},
+			columns: r.columns,
+			run: func(ctx context.Context, c *pfsenseapi.Client, args []string, _ fieldValues) (any, error) {
+				id, err := r.parseID(args[0])
+				if err != nil {
+					return nil, err
+				}
+				return r.delete(ctx, c, id)
+			},
+		},
+	}
+}
+
+func parseStringID(s string) (string, error) {
+	return s, nil
+}
+
+func parseIntID(s string) (int, error) {
+	id, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid id %q: must be a number", s)
+	}
+	return id, nil
+}
@@ -0,0 +1,198 @@
+// Command pfsensectl exposes the pfsenseapi services on the command line.
+//
+// Usage:
+//
+//	pfsensectl [global flags] <resource> <command> [flags] [args]
+//
+// For example:
+//
+//	pfsensectl vlan list -o json
+//	pfsensectl user create --name alice --password secret
+//	pfsensectl interface apply
+//	pfsensectl vlan delete 3 --dry-run
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+
+	"github.com/sjafferali/pfsense-api-goclient/v2/pfsenseapi"
+)
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	os.Exit(run(ctx, os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run executes the command line given in args and returns the process exit
+// code.
+func run(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	opts := new(options)
+
+	fs := flag.NewFlagSet("pfsensectl", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	opts.register(fs)
+	fs.Usage = func() { printUsage(stderr, fs) }
+
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+
+	args = fs.Args()
+	if len(args) == 0 {
+		printUsage(stderr, fs)
+		return 2
+	}
+
+	if args[0] == "completion" {
+		if err := writeCompletion(stdout, args[1:]); err != nil {
+			fmt.Fprintf(stderr, "error: %v\n", err)
+			return 2
+		}
+		return 0
+	}
+
+	res, ok := findResource(args[0])
+	if !ok {
+		fmt.Fprintf(stderr, "error: unknown resource %q\n\n", args[0])
+		printUsage(stderr, fs)
+		return 2
+	}
+
+	if len(args) < 2 {
+		printResourceUsage(stderr, res)
+		return 2
+	}
+
+	cmd, ok := res.find(args[1])
+	if !ok {
+		fmt.Fprintf(stderr, "error: unknown command %q for resource %q\n\n", args[1], res.name)
+		printResourceUsage(stderr, res)
+		return 2
+	}
+
+	cmdfs := flag.NewFlagSet(res.name+" "+cmd.name, flag.ContinueOnError)
+	cmdfs.SetOutput(stderr)
+	values := cmd.registerFields(cmdfs)
+	// a request field wins over the global flag of the same name, such as
+	// --password of "user create"; the global one can still be given before
+	// the resource name
+	globals := flag.NewFlagSet("", flag.ContinueOnError)
+	opts.register(globals)
+	globals.VisitAll(func(f *flag.Flag) {
+		if cmdfs.Lookup(f.Name) == nil {
+			cmdfs.Var(f.Value, f.Name, f.Usage)
+		}
+	})
+	cmdfs.Usage = func() { printCommandUsage(stderr, res, cmd, cmdfs) }
+
+	if err := cmdfs.Parse(args[2:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+
+	if cmdfs.NArg() != len(cmd.args) {
+		fmt.Fprintf(stderr, "error: %s %s expects %d argument(s), got %d\n\n", res.name, cmd.name, len(cmd.args), cmdfs.NArg())
+		printCommandUsage(stderr, res, cmd, cmdfs)
+		return 2
+	}
+
+	cfg, err := opts.resolve()
+	if err != nil {
+		fmt.Fprintf(stderr, "error: %v\n", err)
+		return 2
+	}
+
+	printer, err := newPrinter(opts.Output, cmd.columns)
+	if err != nil {
+		fmt.Fprintf(stderr, "error: %v\n", err)
+		return 2
+	}
+
+	client := pfsenseapi.NewClient(cfg)
+	result, err := cmd.run(ctx, client, cmdfs.Args(), values)
+	if err != nil {
+		fmt.Fprintf(stderr, "error: %v\n", err)
+		return 1
+	}
+
+	if opts.DryRun {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		if err = enc.Encode(client.Plan()); err != nil {
+			fmt.Fprintf(stderr, "error: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+
+	if result == nil {
+		return 0
+	}
+
+	if err = printer.print(stdout, result); err != nil {
+		fmt.Fprintf(stderr, "error: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+func printUsage(w io.Writer, fs *flag.FlagSet) {
+	fmt.Fprintln(w, "Usage: pfsensectl [global flags] <resource> <command> [flags] [args]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Resources:")
+	for _, res := range resources {
+		fmt.Fprintf(w, "  %-12s %s\n", res.name, res.usage)
+	}
+	fmt.Fprintf(w, "  %-12s %s\n", "completion", "Generate shell completion (bash, zsh)")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Global flags:")
+	fs.PrintDefaults()
+}
+
+func printResourceUsage(w io.Writer, res *resource) {
+	fmt.Fprintf(w, "Usage: pfsensectl %s <command> [flags] [args]\n", res.name)
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	for _, cmd := range res.commands {
+		fmt.Fprintf(w, "  %-8s %s\n", cmd.name, cmd.usage)
+	}
+}
+
+func printCommandUsage(w io.Writer, res *resource, cmd *command, fs *flag.FlagSet) {
+	argNames := make([]string, 0, len(cmd.args))
+	for _, arg := range cmd.args {
+		argNames = append(argNames, "<"+arg+">")
+	}
+	fmt.Fprintf(w, "Usage: pfsensectl %s %s [flags] %s\n", res.name, cmd.name, strings.Join(argNames, " "))
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, cmd.usage)
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Flags:")
+	fs.PrintDefaults()
+}
+
+// resourceNames returns the sorted names of every resource.
+func resourceNames() []string {
+	names := make([]string, 0, len(resources))
+	for _, res := range resources {
+		names = append(names, res.name)
+	}
+	sort.Strings(names)
+	return names
+}
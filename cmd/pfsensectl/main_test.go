@@ -0,0 +1,237 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func setupTestServer(t *testing.T, path, response string) *httptest.Server {
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, path, r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		_, err := io.WriteString(w, response)
+		require.NoError(t, err)
+	}
+
+	return httptest.NewServer(http.HandlerFunc(handler))
+}
+
+func TestRun_VLANListJSON(t *testing.T) {
+	data, err := os.ReadFile("../../pfsenseapi/testdata/multiplevlan.json")
+	require.NoError(t, err)
+	server := setupTestServer(t, "/api/v2/interface/vlans", string(data))
+	defer server.Close()
+
+	var stdout, stderr bytes.Buffer
+	code := run(context.Background(), []string{"--host", server.URL, "vlan", "list", "-o", "json"}, &stdout, &stderr)
+	require.Equal(t, 0, code, stderr.String())
+
+	var vlans []map[string]any
+	require.NoError(t, json.Unmarshal(stdout.Bytes(), &vlans))
+	require.Len(t, vlans, 2)
+	require.Equal(t, "em1", vlans[0]["if"])
+}
+
+func TestRun_VLANGetTable(t *testing.T) {
+	data, err := os.ReadFile("../../pfsenseapi/testdata/singlevlan.json")
+	require.NoError(t, err)
+	server := setupTestServer(t, "/api/v2/interface/vlan", string(data))
+	defer server.Close()
+
+	t.Setenv("PFSENSE_HOST", server.URL)
+
+	var stdout, stderr bytes.Buffer
+	code := run(context.Background(), []string{"vlan", "get", "1"}, &stdout, &stderr)
+	require.Equal(t, 0, code, stderr.String())
+	require.Contains(t, stdout.String(), "TAG")
+	require.Contains(t, stdout.String(), "Test VLAN")
+}
+
+func TestRun_RuleGetTable(t *testing.T) {
+	data, err := os.ReadFile("../../pfsenseapi/testdata/singlefirewallrule.json")
+	require.NoError(t, err)
+	server := setupTestServer(t, "/api/v2/firewall/rule", string(data))
+	defer server.Close()
+
+	var stdout, stderr bytes.Buffer
+	code := run(context.Background(), []string{"--host", server.URL, "rule", "get", "2"}, &stdout, &stderr)
+	require.Equal(t, 0, code, stderr.String())
+	require.Contains(t, stdout.String(), "DESTINATION_PORT")
+	require.Contains(t, stdout.String(), "!10.0.0.0/8")
+}
+
+func TestRun_DryRun(t *testing.T) {
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected %s %s in dry-run mode", r.Method, r.URL.Path)
+	}
+	server := httptest.NewServer(http.HandlerFunc(handler))
+	defer server.Close()
+
+	var stdout, stderr bytes.Buffer
+	code := run(context.Background(), []string{"--host", server.URL, "--dry-run", "vlan", "delete", "3"}, &stdout, &stderr)
+	require.Equal(t, 0, code, stderr.String())
+
+	var plan struct {
+		Operations []struct {
+			Method   string            `json:"method"`
+			Endpoint string            `json:"endpoint"`
+			Query    map[string]string `json:"query"`
+		} `json:"operations"`
+	}
+	require.NoError(t, json.Unmarshal(stdout.Bytes(), &plan))
+	require.Len(t, plan.Operations, 1)
+	require.Equal(t, http.MethodDelete, plan.Operations[0].Method)
+	require.Equal(t, "api/v2/interface/vlan", plan.Operations[0].Endpoint)
+	require.Equal(t, map[string]string{"id": "3"}, plan.Operations[0].Query)
+}
+
+func TestRun_RequestFieldShadowsGlobalFlag(t *testing.T) {
+	data, err := os.ReadFile("../../pfsenseapi/testdata/singleuser.json")
+	require.NoError(t, err)
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodPost, r.Method)
+		require.Equal(t, "/api/v2/user", r.URL.Path)
+		var body map[string]any
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		require.Equal(t, "alice", body["name"])
+		require.Equal(t, "alicepass", body["password"])
+		w.Header().Set("Content-Type", "application/json")
+		_, err := w.Write(data)
+		require.NoError(t, err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(handler))
+	defer server.Close()
+
+	var stdout, stderr bytes.Buffer
+	code := run(context.Background(), []string{
+		"--host", server.URL, "--auth", "local", "--user", "admin", "--password", "adminpass",
+		"user", "create", "--name", "alice", "--password", "alicepass",
+	}, &stdout, &stderr)
+	require.Equal(t, 0, code, stderr.String())
+}
+
+func TestRun_UsageErrors(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	require.Equal(t, 2, run(context.Background(), []string{"nosuchresource"}, &stdout, &stderr))
+	require.Equal(t, 2, run(context.Background(), []string{"vlan", "nosuchcommand"}, &stdout, &stderr))
+	require.Equal(t, 2, run(context.Background(), []string{"vlan", "get"}, &stdout, &stderr))
+	require.Equal(t, 2, run(context.Background(), []string{"vlan", "list", "-o", "xml", "--host", "http://localhost"}, &stdout, &stderr))
+}
+
+func TestOptions_ResolveProfile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	config := `
+default_profile: lab
+profiles:
+  lab:
+    host: https://10.0.0.1
+    user: admin
+    password: secret
+  prod:
+    host: https://10.0.0.2
+    client_id: id
+    client_token: token
+`
+	require.NoError(t, os.WriteFile(path, []byte(config), 0o600))
+
+	opts := &options{ConfigFile: path}
+	cfg, err := opts.resolve()
+	require.NoError(t, err)
+	require.Equal(t, "https://10.0.0.1", cfg.Host)
+	require.True(t, cfg.LocalAuthEnabled)
+	require.True(t, cfg.SkipTLS)
+
+	opts = &options{ConfigFile: path, Profile: "prod"}
+	cfg, err = opts.resolve()
+	require.NoError(t, err)
+	require.Equal(t, "https://10.0.0.2", cfg.Host)
+	require.True(t, cfg.TokenAuthEnabled)
+
+	opts = &options{ConfigFile: path, Host: "https://10.0.0.3"}
+	cfg, err = opts.resolve()
+	require.NoError(t, err)
+	require.Equal(t, "https://10.0.0.3", cfg.Host)
+
+	opts = &options{ConfigFile: path, Profile: "missing"}
+	_, err = opts.resolve()
+	require.Error(t, err)
+}
+
+func TestOptions_VerifyTLSFlagOverridesProfile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	config := `
+default_profile: lab
+profiles:
+  lab:
+    host: https://10.0.0.1
+    verify_tls: true
+`
+	require.NoError(t, os.WriteFile(path, []byte(config), 0o600))
+
+	opts := &options{ConfigFile: path}
+	cfg, err := opts.resolve()
+	require.NoError(t, err)
+	require.False(t, cfg.SkipTLS)
+
+	opts = &options{ConfigFile: path}
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	opts.register(fs)
+	require.NoError(t, fs.Parse([]string{"--verify-tls=false"}))
+	cfg, err = opts.resolve()
+	require.NoError(t, err)
+	require.True(t, cfg.SkipTLS)
+}
+
+func TestOptions_ConnectionEnv(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("PFSENSECTL_CONFIG", "")
+	t.Setenv("PFSENSE_PROFILE", "")
+	t.Setenv("PFSENSE_HOST", "https://10.0.0.1")
+	t.Setenv("PFSENSE_VERIFY_TLS", "true")
+	t.Setenv("PFSENSE_TIMEOUT", "30s")
+
+	cfg, err := new(options).resolve()
+	require.NoError(t, err)
+	require.False(t, cfg.SkipTLS)
+	require.Equal(t, 30*time.Second, cfg.Timeout)
+
+	t.Setenv("PFSENSE_TIMEOUT", "soon")
+	_, err = new(options).resolve()
+	require.ErrorContains(t, err, "PFSENSE_TIMEOUT")
+}
+
+func TestCrudUsage(t *testing.T) {
+	usages := make(map[string]bool)
+	for _, cmd := range firewallAliasCRUD.commands() {
+		usages[cmd.usage] = true
+	}
+	for _, cmd := range interfaceCRUD.commands() {
+		usages[cmd.usage] = true
+	}
+	require.True(t, usages["List all firewall aliases"])
+	require.True(t, usages["Create an interface"])
+}
+
+func TestWriteCompletion(t *testing.T) {
+	var out bytes.Buffer
+	require.NoError(t, writeCompletion(&out, []string{"bash"}))
+	require.Contains(t, out.String(), "complete -F _pfsensectl pfsensectl")
+	require.Contains(t, out.String(), "vlan) COMPREPLY")
+
+	out.Reset()
+	require.NoError(t, writeCompletion(&out, []string{"zsh"}))
+	require.Contains(t, out.String(), "#compdef pfsensectl")
+
+	require.Error(t, writeCompletion(&out, []string{"powershell"}))
+}
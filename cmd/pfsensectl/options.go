@@ -0,0 +1,253 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/sjafferali/pfsense-api-goclient/v2/pfsenseapi"
+)
+
+const (
+	authNone  = "none"
+	authLocal = "local"
+	authJWT   = "jwt"
+	authToken = "token"
+)
+
+// options holds the global flags. Values left empty on the command line are
+// filled in from the environment and then from the selected profile.
+type options struct {
+	ConfigFile string
+	Profile    string
+	Output     string
+
+	Host        string
+	Auth        string
+	User        string
+	Password    string
+	ClientID    string
+	ClientToken string
+	VerifyTLS   *bool
+	Timeout     time.Duration
+	DryRun      bool
+}
+
+// profile is a named set of connection settings read from the config file.
+type profile struct {
+	Host        string        `yaml:"host"`
+	Auth        string        `yaml:"auth"`
+	User        string        `yaml:"user"`
+	Password    string        `yaml:"password"`
+	ClientID    string        `yaml:"client_id"`
+	ClientToken string        `yaml:"client_token"`
+	VerifyTLS   bool          `yaml:"verify_tls"`
+	Timeout     time.Duration `yaml:"timeout"`
+}
+
+// configFile is the layout of the pfsensectl config file.
+type configFile struct {
+	DefaultProfile string              `yaml:"default_profile"`
+	Profiles       map[string]*profile `yaml:"profiles"`
+}
+
+// register adds the global flags to fs. It is called once for the top level
+// flag set and once for the command flag set, so global flags can be given
+// on either side of the resource and command names. The current values are
+// used as defaults so the second registration does not reset the first.
+func (o *options) register(fs *flag.FlagSet) {
+	fs.StringVar(&o.ConfigFile, "config", o.ConfigFile, "path to the config file (env PFSENSECTL_CONFIG)")
+	fs.StringVar(&o.Profile, "profile", o.Profile, "profile to load from the config file (env PFSENSE_PROFILE)")
+	fs.StringVar(&o.Output, "output", o.Output, "output format: table, json or yaml (env PFSENSE_OUTPUT)")
+	fs.StringVar(&o.Output, "o", o.Output, "shorthand for --output")
+	fs.StringVar(&o.Host, "host", o.Host, "firewall URL, e.g. https://192.168.1.1 (env PFSENSE_HOST)")
+	fs.StringVar(&o.Auth, "auth", o.Auth, "authentication: none, local, jwt or token (env PFSENSE_AUTH)")
+	fs.StringVar(&o.User, "user", o.User, "username for local or jwt auth (env PFSENSE_USER)")
+	fs.StringVar(&o.Password, "password", o.Password, "password for local or jwt auth (env PFSENSE_PASSWORD)")
+	fs.StringVar(&o.ClientID, "client-id", o.ClientID, "API client ID for token auth (env PFSENSE_CLIENT_ID)")
+	fs.StringVar(&o.ClientToken, "client-token", o.ClientToken, "API client token for token auth (env PFSENSE_CLIENT_TOKEN)")
+	fs.Var(optionalBool{&o.VerifyTLS}, "verify-tls", "verify the firewall's TLS certificate (env PFSENSE_VERIFY_TLS)")
+	fs.DurationVar(&o.Timeout, "timeout", o.Timeout, "request timeout (default 5s, env PFSENSE_TIMEOUT)")
+	fs.BoolVar(&o.DryRun, "dry-run", o.DryRun, "print the changes as a JSON plan instead of making them")
+}
+
+// resolve fills unset options from the environment and the selected profile
+// and returns the resulting client configuration.
+func (o *options) resolve() (pfsenseapi.Config, error) {
+	fromEnv(&o.ConfigFile, "PFSENSECTL_CONFIG")
+	fromEnv(&o.Profile, "PFSENSE_PROFILE")
+	fromEnv(&o.Output, "PFSENSE_OUTPUT")
+	fromEnv(&o.Host, "PFSENSE_HOST")
+	fromEnv(&o.Auth, "PFSENSE_AUTH")
+	fromEnv(&o.User, "PFSENSE_USER")
+	fromEnv(&o.Password, "PFSENSE_PASSWORD")
+	fromEnv(&o.ClientID, "PFSENSE_CLIENT_ID")
+	fromEnv(&o.ClientToken, "PFSENSE_CLIENT_TOKEN")
+	if v := os.Getenv("PFSENSE_VERIFY_TLS"); v != "" && o.VerifyTLS == nil {
+		verify, err := strconv.ParseBool(v)
+		if err != nil {
+			return pfsenseapi.Config{}, fmt.Errorf("invalid PFSENSE_VERIFY_TLS %q: %w", v, err)
+		}
+		o.VerifyTLS = &verify
+	}
+	if v := os.Getenv("PFSENSE_TIMEOUT"); v != "" && o.Timeout == 0 {
+		timeout, err := time.ParseDuration(v)
+		if err != nil {
+			return pfsenseapi.Config{}, fmt.Errorf("invalid PFSENSE_TIMEOUT %q: %w", v, err)
+		}
+		o.Timeout = timeout
+	}
+
+	prof, err := o.loadProfile()
+	if err != nil {
+		return pfsenseapi.Config{}, err
+	}
+	if prof != nil {
+		fromProfile(&o.Host, prof.Host)
+		fromProfile(&o.Auth, prof.Auth)
+		fromProfile(&o.User, prof.User)
+		fromProfile(&o.Password, prof.Password)
+		fromProfile(&o.ClientID, prof.ClientID)
+		fromProfile(&o.ClientToken, prof.ClientToken)
+		if o.VerifyTLS == nil {
+			o.VerifyTLS = &prof.VerifyTLS
+		}
+		if o.Timeout == 0 {
+			o.Timeout = prof.Timeout
+		}
+	}
+
+	if o.Host == "" {
+		return pfsenseapi.Config{}, errors.New("no host given: use --host, PFSENSE_HOST or a profile")
+	}
+	if o.Timeout == 0 {
+		o.Timeout = 5 * time.Second
+	}
+
+	cfg := pfsenseapi.Config{
+		Host:    o.Host,
+		SkipTLS: o.VerifyTLS == nil || !*o.VerifyTLS,
+		Timeout: o.Timeout,
+		DryRun:  o.DryRun,
+	}
+
+	switch o.authMethod() {
+	case authNone:
+	case authLocal:
+		cfg.LocalAuthEnabled = true
+		cfg.User = o.User
+		cfg.Password = o.Password
+	case authJWT:
+		cfg.JWTAuthEnabled = true
+		cfg.User = o.User
+		cfg.Password = o.Password
+	case authToken:
+		cfg.TokenAuthEnabled = true
+		cfg.ApiClientID = o.ClientID
+		cfg.ApiClientToken = o.ClientToken
+	default:
+		return pfsenseapi.Config{}, fmt.Errorf("unknown auth method %q", o.Auth)
+	}
+
+	return cfg, nil
+}
+
+// authMethod returns the configured auth method, inferring it from the
+// credentials that were given when none was set explicitly.
+func (o *options) authMethod() string {
+	switch {
+	case o.Auth != "":
+		return o.Auth
+	case o.ClientID != "":
+		return authToken
+	case o.User != "":
+		return authLocal
+	default:
+		return authNone
+	}
+}
+
+// loadProfile reads the selected profile from the config file. It returns nil
+// when no config file exists and no profile was asked for explicitly.
+func (o *options) loadProfile() (*profile, error) {
+	path := o.ConfigFile
+	if path == "" {
+		dir, err := os.UserConfigDir()
+		if err != nil {
+			return nil, nil
+		}
+		path = filepath.Join(dir, "pfsensectl", "config.yaml")
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) && o.ConfigFile == "" && o.Profile == "" {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error reading config file: %w", err)
+	}
+
+	cfg := new(configFile)
+	if err = yaml.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("error parsing config file %s: %w", path, err)
+	}
+
+	name := o.Profile
+	if name == "" {
+		name = cfg.DefaultProfile
+	}
+	if name == "" {
+		return nil, nil
+	}
+
+	prof, ok := cfg.Profiles[name]
+	if !ok {
+		return nil, fmt.Errorf("profile %q not found in %s", name, path)
+	}
+	return prof, nil
+}
+
+func fromEnv(target *string, key string) {
+	if *target == "" {
+		*target = os.Getenv(key)
+	}
+}
+
+func fromProfile(target *string, value string) {
+	if *target == "" {
+		*target = value
+	}
+}
+
+// optionalBool is a boolean flag that leaves its target nil until it is given
+// on the command line, so an explicit --verify-tls=false can override a
+// profile that turns verification on.
+type optionalBool struct {
+	target **bool
+}
+
+func (b optionalBool) String() string {
+	if b.target == nil || *b.target == nil {
+		return "false"
+	}
+	return strconv.FormatBool(**b.target)
+}
+
+func (b optionalBool) Set(s string) error {
+	v, err := strconv.ParseBool(s)
+	if err != nil {
+		return err
+	}
+	*b.target = &v
+	return nil
+}
+
+func (b optionalBool) IsBoolFlag() bool {
+	return true
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+	outputYAML  = "yaml"
+)
+
+// printer writes command results in one of the supported output formats.
+type printer struct {
+	format  string
+	columns []string
+}
+
+func newPrinter(format string, columns []string) (*printer, error) {
+	if format == "" {
+		format = outputTable
+	}
+
+	switch format {
+	case outputTable, outputJSON, outputYAML:
+	default:
+		return nil, fmt.Errorf("unknown output format %q", format)
+	}
+
+	return &printer{format: format, columns: columns}, nil
+}
+
+func (p *printer) print(w io.Writer, result any) error {
+	switch p.format {
+	case outputJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(result)
+	case outputYAML:
+		generic, err := toGeneric(result)
+		if err != nil {
+			return err
+		}
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		if err = enc.Encode(generic); err != nil {
+			return err
+		}
+		return enc.Close()
+	default:
+		return p.printTable(w, result)
+	}
+}
+
+// printTable prints one row per object with the printer's columns. The
+// columns are JSON field names, so the table always agrees with the json and
+// yaml output.
+func (p *printer) printTable(w io.Writer, result any) error {
+	generic, err := toGeneric(result)
+	if err != nil {
+		return err
+	}
+
+	var rows []map[string]any
+	switch v := generic.(type) {
+	case []any:
+		for _, item := range v {
+			row, _ := item.(map[string]any)
+			rows = append(rows, row)
+		}
+	case map[string]any:
+		rows = append(rows, v)
+	default:
+		_, err = fmt.Fprintln(w, formatCell(v))
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	header := make([]string, 0, len(p.columns))
+	for _, col := range p.columns {
+		header = append(header, strings.ToUpper(col))
+	}
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+
+	for _, row := range rows {
+		cells := make([]string, 0, len(p.columns))
+		for _, col := range p.columns {
+			cells = append(cells, formatCell(row[col]))
+		}
+		fmt.Fprintln(tw, strings.Join(cells, "\t"))
+	}
+
+	return tw.Flush()
+}
+
+// toGeneric round-trips v through JSON so output uses the API field names and
+// the optional types' JSON encoding.
+func toGeneric(v any) (any, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling result: %w", err)
+	}
+
+	var generic any
+	if err = json.Unmarshal(data, &generic); err != nil {
+		return nil, fmt.Errorf("error unmarshalling result: %w", err)
+	}
+	return generic, nil
+}
+
+func formatCell(v any) string {
+	switch value := v.(type) {
+	case nil:
+		return ""
+	case []any:
+		parts := make([]string, 0, len(value))
+		for _, item := range value {
+			parts = append(parts, formatCell(item))
+		}
+		return strings.Join(parts, ",")
+	case map[string]any:
+		data, _ := json.Marshal(value)
+		return string(data)
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	default:
+		return fmt.Sprint(value)
+	}
+}
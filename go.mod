@@ -6,10 +6,10 @@ require (
 	github.com/markphelps/optional v0.11.0
 	github.com/stretchr/testify v1.8.4
 	golang.org/x/exp v0.0.0-20221031165847-c99f073a8326
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
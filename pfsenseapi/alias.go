@@ -0,0 +1,345 @@
+package pfsenseapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/markphelps/optional"
+)
+
+const (
+	firewallAliasEndpoint   = "api/v2/firewall/alias"
+	firewallAliasesEndpoint = "api/v2/firewall/aliases"
+)
+
+// AliasType is the kind of entries an alias holds.
+type AliasType string
+
+const (
+	AliasTypeHost          AliasType = "host"
+	AliasTypeNetwork       AliasType = "network"
+	AliasTypePort          AliasType = "port"
+	AliasTypeURL           AliasType = "url"
+	AliasTypeURLPorts      AliasType = "url_ports"
+	AliasTypeURLTable      AliasType = "urltable"
+	AliasTypeURLTablePorts AliasType = "urltable_ports"
+)
+
+// AliasEntry is one address, network, port or URL of an alias along with
+// its description.
+type AliasEntry struct {
+	Address string
+	Detail  string
+}
+
+// Alias represents a single firewall alias.
+type Alias struct {
+	AliasRequest
+	Id int `json:"id"`
+}
+
+type aliasListResponse struct {
+	apiResponse
+	Data flexList[*Alias] `json:"data"`
+}
+
+// ListAliases returns the firewall aliases.
+func (s FirewallService) ListAliases(ctx context.Context) ([]*Alias, error) {
+	response, err := s.client.get(ctx, firewallAliasesEndpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := new(aliasListResponse)
+	if err = s.client.decode(response, resp); err != nil {
+		return nil, fmt.Errorf("error unmarshalling response: %w", err)
+	}
+	return resp.Data, nil
+}
+
+// GetAlias returns the alias with the given ID.
+func (s FirewallService) GetAlias(ctx context.Context, id int) (*Alias, error) {
+	response, err := s.client.get(
+		ctx,
+		firewallAliasEndpoint,
+		map[string]string{
+			"id": strconv.Itoa(id),
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := new(aliasResponse)
+	if err = s.client.decode(response, resp); err != nil {
+		return nil, fmt.Errorf("error unmarshalling response: %w", err)
+	}
+	return resp.Data, nil
+}
+
+// GetAliasByName returns the alias with the given name.
+func (s FirewallService) GetAliasByName(ctx context.Context, name string) (*Alias, error) {
+	response, err := s.client.get(
+		ctx,
+		firewallAliasesEndpoint,
+		map[string]string{
+			"name": name,
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := new(aliasListResponse)
+	if err = s.client.decode(response, resp); err != nil {
+		return nil, fmt.Errorf("error unmarshalling response: %w", err)
+	}
+	for _, alias := range resp.Data {
+		if alias.Name == name {
+			return alias, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: no alias named %q", ErrNotFound, name)
+}
+
+// DeleteAlias deletes an alias.
+func (s FirewallService) DeleteAlias(ctx context.Context, idToDelete int) (*Alias, error) {
+	response, err := s.client.delete(
+		ctx,
+		firewallAliasEndpoint,
+		map[string]string{
+			"id": strconv.Itoa(idToDelete),
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := new(aliasResponse)
+	if err = s.client.decode(response, resp); err != nil {
+		return nil, fmt.Errorf("error unmarshalling response: %w", err)
+	}
+	return resp.Data, nil
+}
+
+// AliasRequest is an alias as it is created or updated. Entries are sent as
+// the parallel address and detail lists the API uses.
+type AliasRequest struct {
+	Name    string           `json:"name"`
+	Type    AliasType        `json:"type"`
+	Descr   *optional.String `json:"descr,omitempty"`
+	Entries []AliasEntry     `json:"-"`
+
+	// Extra holds the fields this library does not model.
+	Extra map[string]json.RawMessage `json:"-"`
+}
+
+type aliasResponse struct {
+	apiResponse
+	Data *Alias `json:"data"`
+}
+
+// CreateAlias creates a new alias.
+func (s FirewallService) CreateAlias(
+	ctx context.Context,
+	newAlias AliasRequest,
+) (*Alias, error) {
+	jsonData, err := json.Marshal(newAlias)
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling request payload into json: %w", err)
+	}
+
+	response, err := s.client.post(ctx, firewallAliasEndpoint, nil, jsonData)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := new(aliasResponse)
+	if err = s.client.decode(response, resp); err != nil {
+		return nil, fmt.Errorf("error unmarshalling response: %w", err)
+	}
+	return resp.Data, nil
+}
+
+// UpdateAlias modifies an existing alias, replacing all of its entries. Use
+// AddAliasEntries and RemoveAliasEntries to change single entries.
+func (s FirewallService) UpdateAlias(
+	ctx context.Context,
+	idToUpdate int,
+	aliasData AliasRequest,
+) (*Alias, error) {
+	requestData := Alias{
+		AliasRequest: aliasData,
+		Id:           idToUpdate,
+	}
+
+	jsonData, err := json.Marshal(requestData)
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling request payload into json: %w", err)
+	}
+
+	response, err := s.client.patch(ctx, firewallAliasEndpoint, nil, jsonData)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := new(aliasResponse)
+	if err = s.client.decode(response, resp); err != nil {
+		return nil, fmt.Errorf("error unmarshalling response: %w", err)
+	}
+	return resp.Data, nil
+}
+
+// ErrAliasConflict is returned by AddAliasEntries and RemoveAliasEntries
+// when other writers kept overwriting the alias and the change could not be
+// made to stick.
+var ErrAliasConflict = errors.New("alias kept changing while its entries were being updated")
+
+// aliasUpdateAttempts is how often an entry change is retried when it is
+// found overwritten by another writer.
+const aliasUpdateAttempts = 3
+
+// AddAliasEntries adds entries to the alias with the given name, leaving out
+// addresses it already holds, and returns the updated alias.
+//
+// Unlike UpdateAlias, only the change is carried over from the caller: the
+// alias is read fresh, the entries are added to what it holds at that moment
+// and only the entry lists are written back. Calls for the same alias on one
+// Client run one at a time, and not while ReplaceAllAliases is replacing it.
+// Right before the write the alias is read again by ID, and the change is
+// started over if the alias under that ID was renamed or its entries changed
+// since the first read; after the write it is read once more to make sure
+// the change stuck. A change that cannot be made
+// in three attempts fails with ErrAliasConflict.
+//
+// The API has no conditional writes, so against writers in other processes
+// this is best-effort only: a change landing between the last read and the
+// write is still overwritten.
+func (s FirewallService) AddAliasEntries(ctx context.Context, name string, entries ...AliasEntry) (*Alias, error) {
+	return s.editAliasEntries(ctx, name, func(current []AliasEntry) ([]AliasEntry, bool) {
+		have := make(map[string]bool, len(current))
+		for _, entry := range current {
+			have[entry.Address] = true
+		}
+
+		updated := current
+		for _, entry := range entries {
+			if !have[entry.Address] {
+				have[entry.Address] = true
+				updated = append(updated, entry)
+			}
+		}
+		return updated, len(updated) != len(current)
+	})
+}
+
+// RemoveAliasEntries removes the entries with the given addresses from the
+// alias with the given name and returns the updated alias. Addresses the
+// alias does not hold are ignored. It guards against lost updates the same
+// way AddAliasEntries does.
+func (s FirewallService) RemoveAliasEntries(ctx context.Context, name string, addresses ...string) (*Alias, error) {
+	return s.editAliasEntries(ctx, name, func(current []AliasEntry) ([]AliasEntry, bool) {
+		remove := make(map[string]bool, len(addresses))
+		for _, address := range addresses {
+			remove[address] = true
+		}
+
+		updated := make([]AliasEntry, 0, len(current))
+		for _, entry := range current {
+			if !remove[entry.Address] {
+				updated = append(updated, entry)
+			}
+		}
+		return updated, len(updated) != len(current)
+	})
+}
+
+// editAliasEntries applies edit to the entries of an alias until reading the
+// alias back shows the edit in effect, i.e. applying it again changes
+// nothing. An attempt is abandoned before writing when the alias no longer
+// matches the snapshot the edit was based on.
+func (s FirewallService) editAliasEntries(ctx context.Context, name string, edit func([]AliasEntry) ([]AliasEntry, bool)) (*Alias, error) {
+	unlock, err := lockKey(ctx, &s.client.aliasLocks, name)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	ctx = WithoutCache(ctx)
+	for attempt := 0; attempt < aliasUpdateAttempts; attempt++ {
+		alias, err := s.GetAliasByName(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+
+		entries, changed := edit(append([]AliasEntry(nil), alias.Entries...))
+		if !changed {
+			return alias, nil
+		}
+
+		// the write replaces the whole entry list and targets the alias by
+		// its position, so make sure neither moved under us
+		current, err := s.GetAlias(ctx, alias.Id)
+		if err != nil {
+			return nil, err
+		}
+		if current.Name != name || !equalAliasEntries(current.Entries, alias.Entries) {
+			continue
+		}
+
+		updated, err := s.patchAliasEntries(ctx, alias.Id, entries)
+		if err != nil {
+			return nil, err
+		}
+		if s.client.Cfg.DryRun {
+			return updated, nil
+		}
+
+		check, err := s.GetAliasByName(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		if _, changed = edit(check.Entries); !changed {
+			return check, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrAliasConflict, name)
+}
+
+func equalAliasEntries(a, b []AliasEntry) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// patchAliasEntries writes only the entries of an alias, so that its other
+// fields are not reset to what a possibly stale read returned.
+func (s FirewallService) patchAliasEntries(ctx context.Context, id int, entries []AliasEntry) (*Alias, error) {
+	jsonData, err := json.Marshal(struct {
+		Id int `json:"id"`
+		aliasEntryLists
+	}{id, splitAliasEntries(entries)})
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling request payload into json: %w", err)
+	}
+
+	response, err := s.client.patch(ctx, firewallAliasEndpoint, nil, jsonData)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := new(aliasResponse)
+	if err = s.client.decode(response, resp); err != nil {
+		return nil, fmt.Errorf("error unmarshalling response: %w", err)
+	}
+	return resp.Data, nil
+}
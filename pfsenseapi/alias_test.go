@@ -0,0 +1,318 @@
+package pfsenseapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/markphelps/optional"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFirewallService_ListAliases(t *testing.T) {
+	data := mustReadFileString(t, "testdata/multiplealias.json")
+	server := setupTestServer(t, data)
+	defer server.Close()
+
+	newClient := NewClientWithNoAuth(server.URL)
+	response, err := newClient.Firewall.ListAliases(context.Background())
+	require.NoError(t, err)
+	require.Len(t, response, 2)
+	require.Equal(t, AliasTypePort, response[1].Type)
+	require.Equal(t, AliasEntry{Address: "8000:8080"}, response[1].Entries[2])
+
+	response, err = newClient.Firewall.ListAliases(context.Background())
+	require.Error(t, err)
+	require.Nil(t, response)
+
+	response, err = newClient.Firewall.ListAliases(context.Background())
+	require.Error(t, err)
+	require.Nil(t, response)
+}
+
+func TestFirewallService_GetAlias(t *testing.T) {
+	data := mustReadFileString(t, "testdata/singlealias.json")
+	server := setupTestServer(t, data)
+	defer server.Close()
+
+	newClient := NewClientWithNoAuth(server.URL)
+	response, err := newClient.Firewall.GetAlias(context.Background(), 0)
+	require.NoError(t, err)
+	require.Equal(t, "blocklist", response.Name)
+	// the detail list is shorter than the address list
+	require.Equal(t, []AliasEntry{
+		{Address: "198.51.100.7", Detail: "ticket 1234"},
+		{Address: "203.0.113.20", Detail: "ticket 1240"},
+		{Address: "bad.example.com"},
+	}, response.Entries)
+	require.Nil(t, response.Extra)
+
+	response, err = newClient.Firewall.GetAlias(context.Background(), 0)
+	require.Error(t, err)
+	require.Nil(t, response)
+
+	response, err = newClient.Firewall.GetAlias(context.Background(), 0)
+	require.Error(t, err)
+	require.Nil(t, response)
+}
+
+func TestFirewallService_GetAliasByName(t *testing.T) {
+	data := mustReadFileString(t, "testdata/multiplealias.json")
+	server := setupTestServer(t, data)
+	defer server.Close()
+
+	newClient := NewClientWithNoAuth(server.URL)
+	response, err := newClient.Firewall.GetAliasByName(context.Background(), "web_ports")
+	require.NoError(t, err)
+	require.Equal(t, 1, response.Id)
+
+	response, err = newClient.Firewall.GetAliasByName(context.Background(), "web_ports")
+	require.Error(t, err)
+	require.Nil(t, response)
+
+	response, err = newClient.Firewall.GetAliasByName(context.Background(), "web_ports")
+	require.Error(t, err)
+	require.Nil(t, response)
+}
+
+func TestFirewallService_DeleteAlias(t *testing.T) {
+	data := mustReadFileString(t, "testdata/singlealias.json")
+	server := setupTestServer(t, data)
+	defer server.Close()
+
+	newClient := NewClientWithNoAuth(server.URL)
+	response, err := newClient.Firewall.DeleteAlias(context.Background(), 0)
+	require.NoError(t, err)
+	require.NotNil(t, response)
+
+	response, err = newClient.Firewall.DeleteAlias(context.Background(), 0)
+	require.Error(t, err)
+	require.Nil(t, response)
+
+	response, err = newClient.Firewall.DeleteAlias(context.Background(), 0)
+	require.Error(t, err)
+	require.Nil(t, response)
+}
+
+func TestFirewallService_CreateAlias(t *testing.T) {
+	data := mustReadFileString(t, "testdata/singlealias.json")
+	server := setupTestServer(t, data)
+	defer server.Close()
+
+	newClient := NewClientWithNoAuth(server.URL)
+	descr := optional.NewString("Hosts blocked by IPAM")
+	newAlias := AliasRequest{
+		Name:    "blocklist",
+		Type:    AliasTypeHost,
+		Descr:   &descr,
+		Entries: []AliasEntry{{Address: "198.51.100.7", Detail: "ticket 1234"}},
+	}
+	response, err := newClient.Firewall.CreateAlias(context.Background(), newAlias)
+	require.NoError(t, err)
+	require.NotNil(t, response)
+
+	response, err = newClient.Firewall.CreateAlias(context.Background(), newAlias)
+	require.Error(t, err)
+	require.Nil(t, response)
+
+	response, err = newClient.Firewall.CreateAlias(context.Background(), newAlias)
+	require.Error(t, err)
+	require.Nil(t, response)
+}
+
+func TestAliasRequest_JSON(t *testing.T) {
+	alias := Alias{
+		AliasRequest: AliasRequest{
+			Name: "web_ports",
+			Type: AliasTypePort,
+			Entries: []AliasEntry{
+				{Address: "80", Detail: "http"},
+				{Address: "443"},
+			},
+			Extra: map[string]json.RawMessage{"created_by": json.RawMessage(`"admin"`)},
+		},
+		Id: 4,
+	}
+
+	data, err := json.Marshal(alias)
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+		"id": 4,
+		"name": "web_ports",
+		"type": "port",
+		"address": ["80", "443"],
+		"detail": ["http", ""],
+		"created_by": "admin"
+	}`, string(data))
+
+	var decoded Alias
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	require.Equal(t, alias, decoded)
+}
+
+// aliasServer is a fake firewall holding a single alias named blocklist.
+// When dropWrites is set, PATCHes are answered but not stored, as if another
+// writer overwrote them straight away. beforeGetByID, when set, runs once
+// ahead of the next read by ID, to let another writer in between.
+type aliasServer struct {
+	mu            sync.Mutex
+	entries       []AliasEntry
+	patches       int
+	dropWrites    bool
+	beforeGetByID func()
+}
+
+func setupAliasServer(t *testing.T, entries ...AliasEntry) (*httptest.Server, *aliasServer) {
+	as := &aliasServer{entries: entries}
+
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet && r.URL.Path == "/"+firewallAliasEndpoint {
+			as.mu.Lock()
+			hook := as.beforeGetByID
+			as.beforeGetByID = nil
+			as.mu.Unlock()
+			if hook != nil {
+				hook()
+			}
+		}
+
+		as.mu.Lock()
+		defer as.mu.Unlock()
+
+		alias := &Alias{Id: 0, AliasRequest: AliasRequest{Name: "blocklist", Type: AliasTypeHost, Entries: as.entries}}
+		var data any = alias
+		switch r.Method {
+		case http.MethodGet:
+			if r.URL.Path == "/"+firewallAliasEndpoint {
+				require.Equal(t, "0", r.URL.Query().Get("id"))
+				break
+			}
+			require.Equal(t, "/"+firewallAliasesEndpoint, r.URL.Path)
+			require.Equal(t, "blocklist", r.URL.Query().Get("name"))
+			data = []*Alias{alias}
+		case http.MethodPatch:
+			require.Equal(t, "/"+firewallAliasEndpoint, r.URL.Path)
+			body, err := io.ReadAll(r.Body)
+			require.NoError(t, err)
+
+			// only the entries are sent
+			var fields map[string]json.RawMessage
+			require.NoError(t, json.Unmarshal(body, &fields))
+			require.ElementsMatch(t, []string{"id", "address", "detail"}, keys(fields))
+
+			var patched Alias
+			require.NoError(t, json.Unmarshal(body, &patched))
+			as.patches++
+			if !as.dropWrites {
+				as.entries = patched.Entries
+			}
+			alias.Entries = patched.Entries
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		require.NoError(t, json.NewEncoder(w).Encode(map[string]any{"code": 200, "status": "ok", "data": data}))
+	}
+	return httptest.NewServer(http.HandlerFunc(handler)), as
+}
+
+func keys(m map[string]json.RawMessage) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+func TestFirewallService_AddAliasEntries(t *testing.T) {
+	server, as := setupAliasServer(t, AliasEntry{Address: "198.51.100.7", Detail: "ticket 1234"})
+	defer server.Close()
+
+	newClient := NewClientWithNoAuth(server.URL)
+	alias, err := newClient.Firewall.AddAliasEntries(context.Background(), "blocklist",
+		AliasEntry{Address: "203.0.113.20", Detail: "ticket 1240"},
+		AliasEntry{Address: "198.51.100.7", Detail: "duplicate"},
+	)
+	require.NoError(t, err)
+	require.Equal(t, []AliasEntry{
+		{Address: "198.51.100.7", Detail: "ticket 1234"},
+		{Address: "203.0.113.20", Detail: "ticket 1240"},
+	}, alias.Entries)
+	require.Equal(t, 1, as.patches)
+
+	// nothing to add, nothing written
+	_, err = newClient.Firewall.AddAliasEntries(context.Background(), "blocklist", AliasEntry{Address: "203.0.113.20"})
+	require.NoError(t, err)
+	require.Equal(t, 1, as.patches)
+}
+
+func TestFirewallService_AddAliasEntriesConcurrently(t *testing.T) {
+	server, as := setupAliasServer(t)
+	defer server.Close()
+
+	newClient := NewClientWithNoAuth(server.URL)
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, err := newClient.Firewall.AddAliasEntries(context.Background(), "blocklist",
+				AliasEntry{Address: fmt.Sprintf("192.0.2.%d", i)},
+			)
+			require.NoError(t, err)
+		}(i)
+	}
+	wg.Wait()
+
+	// no entry was lost to a write based on a stale read
+	require.Len(t, as.entries, 20)
+}
+
+func TestFirewallService_AddAliasEntriesInterleaved(t *testing.T) {
+	server, as := setupAliasServer(t, AliasEntry{Address: "198.51.100.7"})
+	defer server.Close()
+
+	// two clients stand in for two processes, so the per-client lock does
+	// not keep them apart
+	first := NewClientWithNoAuth(server.URL)
+	second := NewClientWithNoAuth(server.URL)
+	as.beforeGetByID = func() {
+		_, err := second.Firewall.AddAliasEntries(context.Background(), "blocklist", AliasEntry{Address: "203.0.113.20"})
+		require.NoError(t, err)
+	}
+
+	alias, err := first.Firewall.AddAliasEntries(context.Background(), "blocklist", AliasEntry{Address: "192.0.2.1"})
+	require.NoError(t, err)
+	require.Equal(t, []AliasEntry{
+		{Address: "198.51.100.7"},
+		{Address: "203.0.113.20"},
+		{Address: "192.0.2.1"},
+	}, alias.Entries)
+	require.Equal(t, alias.Entries, as.entries)
+	require.Equal(t, 2, as.patches)
+}
+
+func TestFirewallService_RemoveAliasEntries(t *testing.T) {
+	server, as := setupAliasServer(t,
+		AliasEntry{Address: "198.51.100.7"},
+		AliasEntry{Address: "203.0.113.20"},
+		AliasEntry{Address: "bad.example.com"},
+	)
+	defer server.Close()
+
+	newClient := NewClientWithNoAuth(server.URL)
+	alias, err := newClient.Firewall.RemoveAliasEntries(context.Background(), "blocklist", "203.0.113.20", "192.0.2.1")
+	require.NoError(t, err)
+	require.Equal(t, []AliasEntry{{Address: "198.51.100.7"}, {Address: "bad.example.com"}}, alias.Entries)
+
+	as.mu.Lock()
+	as.dropWrites = true
+	as.mu.Unlock()
+	_, err = newClient.Firewall.RemoveAliasEntries(context.Background(), "blocklist", "198.51.100.7")
+	require.ErrorIs(t, err, ErrAliasConflict)
+	require.Equal(t, 1+aliasUpdateAttempts, as.patches)
+}
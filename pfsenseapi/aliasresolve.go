@@ -0,0 +1,351 @@
+package pfsenseapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/netip"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ErrAliasCycle is returned when resolving an alias that, through the
+// aliases it references, ends up referencing itself.
+var ErrAliasCycle = errors.New("alias references itself")
+
+// PortRange is an inclusive range of ports. A single port has Start == End.
+// It is written the way pfSense writes it, e.g. "443" or "8000:8080".
+type PortRange struct {
+	Start uint16
+	End   uint16
+}
+
+func (r PortRange) String() string {
+	if r.Start == r.End {
+		return strconv.Itoa(int(r.Start))
+	}
+	return fmt.Sprintf("%d:%d", r.Start, r.End)
+}
+
+func (r PortRange) MarshalText() ([]byte, error) {
+	return []byte(r.String()), nil
+}
+
+func (r *PortRange) UnmarshalText(text []byte) error {
+	parsed, ok := parsePortRange(string(text))
+	if !ok {
+		return fmt.Errorf("invalid port range %q", text)
+	}
+	*r = parsed
+	return nil
+}
+
+// UnresolvedEntry is an alias entry that cannot be turned into addresses
+// without a lookup, such as an FQDN or the URL of a table to download.
+type UnresolvedEntry struct {
+	// Alias is the name of the alias holding the entry, which may be one
+	// nested in the alias that was resolved.
+	Alias   string `json:"alias"`
+	Address string `json:"address"`
+}
+
+// ResolvedAlias is an alias with all nested aliases expanded. Prefixes are
+// sorted, IPv4 before IPv6, and merged so that none overlap or could be
+// combined into a shorter one; Ports are merged the same way.
+type ResolvedAlias struct {
+	Name       string            `json:"name"`
+	Prefixes   []netip.Prefix    `json:"prefixes"`
+	Ports      []PortRange       `json:"ports"`
+	Unresolved []UnresolvedEntry `json:"unresolved"`
+}
+
+// Contains reports whether addr lies in one of the alias's prefixes. Entries
+// that were left unresolved are not considered.
+func (a *ResolvedAlias) Contains(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	i := sort.Search(len(a.Prefixes), func(i int) bool {
+		return lastAddr(a.Prefixes[i]).Compare(addr) >= 0
+	})
+	return i < len(a.Prefixes) && a.Prefixes[i].Contains(addr)
+}
+
+// ContainsPort reports whether port lies in one of the alias's port ranges.
+func (a *ResolvedAlias) ContainsPort(port uint16) bool {
+	i := sort.Search(len(a.Ports), func(i int) bool {
+		return a.Ports[i].End >= port
+	})
+	return i < len(a.Ports) && a.Ports[i].Start <= port
+}
+
+// AliasResolver expands aliases into the addresses and ports they stand for,
+// working only from an alias list fetched beforehand. It is safe for
+// concurrent use.
+type AliasResolver struct {
+	aliases map[string]*Alias
+
+	mu       sync.Mutex
+	resolved map[string]*ResolvedAlias
+}
+
+// NewAliasResolver returns a resolver for the given aliases.
+func NewAliasResolver(aliases []*Alias) *AliasResolver {
+	r := &AliasResolver{
+		aliases:  make(map[string]*Alias, len(aliases)),
+		resolved: make(map[string]*ResolvedAlias),
+	}
+	for _, alias := range aliases {
+		r.aliases[alias.Name] = alias
+	}
+	return r
+}
+
+// ResolveAliases fetches the current aliases and returns a resolver for
+// them.
+func (s FirewallService) ResolveAliases(ctx context.Context) (*AliasResolver, error) {
+	aliases, err := s.ListAliases(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return NewAliasResolver(aliases), nil
+}
+
+// Resolve expands the named alias. Entries naming another alias are
+// replaced by what that alias resolves to; every other entry is read
+// according to the type of the alias holding it. Host and network entries
+// that are neither an address, a CIDR nor an address range are taken to be
+// FQDNs, and the entries of URL and URL table aliases are all reported as
+// unresolved, since resolving them would need a lookup.
+func (r *AliasResolver) Resolve(name string) (*ResolvedAlias, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.resolve(name, nil)
+}
+
+// Contains reports whether addr lies in the named alias.
+func (r *AliasResolver) Contains(name string, addr netip.Addr) (bool, error) {
+	resolved, err := r.Resolve(name)
+	if err != nil {
+		return false, err
+	}
+	return resolved.Contains(addr), nil
+}
+
+// resolve expands name; path lists the aliases being expanded that led to
+// it. r.mu must be held.
+func (r *AliasResolver) resolve(name string, path []string) (*ResolvedAlias, error) {
+	if resolved, ok := r.resolved[name]; ok {
+		return resolved, nil
+	}
+	for _, seen := range path {
+		if seen == name {
+			return nil, fmt.Errorf("%w: %s", ErrAliasCycle, strings.Join(append(path, name), " -> "))
+		}
+	}
+	alias, ok := r.aliases[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: no alias named %q", ErrNotFound, name)
+	}
+	path = append(path, name)
+
+	var (
+		ranges     []addrRange
+		ports      []PortRange
+		unresolved []UnresolvedEntry
+	)
+	for _, entry := range alias.Entries {
+		address := strings.TrimSpace(entry.Address)
+		if _, nested := r.aliases[address]; nested {
+			resolved, err := r.resolve(address, path)
+			if err != nil {
+				return nil, err
+			}
+			for _, prefix := range resolved.Prefixes {
+				ranges = append(ranges, addrRange{prefix.Addr(), lastAddr(prefix)})
+			}
+			ports = append(ports, resolved.Ports...)
+			unresolved = append(unresolved, resolved.Unresolved...)
+			continue
+		}
+
+		switch alias.Type {
+		case AliasTypeHost, AliasTypeNetwork:
+			if rng, ok := parseAddrRange(address); ok {
+				ranges = append(ranges, rng)
+				continue
+			}
+		case AliasTypePort:
+			if port, ok := parsePortRange(address); ok {
+				ports = append(ports, port)
+				continue
+			}
+		}
+		unresolved = append(unresolved, UnresolvedEntry{Alias: name, Address: address})
+	}
+
+	resolved := &ResolvedAlias{
+		Name:       name,
+		Prefixes:   rangesToPrefixes(mergeAddrRanges(ranges)),
+		Ports:      mergePortRanges(ports),
+		Unresolved: dedupeUnresolved(unresolved),
+	}
+	r.resolved[name] = resolved
+	return resolved, nil
+}
+
+// dedupeUnresolved drops the repeats of entries reached through more than
+// one path of nested aliases.
+func dedupeUnresolved(entries []UnresolvedEntry) []UnresolvedEntry {
+	seen := make(map[UnresolvedEntry]bool, len(entries))
+	unique := entries[:0]
+	for _, entry := range entries {
+		if !seen[entry] {
+			seen[entry] = true
+			unique = append(unique, entry)
+		}
+	}
+	return unique
+}
+
+// addrRange is an inclusive range of addresses of the same family.
+type addrRange struct {
+	start, end netip.Addr
+}
+
+// parseAddrRange reads an address, a CIDR or a range in the form
+// "first-last".
+func parseAddrRange(s string) (addrRange, bool) {
+	if first, last, ok := strings.Cut(s, "-"); ok {
+		start, err := netip.ParseAddr(strings.TrimSpace(first))
+		if err != nil {
+			return addrRange{}, false
+		}
+		end, err := netip.ParseAddr(strings.TrimSpace(last))
+		if err != nil {
+			return addrRange{}, false
+		}
+		start, end = start.Unmap().WithZone(""), end.Unmap().WithZone("")
+		if start.Is4() != end.Is4() {
+			return addrRange{}, false
+		}
+		if end.Less(start) {
+			start, end = end, start
+		}
+		return addrRange{start, end}, true
+	}
+
+	if strings.Contains(s, "/") {
+		prefix, err := netip.ParsePrefix(s)
+		if err != nil {
+			return addrRange{}, false
+		}
+		prefix = prefix.Masked()
+		return addrRange{prefix.Addr(), lastAddr(prefix)}, true
+	}
+
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return addrRange{}, false
+	}
+	addr = addr.Unmap().WithZone("")
+	return addrRange{addr, addr}, true
+}
+
+// mergeAddrRanges sorts ranges and joins those that overlap or touch.
+func mergeAddrRanges(ranges []addrRange) []addrRange {
+	sort.Slice(ranges, func(i, j int) bool {
+		return ranges[i].start.Less(ranges[j].start)
+	})
+
+	var merged []addrRange
+	for _, rng := range ranges {
+		if n := len(merged); n > 0 {
+			last := &merged[n-1]
+			if rng.start.Compare(last.end) <= 0 || rng.start == last.end.Next() {
+				if last.end.Less(rng.end) {
+					last.end = rng.end
+				}
+				continue
+			}
+		}
+		merged = append(merged, rng)
+	}
+	return merged
+}
+
+// rangesToPrefixes returns the fewest prefixes covering exactly the given
+// ranges.
+func rangesToPrefixes(ranges []addrRange) []netip.Prefix {
+	var prefixes []netip.Prefix
+	for _, rng := range ranges {
+		start := rng.start
+		for {
+			// the shortest prefix starting at start that ends within the range
+			var prefix netip.Prefix
+			for bits := 0; bits <= start.BitLen(); bits++ {
+				prefix = netip.PrefixFrom(start, bits)
+				if prefix.Masked().Addr() == start && lastAddr(prefix).Compare(rng.end) <= 0 {
+					break
+				}
+			}
+			prefixes = append(prefixes, prefix)
+
+			end := lastAddr(prefix)
+			if end == rng.end {
+				break
+			}
+			start = end.Next()
+		}
+	}
+	return prefixes
+}
+
+// lastAddr returns the highest address in p.
+func lastAddr(p netip.Prefix) netip.Addr {
+	b := p.Masked().Addr().AsSlice()
+	for i := p.Bits(); i < len(b)*8; i++ {
+		b[i/8] |= 0x80 >> (i % 8)
+	}
+	addr, _ := netip.AddrFromSlice(b)
+	return addr
+}
+
+// parsePortRange reads a port or a range in the form "first:last".
+func parsePortRange(s string) (PortRange, bool) {
+	first, last, isRange := strings.Cut(s, ":")
+	start, err := strconv.ParseUint(first, 10, 16)
+	if err != nil {
+		return PortRange{}, false
+	}
+	end := start
+	if isRange {
+		if end, err = strconv.ParseUint(last, 10, 16); err != nil {
+			return PortRange{}, false
+		}
+	}
+	if end < start {
+		start, end = end, start
+	}
+	return PortRange{Start: uint16(start), End: uint16(end)}, true
+}
+
+// mergePortRanges sorts ranges and joins those that overlap or touch.
+func mergePortRanges(ranges []PortRange) []PortRange {
+	sort.Slice(ranges, func(i, j int) bool {
+		return ranges[i].Start < ranges[j].Start
+	})
+
+	var merged []PortRange
+	for _, rng := range ranges {
+		if n := len(merged); n > 0 && int(rng.Start) <= int(merged[n-1].End)+1 {
+			if merged[n-1].End < rng.End {
+				merged[n-1].End = rng.End
+			}
+			continue
+		}
+		merged = append(merged, rng)
+	}
+	return merged
+}
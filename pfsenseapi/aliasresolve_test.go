@@ -0,0 +1,131 @@
+package pfsenseapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestAlias(name string, aliasType AliasType, addresses ...string) *Alias {
+	alias := &Alias{AliasRequest: AliasRequest{Name: name, Type: aliasType}}
+	for _, address := range addresses {
+		alias.Entries = append(alias.Entries, AliasEntry{Address: address})
+	}
+	return alias
+}
+
+func TestFirewallService_ResolveAliases(t *testing.T) {
+	data := mustReadFileString(t, "testdata/multiplealias.json")
+	server := setupTestServer(t, data)
+	defer server.Close()
+
+	newClient := NewClientWithNoAuth(server.URL)
+	resolver, err := newClient.Firewall.ResolveAliases(context.Background())
+	require.NoError(t, err)
+
+	ports, err := resolver.Resolve("web_ports")
+	require.NoError(t, err)
+	require.Equal(t, []PortRange{{80, 80}, {443, 443}, {8000, 8080}}, ports.Ports)
+
+	in, err := resolver.Contains("blocklist", netip.MustParseAddr("203.0.113.20"))
+	require.NoError(t, err)
+	require.True(t, in)
+
+	resolver, err = newClient.Firewall.ResolveAliases(context.Background())
+	require.Error(t, err)
+	require.Nil(t, resolver)
+
+	resolver, err = newClient.Firewall.ResolveAliases(context.Background())
+	require.Error(t, err)
+	require.Nil(t, resolver)
+}
+
+func TestAliasResolver_Resolve(t *testing.T) {
+	resolver := NewAliasResolver([]*Alias{
+		newTestAlias("servers", AliasTypeHost, "10.0.0.4", "10.0.0.5", "10.0.0.6-10.0.0.7", "db.example.com", "2001:db8::1"),
+		newTestAlias("lans", AliasTypeNetwork, "10.0.0.0/25", "10.0.0.128/25", "servers", "192.168.1.77/24"),
+		newTestAlias("feeds", AliasTypeURLTable, "https://example.com/drop.txt"),
+		newTestAlias("everything", AliasTypeNetwork, "lans", "feeds", "servers"),
+		newTestAlias("ports", AliasTypePort, "443", "80", "81:90", "8080:8000", "http_alt"),
+	})
+
+	servers, err := resolver.Resolve("servers")
+	require.NoError(t, err)
+	require.Equal(t, []netip.Prefix{
+		netip.MustParsePrefix("10.0.0.4/30"),
+		netip.MustParsePrefix("2001:db8::1/128"),
+	}, servers.Prefixes)
+	require.Equal(t, []UnresolvedEntry{{Alias: "servers", Address: "db.example.com"}}, servers.Unresolved)
+
+	everything, err := resolver.Resolve("everything")
+	require.NoError(t, err)
+	require.Equal(t, []netip.Prefix{
+		netip.MustParsePrefix("10.0.0.0/24"),
+		netip.MustParsePrefix("192.168.1.0/24"),
+		netip.MustParsePrefix("2001:db8::1/128"),
+	}, everything.Prefixes)
+	require.ElementsMatch(t, []UnresolvedEntry{
+		{Alias: "servers", Address: "db.example.com"},
+		{Alias: "feeds", Address: "https://example.com/drop.txt"},
+	}, everything.Unresolved)
+	require.True(t, everything.Contains(netip.MustParseAddr("10.0.0.200")))
+	require.True(t, everything.Contains(netip.MustParseAddr("::ffff:192.168.1.1")))
+	require.True(t, everything.Contains(netip.MustParseAddr("2001:db8::1")))
+	require.False(t, everything.Contains(netip.MustParseAddr("10.0.1.0")))
+	require.False(t, everything.Contains(netip.MustParseAddr("2001:db8::2")))
+
+	ports, err := resolver.Resolve("ports")
+	require.NoError(t, err)
+	require.Equal(t, []PortRange{{80, 90}, {443, 443}, {8000, 8080}}, ports.Ports)
+	require.Equal(t, []UnresolvedEntry{{Alias: "ports", Address: "http_alt"}}, ports.Unresolved)
+	require.True(t, ports.ContainsPort(85))
+	require.True(t, ports.ContainsPort(8080))
+	require.False(t, ports.ContainsPort(91))
+
+	data, err := json.Marshal(ports.Ports)
+	require.NoError(t, err)
+	require.JSONEq(t, `["80:90", "443", "8000:8080"]`, string(data))
+
+	_, err = resolver.Resolve("missing")
+	require.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestAliasResolver_Cycle(t *testing.T) {
+	resolver := NewAliasResolver([]*Alias{
+		newTestAlias("a", AliasTypeHost, "10.0.0.1", "b"),
+		newTestAlias("b", AliasTypeHost, "c"),
+		newTestAlias("c", AliasTypeHost, "a"),
+		newTestAlias("d", AliasTypeHost, "b"),
+	})
+
+	_, err := resolver.Resolve("d")
+	require.ErrorIs(t, err, ErrAliasCycle)
+	require.ErrorContains(t, err, "d -> b -> c -> a -> b")
+
+	_, err = resolver.Contains("a", netip.MustParseAddr("10.0.0.1"))
+	require.ErrorIs(t, err, ErrAliasCycle)
+}
+
+func TestRangesToPrefixes(t *testing.T) {
+	tests := []struct {
+		start, end string
+		prefixes   []string
+	}{
+		{"10.0.0.0", "10.0.0.255", []string{"10.0.0.0/24"}},
+		{"10.0.0.1", "10.0.0.6", []string{"10.0.0.1/32", "10.0.0.2/31", "10.0.0.4/31", "10.0.0.6/32"}},
+		{"0.0.0.0", "255.255.255.255", []string{"0.0.0.0/0"}},
+		{"::", "::1", []string{"::/127"}},
+	}
+
+	for _, tt := range tests {
+		rng := addrRange{netip.MustParseAddr(tt.start), netip.MustParseAddr(tt.end)}
+		var got []string
+		for _, prefix := range rangesToPrefixes([]addrRange{rng}) {
+			got = append(got, prefix.String())
+		}
+		require.Equal(t, tt.prefixes, got, "%s-%s", tt.start, tt.end)
+	}
+}
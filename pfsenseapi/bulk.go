@@ -0,0 +1,357 @@
+package pfsenseapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrUnfilteredDelete is returned by the DeleteMany methods when the query
+// would match every object and DeleteQuery.All is not set.
+var ErrUnfilteredDelete = errors.New("refusing to delete all objects without DeleteQuery.All")
+
+// deleteControlParams are the query parameters the REST API reads as paging,
+// sorting or other controls rather than as filters.
+var deleteControlParams = map[string]bool{
+	"all":        true,
+	"limit":      true,
+	"offset":     true,
+	"sort_by":    true,
+	"sort_order": true,
+	"sort_flags": true,
+	"reverse":    true,
+}
+
+// DeleteQuery selects the objects a DeleteMany method deletes.
+type DeleteQuery struct {
+	// Filters are query filters in the REST API's syntax, e.g.
+	// {"descr__contains": "temp"} or {"tag__gte": "100"}. Every filter needs
+	// a value, and paging and sorting parameters such as limit are not
+	// filters; both would leave the delete unfiltered.
+	Filters map[string]string
+	// Limit and Offset restrict the matched objects to a window. Zero means
+	// no limit and no offset.
+	Limit  int
+	Offset int
+	// All must be set to delete objects without any filter. It guards against
+	// wiping a whole table by passing an empty query.
+	All bool
+}
+
+func (q DeleteQuery) queryMap() (map[string]string, error) {
+	if len(q.Filters) == 0 && !q.All {
+		return nil, ErrUnfilteredDelete
+	}
+	for key, value := range q.Filters {
+		if deleteControlParams[strings.ToLower(key)] {
+			return nil, fmt.Errorf("%w: %q is not a filter", ErrUnfilteredDelete, key)
+		}
+		if value == "" {
+			return nil, fmt.Errorf("%w: filter %q has no value", ErrUnfilteredDelete, key)
+		}
+	}
+
+	queryMap := make(map[string]string, len(q.Filters)+3)
+	for key, value := range q.Filters {
+		queryMap[key] = value
+	}
+	if q.Limit > 0 {
+		queryMap["limit"] = strconv.Itoa(q.Limit)
+	}
+	if q.Offset > 0 {
+		queryMap["offset"] = strconv.Itoa(q.Offset)
+	}
+	if len(q.Filters) == 0 {
+		queryMap["all"] = "true"
+	}
+	return queryMap, nil
+}
+
+type bulkResponse[T any] struct {
+	apiResponse
+	Data flexList[*T] `json:"data"`
+}
+
+// replaceAll replaces every object behind a plural endpoint with items.
+func replaceAll[T, R any](ctx context.Context, c *Client, endpoint string, items []*R) ([]*T, error) {
+	jsonData, err := json.Marshal(items)
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling request payload into json: %w", err)
+	}
+
+	response, err := c.put(ctx, endpoint, nil, jsonData)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := new(bulkResponse[T])
+	if err = c.decode(response, resp); err != nil {
+		return nil, fmt.Errorf("error unmarshalling response: %w", err)
+	}
+	return resp.Data, nil
+}
+
+// deleteMany deletes the objects behind a plural endpoint that match query.
+func deleteMany[T any](ctx context.Context, c *Client, endpoint string, query DeleteQuery) ([]*T, error) {
+	queryMap, err := query.queryMap()
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := c.delete(ctx, endpoint, queryMap)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := new(bulkResponse[T])
+	if err = c.decode(response, resp); err != nil {
+		return nil, fmt.Errorf("error unmarshalling response: %w", err)
+	}
+	return resp.Data, nil
+}
+
+// createMany creates items one at a time, since the plural endpoints do not
+// accept POST. It stops at the first failure and returns the objects created
+// so far.
+func createMany[T, R any](ctx context.Context, items []R, create func(context.Context, R) (*T, error)) ([]*T, error) {
+	created := make([]*T, 0, len(items))
+	for i, item := range items {
+		obj, err := create(ctx, item)
+		if err != nil {
+			return created, fmt.Errorf("error creating item %d: %w", i, err)
+		}
+		created = append(created, obj)
+	}
+	return created, nil
+}
+
+// ReplaceAllInterfaces replaces all interfaces with the given list.
+func (s InterfaceService) ReplaceAllInterfaces(ctx context.Context, interfaces []*InterfaceRequest) ([]*Interface, error) {
+	return replaceAll[Interface](ctx, s.client, interfacesEndpoint, interfaces)
+}
+
+// DeleteManyInterfaces deletes the interfaces matching query.
+func (s InterfaceService) DeleteManyInterfaces(ctx context.Context, query DeleteQuery) ([]*Interface, error) {
+	return deleteMany[Interface](ctx, s.client, interfacesEndpoint, query)
+}
+
+// CreateManyInterfaces creates each of the given interfaces.
+func (s InterfaceService) CreateManyInterfaces(ctx context.Context, interfaces []InterfaceRequest) ([]*Interface, error) {
+	return createMany(ctx, interfaces, s.CreateInterface)
+}
+
+// ReplaceAllVLANs replaces all VLANs with the given list.
+func (s InterfaceService) ReplaceAllVLANs(ctx context.Context, vlans []*VLANRequest) ([]*VLAN, error) {
+	return replaceAll[VLAN](ctx, s.client, interfaceVLANsEndpoint, vlans)
+}
+
+// DeleteManyVLANs deletes the VLANs matching query.
+func (s InterfaceService) DeleteManyVLANs(ctx context.Context, query DeleteQuery) ([]*VLAN, error) {
+	return deleteMany[VLAN](ctx, s.client, interfaceVLANsEndpoint, query)
+}
+
+// CreateManyVLANs creates each of the given VLANs.
+func (s InterfaceService) CreateManyVLANs(ctx context.Context, vlans []VLANRequest) ([]*VLAN, error) {
+	return createMany(ctx, vlans, s.CreateVLAN)
+}
+
+// ReplaceAllInterfaceGroups replaces all interface groups with the given list.
+func (s InterfaceService) ReplaceAllInterfaceGroups(ctx context.Context, groups []*InterfaceGroupRequest) ([]*InterfaceGroup, error) {
+	return replaceAll[InterfaceGroup](ctx, s.client, interfaceGroupsEndpoint, groups)
+}
+
+// DeleteManyInterfaceGroups deletes the interface groups matching query.
+func (s InterfaceService) DeleteManyInterfaceGroups(ctx context.Context, query DeleteQuery) ([]*InterfaceGroup, error) {
+	return deleteMany[InterfaceGroup](ctx, s.client, interfaceGroupsEndpoint, query)
+}
+
+// CreateManyInterfaceGroups creates each of the given interface groups.
+func (s InterfaceService) CreateManyInterfaceGroups(ctx context.Context, groups []InterfaceGroupRequest) ([]*InterfaceGroup, error) {
+	return createMany(ctx, groups, s.CreateInterfaceGroup)
+}
+
+// ReplaceAllInterfaceBridges replaces all bridges with the given list.
+func (s InterfaceService) ReplaceAllInterfaceBridges(ctx context.Context, bridges []*InterfaceBridgeRequest) ([]*InterfaceBridge, error) {
+	return replaceAll[InterfaceBridge](ctx, s.client, interfaceBridgesEndpoint, bridges)
+}
+
+// DeleteManyInterfaceBridges deletes the bridges matching query.
+func (s InterfaceService) DeleteManyInterfaceBridges(ctx context.Context, query DeleteQuery) ([]*InterfaceBridge, error) {
+	return deleteMany[InterfaceBridge](ctx, s.client, interfaceBridgesEndpoint, query)
+}
+
+// CreateManyInterfaceBridges creates each of the given bridges.
+func (s InterfaceService) CreateManyInterfaceBridges(ctx context.Context, bridges []InterfaceBridgeRequest) ([]*InterfaceBridge, error) {
+	return createMany(ctx, bridges, s.CreateInterfaceBridge)
+}
+
+// ReplaceAllUsers replaces all users with the given list.
+func (s *UserService) ReplaceAllUsers(ctx context.Context, users []*UserRequest) ([]*User, error) {
+	return replaceAll[User](ctx, s.client, usersEndpoint, users)
+}
+
+// DeleteManyUsers deletes the users matching query.
+func (s *UserService) DeleteManyUsers(ctx context.Context, query DeleteQuery) ([]*User, error) {
+	return deleteMany[User](ctx, s.client, usersEndpoint, query)
+}
+
+// CreateManyUsers creates each of the given users.
+func (s *UserService) CreateManyUsers(ctx context.Context, users []UserRequest) ([]*User, error) {
+	return createMany(ctx, users, s.CreateUser)
+}
+
+// ReplaceAllUserGroups replaces all user groups with the given list.
+func (s *UserService) ReplaceAllUserGroups(ctx context.Context, groups []*UserGroupRequest) ([]*UserGroup, error) {
+	return replaceAll[UserGroup](ctx, s.client, groupsEndpoint, groups)
+}
+
+// DeleteManyUserGroups deletes the user groups matching query.
+func (s *UserService) DeleteManyUserGroups(ctx context.Context, query DeleteQuery) ([]*UserGroup, error) {
+	return deleteMany[UserGroup](ctx, s.client, groupsEndpoint, query)
+}
+
+// CreateManyUserGroups creates each of the given user groups.
+func (s *UserService) CreateManyUserGroups(ctx context.Context, groups []UserGroupRequest) ([]*UserGroup, error) {
+	return createMany(ctx, groups, s.CreateUserGroup)
+}
+
+// ReplaceAllRules replaces the whole firewall rule list with the given rules,
+// in order.
+func (s FirewallService) ReplaceAllRules(ctx context.Context, rules []*FirewallRuleRequest) ([]*FirewallRule, error) {
+	return replaceAll[FirewallRule](ctx, s.client, firewallRulesEndpoint, rules)
+}
+
+// DeleteManyRules deletes the firewall rules matching query.
+func (s FirewallService) DeleteManyRules(ctx context.Context, query DeleteQuery) ([]*FirewallRule, error) {
+	return deleteMany[FirewallRule](ctx, s.client, firewallRulesEndpoint, query)
+}
+
+// CreateManyRules creates each of the given firewall rules.
+func (s FirewallService) CreateManyRules(ctx context.Context, rules []FirewallRuleRequest) ([]*FirewallRule, error) {
+	return createMany(ctx, rules, s.CreateRule)
+}
+
+// ReplaceAllAliases replaces all aliases with the given list. It takes the
+// per-alias locks AddAliasEntries and RemoveAliasEntries use for every alias
+// it replaces or writes, so it waits for entry changes in progress on this
+// Client and holds new ones off until the list is replaced.
+func (s FirewallService) ReplaceAllAliases(ctx context.Context, aliases []*AliasRequest) ([]*Alias, error) {
+	current, err := s.ListAliases(WithoutCache(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(current)+len(aliases))
+	for _, alias := range current {
+		names = append(names, alias.Name)
+	}
+	for _, alias := range aliases {
+		if alias != nil {
+			names = append(names, alias.Name)
+		}
+	}
+	unlock, err := lockKeys(ctx, &s.client.aliasLocks, names)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	return replaceAll[Alias](ctx, s.client, firewallAliasesEndpoint, aliases)
+}
+
+// DeleteManyAliases deletes the aliases matching query.
+func (s FirewallService) DeleteManyAliases(ctx context.Context, query DeleteQuery) ([]*Alias, error) {
+	return deleteMany[Alias](ctx, s.client, firewallAliasesEndpoint, query)
+}
+
+// CreateManyAliases creates each of the given aliases.
+func (s FirewallService) CreateManyAliases(ctx context.Context, aliases []AliasRequest) ([]*Alias, error) {
+	return createMany(ctx, aliases, s.CreateAlias)
+}
+
+// ReplaceAllSchedules replaces all schedules with the given list.
+func (s FirewallService) ReplaceAllSchedules(ctx context.Context, schedules []*ScheduleRequest) ([]*Schedule, error) {
+	return replaceAll[Schedule](ctx, s.client, firewallSchedulesEndpoint, schedules)
+}
+
+// DeleteManySchedules deletes the schedules matching query.
+func (s FirewallService) DeleteManySchedules(ctx context.Context, query DeleteQuery) ([]*Schedule, error) {
+	return deleteMany[Schedule](ctx, s.client, firewallSchedulesEndpoint, query)
+}
+
+// CreateManySchedules creates each of the given schedules.
+func (s FirewallService) CreateManySchedules(ctx context.Context, schedules []ScheduleRequest) ([]*Schedule, error) {
+	return createMany(ctx, schedules, s.CreateSchedule)
+}
+
+// ReplaceAllPortForwards replaces all port forwards with the given list.
+func (s NATService) ReplaceAllPortForwards(ctx context.Context, portForwards []*PortForwardRequest) ([]*PortForward, error) {
+	return replaceAll[PortForward](ctx, s.client, natPortForwardsEndpoint, portForwards)
+}
+
+// DeleteManyPortForwards deletes the port forwards matching query.
+func (s NATService) DeleteManyPortForwards(ctx context.Context, query DeleteQuery) ([]*PortForward, error) {
+	return deleteMany[PortForward](ctx, s.client, natPortForwardsEndpoint, query)
+}
+
+// CreateManyPortForwards creates each of the given port forwards.
+func (s NATService) CreateManyPortForwards(ctx context.Context, portForwards []PortForwardRequest) ([]*PortForward, error) {
+	return createMany(ctx, portForwards, s.CreatePortForward)
+}
+
+// ReplaceAllOutboundMappings replaces the whole outbound NAT mapping list
+// with the given mappings, in the order given.
+func (s NATService) ReplaceAllOutboundMappings(ctx context.Context, mappings []*OutboundMappingRequest) ([]*OutboundMapping, error) {
+	return replaceAll[OutboundMapping](ctx, s.client, natOutboundMappingsEndpoint, mappings)
+}
+
+// DeleteManyOutboundMappings deletes the outbound NAT mappings matching
+// query.
+func (s NATService) DeleteManyOutboundMappings(ctx context.Context, query DeleteQuery) ([]*OutboundMapping, error) {
+	return deleteMany[OutboundMapping](ctx, s.client, natOutboundMappingsEndpoint, query)
+}
+
+// CreateManyOutboundMappings creates each of the given outbound NAT
+// mappings.
+func (s NATService) CreateManyOutboundMappings(ctx context.Context, mappings []OutboundMappingRequest) ([]*OutboundMapping, error) {
+	return createMany(ctx, mappings, s.CreateOutboundMapping)
+}
+
+// ReplaceAllOneToOneMappings replaces all 1:1 NAT mappings with the given
+// list.
+func (s NATService) ReplaceAllOneToOneMappings(ctx context.Context, mappings []*OneToOneMappingRequest) ([]*OneToOneMapping, error) {
+	return replaceAll[OneToOneMapping](ctx, s.client, natOneToOneMappingsEndpoint, mappings)
+}
+
+// DeleteManyOneToOneMappings deletes the 1:1 NAT mappings matching query.
+func (s NATService) DeleteManyOneToOneMappings(ctx context.Context, query DeleteQuery) ([]*OneToOneMapping, error) {
+	return deleteMany[OneToOneMapping](ctx, s.client, natOneToOneMappingsEndpoint, query)
+}
+
+// CreateManyOneToOneMappings creates each of the given 1:1 NAT mappings.
+func (s NATService) CreateManyOneToOneMappings(ctx context.Context, mappings []OneToOneMappingRequest) ([]*OneToOneMapping, error) {
+	return createMany(ctx, mappings, s.CreateOneToOneMapping)
+}
+
+// ReplaceAllVirtualIPs replaces all virtual IPs with the given list. It
+// fails with an error wrapping ErrVHIDInUse, without sending anything, if
+// two CARP virtual IPs in the list share a VHID on the same interface.
+func (s VirtualIPService) ReplaceAllVirtualIPs(ctx context.Context, vips []*VirtualIPRequest) ([]*VirtualIP, error) {
+	if err := checkVHIDs(vips); err != nil {
+		return nil, err
+	}
+	return replaceAll[VirtualIP](ctx, s.client, virtualIPsEndpoint, vips)
+}
+
+// DeleteManyVirtualIPs deletes the virtual IPs matching query.
+func (s VirtualIPService) DeleteManyVirtualIPs(ctx context.Context, query DeleteQuery) ([]*VirtualIP, error) {
+	return deleteMany[VirtualIP](ctx, s.client, virtualIPsEndpoint, query)
+}
+
+// CreateManyVirtualIPs creates each of the given virtual IPs.
+func (s VirtualIPService) CreateManyVirtualIPs(ctx context.Context, vips []VirtualIPRequest) ([]*VirtualIP, error) {
+	return createMany(ctx, vips, s.CreateVirtualIP)
+}
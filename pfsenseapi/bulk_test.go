@@ -0,0 +1,173 @@
+package pfsenseapi
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeleteQuery_queryMap(t *testing.T) {
+	_, err := DeleteQuery{}.queryMap()
+	require.ErrorIs(t, err, ErrUnfilteredDelete)
+
+	_, err = DeleteQuery{Limit: 5}.queryMap()
+	require.ErrorIs(t, err, ErrUnfilteredDelete)
+
+	// filters the API would ignore do not count as filters
+	_, err = DeleteQuery{Filters: map[string]string{"descr__contains": ""}}.queryMap()
+	require.ErrorIs(t, err, ErrUnfilteredDelete)
+	_, err = DeleteQuery{Filters: map[string]string{"limit": "0"}}.queryMap()
+	require.ErrorIs(t, err, ErrUnfilteredDelete)
+	_, err = DeleteQuery{Filters: map[string]string{"tag": "10", "Sort_By": "tag"}, All: true}.queryMap()
+	require.ErrorIs(t, err, ErrUnfilteredDelete)
+
+	queryMap, err := DeleteQuery{All: true}.queryMap()
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"all": "true"}, queryMap)
+
+	queryMap, err = DeleteQuery{
+		Filters: map[string]string{"descr__contains": "temp"},
+		Limit:   5,
+		Offset:  10,
+	}.queryMap()
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"descr__contains": "temp", "limit": "5", "offset": "10"}, queryMap)
+}
+
+func TestInterfaceService_ReplaceAllVLANs(t *testing.T) {
+	data := mustReadFileString(t, "testdata/multiplevlan.json")
+	server := setupTestServer(t, data)
+	defer server.Close()
+
+	newClient := NewClientWithNoAuth(server.URL)
+	vlans := []*VLANRequest{{If: "em1", Tag: 10}, {If: "em1", Tag: 20}}
+
+	response, err := newClient.Interface.ReplaceAllVLANs(context.Background(), vlans)
+	require.NoError(t, err)
+	require.Len(t, response, 2)
+
+	response, err = newClient.Interface.ReplaceAllVLANs(context.Background(), vlans)
+	require.Error(t, err)
+	require.Nil(t, response)
+
+	response, err = newClient.Interface.ReplaceAllVLANs(context.Background(), vlans)
+	require.Error(t, err)
+	require.Nil(t, response)
+}
+
+func TestUserService_DeleteManyUsers(t *testing.T) {
+	data := mustReadFileString(t, "testdata/multipleuser.json")
+	server := setupTestServer(t, data)
+	defer server.Close()
+
+	newClient := NewClientWithNoAuth(server.URL)
+	query := DeleteQuery{Filters: map[string]string{"name__startswith": "temp"}}
+
+	response, err := newClient.User.DeleteManyUsers(context.Background(), query)
+	require.NoError(t, err)
+	require.NotEmpty(t, response)
+
+	response, err = newClient.User.DeleteManyUsers(context.Background(), query)
+	require.Error(t, err)
+	require.Nil(t, response)
+
+	response, err = newClient.User.DeleteManyUsers(context.Background(), query)
+	require.Error(t, err)
+	require.Nil(t, response)
+}
+
+func TestInterfaceService_DeleteManyVLANsRequest(t *testing.T) {
+	data := mustReadFileString(t, "testdata/multiplevlan.json")
+
+	var method string
+	var query url.Values
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		method = r.Method
+		query = r.URL.Query()
+		w.Header().Set("Content-Type", "application/json")
+		_, err := io.WriteString(w, data)
+		require.NoError(t, err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(handler))
+	defer server.Close()
+
+	newClient := NewClientWithNoAuth(server.URL)
+
+	_, err := newClient.Interface.DeleteManyVLANs(context.Background(), DeleteQuery{})
+	require.ErrorIs(t, err, ErrUnfilteredDelete)
+	_, err = newClient.Interface.DeleteManyVLANs(context.Background(), DeleteQuery{
+		Filters: map[string]string{"descr": ""},
+	})
+	require.ErrorIs(t, err, ErrUnfilteredDelete)
+	_, err = newClient.Interface.DeleteManyVLANs(context.Background(), DeleteQuery{
+		Filters: map[string]string{"limit": "0"},
+	})
+	require.ErrorIs(t, err, ErrUnfilteredDelete)
+	require.Empty(t, method)
+
+	_, err = newClient.Interface.DeleteManyVLANs(context.Background(), DeleteQuery{
+		Filters: map[string]string{"tag__gte": "100"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, http.MethodDelete, method)
+	require.Equal(t, "100", query.Get("tag__gte"))
+	require.False(t, query.Has("all"))
+
+	_, err = newClient.Interface.DeleteManyVLANs(context.Background(), DeleteQuery{All: true})
+	require.NoError(t, err)
+	require.Equal(t, "true", query.Get("all"))
+}
+
+func TestUserService_CreateManyUserGroups(t *testing.T) {
+	data := mustReadFileString(t, "testdata/singleusergroup.json")
+	server := setupTestServer(t, data)
+	defer server.Close()
+
+	newClient := NewClientWithNoAuth(server.URL)
+	groups := []UserGroupRequest{{Name: "one"}, {Name: "two"}, {Name: "three"}}
+
+	// the second call fails, so only the first group is created
+	response, err := newClient.User.CreateManyUserGroups(context.Background(), groups)
+	require.ErrorIs(t, err, ErrBadRequest)
+	require.Len(t, response, 1)
+}
+
+func TestFirewallService_ReplaceAllAliasesLocks(t *testing.T) {
+	data := mustReadFileString(t, "testdata/multiplealias.json")
+	var puts atomic.Int32
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPut {
+			puts.Add(1)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, err := io.WriteString(w, data)
+		require.NoError(t, err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(handler))
+	defer server.Close()
+
+	newClient := NewClientWithNoAuth(server.URL)
+	aliases := []*AliasRequest{{Name: "blocklist", Type: "host"}}
+
+	// an entry change in progress on an alias being replaced holds it off
+	unlock, err := lockKey(context.Background(), &newClient.aliasLocks, "web_ports")
+	require.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = newClient.Firewall.ReplaceAllAliases(ctx, aliases)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.Zero(t, puts.Load())
+
+	unlock()
+	response, err := newClient.Firewall.ReplaceAllAliases(context.Background(), aliases)
+	require.NoError(t, err)
+	require.Len(t, response, 2)
+	require.Equal(t, int32(1), puts.Load())
+}
@@ -0,0 +1,209 @@
+package pfsenseapi
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/exp/slices"
+)
+
+const defaultCacheTTL = 10 * time.Second
+
+// endpointFamilies maps plural endpoints onto the singular endpoint of the
+// same resource. Endpoints that share a family invalidate each other's cached
+// responses; every other endpoint is a family of its own.
+var endpointFamilies = map[string]string{
+	interfacesEndpoint:          interfaceEndpoint,
+	interfaceVLANsEndpoint:      interfaceVLANEndpoint,
+	interfaceGroupsEndpoint:     interfaceGroupEndpoint,
+	interfaceBridgesEndpoint:    interfaceBridgeEndpoint,
+	usersEndpoint:               userEndpoint,
+	groupsEndpoint:              groupEndpoint,
+	firewallRulesEndpoint:       firewallRuleEndpoint,
+	firewallAliasesEndpoint:     firewallAliasEndpoint,
+	firewallSchedulesEndpoint:   firewallScheduleEndpoint,
+	natPortForwardsEndpoint:     natPortForwardEndpoint,
+	natOutboundMappingsEndpoint: natOutboundMappingEndpoint,
+	natOneToOneMappingsEndpoint: natOneToOneMappingEndpoint,
+	virtualIPsEndpoint:          virtualIPEndpoint,
+}
+
+// familySideEffects lists, for families whose changes pfSense carries over to
+// other resources, the families of those resources.
+var familySideEffects = map[string][]string{
+	// a new VLAN tag renames the VLAN device, and the interface assigned to
+	// it with it
+	interfaceVLANEndpoint:   {interfaceEndpoint},
+	v1InterfaceVLANEndpoint: {v1InterfaceEndpoint},
+	// deleting a user removes it from its groups
+	userEndpoint: {groupEndpoint},
+	// port forwards can have a linked filter rule
+	natPortForwardEndpoint: {firewallRuleEndpoint},
+	// leaving automatic mode turns the generated rules into mappings
+	natOutboundModeEndpoint: {natOutboundMappingEndpoint},
+	// killing states changes the state count
+	firewallStatesEndpoint: {firewallStatesSizeEndpoint},
+	// CARP status reports on the CARP virtual IPs once they are applied
+	virtualIPEndpoint:      {statusCARPEndpoint},
+	virtualIPApplyEndpoint: {statusCARPEndpoint},
+}
+
+type cacheBypassKey struct{}
+
+// WithoutCache returns a context that makes reads skip the response cache and
+// go to the firewall. The fresh response still replaces the cached one.
+func WithoutCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, cacheBypassKey{}, true)
+}
+
+func cacheBypassed(ctx context.Context) bool {
+	bypass, _ := ctx.Value(cacheBypassKey{}).(bool)
+	return bypass
+}
+
+// endpointFamily returns the resource family of an endpoint.
+func endpointFamily(endpoint string) string {
+	endpoint = strings.Trim(endpoint, "/")
+	if family, ok := endpointFamilies[endpoint]; ok {
+		return family
+	}
+	return endpoint
+}
+
+type cacheEntry struct {
+	body    []byte
+	family  string
+	expires time.Time
+}
+
+// responseCache is a read-through cache of successful GET response bodies,
+// keyed by endpoint and query.
+type responseCache struct {
+	mu         sync.Mutex
+	entries    map[string]cacheEntry
+	generation uint64
+
+	defaultTTL time.Duration
+	ttls       map[string]time.Duration
+}
+
+func newResponseCache(config Config) *responseCache {
+	ttl := config.CacheTTL
+	if ttl == 0 {
+		ttl = defaultCacheTTL
+	}
+
+	ttls := make(map[string]time.Duration, len(config.CacheTTLs))
+	for endpoint, ttl := range config.CacheTTLs {
+		ttls[endpointFamily(endpoint)] = ttl
+	}
+
+	return &responseCache{
+		entries:    make(map[string]cacheEntry),
+		defaultTTL: ttl,
+		ttls:       ttls,
+	}
+}
+
+func cacheKey(endpoint string, queryMap map[string]string) string {
+	q := url.Values{}
+	for key, value := range queryMap {
+		q.Set(key, value)
+	}
+	return strings.Trim(endpoint, "/") + "?" + q.Encode()
+}
+
+func (rc *responseCache) ttlFor(family string) time.Duration {
+	if ttl, ok := rc.ttls[family]; ok {
+		return ttl
+	}
+	return rc.defaultTTL
+}
+
+// get returns the cached body for the request, if there is a live one, along
+// with the cache generation to pass to set once the request completes.
+func (rc *responseCache) get(endpoint string, queryMap map[string]string) ([]byte, uint64, bool) {
+	key := cacheKey(endpoint, queryMap)
+
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	entry, ok := rc.entries[key]
+	if !ok {
+		return nil, rc.generation, false
+	}
+	if time.Now().After(entry.expires) {
+		delete(rc.entries, key)
+		return nil, rc.generation, false
+	}
+	return entry.body, rc.generation, true
+}
+
+// set stores a response body. The body is dropped if the cache was
+// invalidated after generation was read, since the response may predate the
+// change that caused the invalidation.
+func (rc *responseCache) set(endpoint string, queryMap map[string]string, body []byte, generation uint64) {
+	family := endpointFamily(endpoint)
+	ttl := rc.ttlFor(family)
+	if ttl < 0 {
+		return
+	}
+
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	if generation != rc.generation {
+		return
+	}
+
+	rc.entries[cacheKey(endpoint, queryMap)] = cacheEntry{
+		body:    body,
+		family:  family,
+		expires: time.Now().Add(ttl),
+	}
+}
+
+// invalidate drops every entry of the endpoint's family or of one of its
+// side effects.
+func (rc *responseCache) invalidate(endpoint string) {
+	family := endpointFamily(endpoint)
+	families := append([]string{family}, familySideEffects[family]...)
+
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	rc.generation++
+	for key, entry := range rc.entries {
+		if slices.Contains(families, entry.family) {
+			delete(rc.entries, key)
+		}
+	}
+}
+
+func (rc *responseCache) flush() {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	rc.generation++
+	rc.entries = make(map[string]cacheEntry)
+}
+
+// FlushCache drops every cached response. It is a no-op when caching is
+// disabled.
+func (c *Client) FlushCache() {
+	if c.cache != nil {
+		c.cache.flush()
+	}
+}
+
+// InvalidateCache drops the cached responses of the resource family the
+// endpoint belongs to, e.g. "api/v2/interface/vlan" also drops
+// "api/v2/interface/vlans". It is a no-op when caching is disabled.
+func (c *Client) InvalidateCache(endpoint string) {
+	if c.cache != nil {
+		c.cache.invalidate(endpoint)
+	}
+}
@@ -0,0 +1,149 @@
+package pfsenseapi
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// setupCountingServer returns a server that answers every request with the
+// given response and counts the requests per method and path.
+func setupCountingServer(t *testing.T, response string) (*httptest.Server, func(method, path string) int) {
+	var mu sync.Mutex
+	hits := make(map[string]int)
+
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		hits[r.Method+" "+r.URL.Path]++
+		mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		_, err := io.WriteString(w, response)
+		require.NoError(t, err)
+	}
+
+	count := func(method, path string) int {
+		mu.Lock()
+		defer mu.Unlock()
+		return hits[method+" "+path]
+	}
+
+	return httptest.NewServer(http.HandlerFunc(handler)), count
+}
+
+func TestClient_Cache(t *testing.T) {
+	data := mustReadFileString(t, "testdata/multiplevlan.json")
+	server, count := setupCountingServer(t, data)
+	defer server.Close()
+
+	newClient := NewClient(Config{
+		Host:         server.URL,
+		Timeout:      defaultTimeout,
+		CacheEnabled: true,
+	})
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		vlans, err := newClient.Interface.ListVLANs(ctx)
+		require.NoError(t, err)
+		require.Len(t, vlans, 2)
+	}
+	require.Equal(t, 1, count(http.MethodGet, "/api/v2/interface/vlans"))
+
+	_, err := newClient.Interface.ListVLANs(WithoutCache(ctx))
+	require.NoError(t, err)
+	require.Equal(t, 2, count(http.MethodGet, "/api/v2/interface/vlans"))
+
+	// a mutation on the singular endpoint invalidates the plural one
+	_, _ = newClient.Interface.DeleteVLAN(ctx, 1)
+	_, err = newClient.Interface.ListVLANs(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 3, count(http.MethodGet, "/api/v2/interface/vlans"))
+
+	// unrelated resources keep their cached responses
+	_, err = newClient.Interface.ListVLANs(ctx)
+	require.NoError(t, err)
+	_, _ = newClient.User.DeleteUser(ctx, 1)
+	_, err = newClient.Interface.ListVLANs(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 3, count(http.MethodGet, "/api/v2/interface/vlans"))
+
+	newClient.FlushCache()
+	_, err = newClient.Interface.ListVLANs(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 4, count(http.MethodGet, "/api/v2/interface/vlans"))
+}
+
+func TestClient_CacheTTL(t *testing.T) {
+	data := mustReadFileString(t, "testdata/multiplevlan.json")
+	server, count := setupCountingServer(t, data)
+	defer server.Close()
+
+	newClient := NewClient(Config{
+		Host:         server.URL,
+		Timeout:      defaultTimeout,
+		CacheEnabled: true,
+		CacheTTLs: map[string]time.Duration{
+			"api/v2/interface/vlan": time.Millisecond,
+			"api/v2/user":           -1,
+		},
+	})
+	ctx := context.Background()
+
+	_, err := newClient.Interface.ListVLANs(ctx)
+	require.NoError(t, err)
+	time.Sleep(5 * time.Millisecond)
+	_, err = newClient.Interface.ListVLANs(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 2, count(http.MethodGet, "/api/v2/interface/vlans"))
+
+	_, _ = newClient.User.ListUsers(ctx)
+	_, _ = newClient.User.ListUsers(ctx)
+	require.Equal(t, 2, count(http.MethodGet, "/api/v2/users"))
+}
+
+func TestEndpointFamily(t *testing.T) {
+	require.Equal(t, "api/v2/interface/vlan", endpointFamily("api/v2/interface/vlans"))
+	require.Equal(t, "api/v2/interface/vlan", endpointFamily("/api/v2/interface/vlan"))
+	require.Equal(t, "api/v2/interface/apply", endpointFamily("api/v2/interface/apply"))
+}
+
+func TestClient_CacheSideEffects(t *testing.T) {
+	data := mustReadFileString(t, "testdata/multiplevlan.json")
+	server, count := setupCountingServer(t, data)
+	defer server.Close()
+
+	newClient := NewClient(Config{
+		Host:         server.URL,
+		Timeout:      defaultTimeout,
+		CacheEnabled: true,
+	})
+	ctx := context.Background()
+
+	list := func() {
+		_, _ = newClient.Interface.ListInterfaces(ctx)
+		_, _ = newClient.Interface.ListVLANs(ctx)
+		_, _ = newClient.Interface.ListInterfaceGroups(ctx)
+	}
+	list()
+
+	// a change to an interface leaves the nested endpoints' responses alone
+	_, _ = newClient.Interface.DeleteInterface(ctx, "opt1")
+	list()
+	require.Equal(t, 2, count(http.MethodGet, "/api/v2/interfaces"))
+	require.Equal(t, 1, count(http.MethodGet, "/api/v2/interface/vlans"))
+	require.Equal(t, 1, count(http.MethodGet, "/api/v2/interface/groups"))
+
+	// a VLAN change can rename the interface assigned to it
+	_, _ = newClient.Interface.DeleteVLAN(ctx, 1)
+	list()
+	require.Equal(t, 3, count(http.MethodGet, "/api/v2/interfaces"))
+	require.Equal(t, 2, count(http.MethodGet, "/api/v2/interface/vlans"))
+	require.Equal(t, 1, count(http.MethodGet, "/api/v2/interface/groups"))
+}
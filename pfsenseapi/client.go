@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"sync"
 	"time"
 
 	"golang.org/x/exp/slices"
@@ -28,11 +29,30 @@ var (
 
 // Client provides client Methods
 type Client struct {
-	client *http.Client
-	Cfg    Config
+	client   *http.Client
+	cache    *responseCache
+	throttle *throttle
+	Cfg      Config
 
+	serverInfoMu    sync.Mutex
+	serverInfo      *ServerInfo
+	serverInfoErr   error
+	serverInfoErrAt time.Time
+	serverInfoWait  chan struct{} // closed when the lookup in flight ends
+
+	planMu sync.Mutex
+	plan   []PlannedOperation
+
+	aliasLocks sync.Map // map[string]chan struct{}
+	vhidLocks  sync.Map // map[string]chan struct{}
+
+	Firewall  *FirewallService
+	GraphQL   *GraphQLService
 	Interface *InterfaceService
+	NAT       *NATService
+	Status    *StatusService
 	User      *UserService
+	VirtualIP *VirtualIPService
 }
 
 // Config provides configuration for the client. These values are only read in
@@ -53,6 +73,42 @@ type Config struct {
 
 	SkipTLS bool
 	Timeout time.Duration
+
+	// StrictDecoding makes responses fail to decode when they rely on the
+	// loose encodings the Flex types accept, such as numbers encoded as
+	// strings. Useful in tests to keep fixtures honest.
+	StrictDecoding bool
+
+	// APIVersion selects the REST API package the firewall runs. Defaults to
+	// APIVersionV2.
+	APIVersion APIVersion
+
+	// CacheEnabled turns on the read-through cache of GET responses. Any
+	// other request, except read-only ones such as GraphQL queries,
+	// invalidates the cached responses of its resource family.
+	CacheEnabled bool
+	// CacheTTL is how long a response is cached. Defaults to 10 seconds.
+	CacheTTL time.Duration
+	// CacheTTLs overrides CacheTTL per resource, keyed by endpoint, e.g.
+	// "api/v2/interface/vlan". A negative TTL disables caching of the resource.
+	CacheTTLs map[string]time.Duration
+
+	// DryRun stops the client from sending changes. Reads still go to the
+	// firewall, but every other request is recorded in the client's Plan and
+	// answered with a response made up from the request.
+	DryRun bool
+
+	// RateLimit caps the requests sent to the firewall per second, allowing
+	// bursts of up to RateBurst requests (default 1). Zero means no limit.
+	RateLimit float64
+	RateBurst int
+	// MaxConcurrentRequests caps the requests in flight at once. A request
+	// is in flight until its response body is closed, so an open ListIterator
+	// holds its slot until it is closed. Zero means no limit.
+	MaxConcurrentRequests int
+	// SerializeMutations sends requests that change the configuration one at
+	// a time. pfSense does not guard its config writes against each other.
+	SerializeMutations bool
 }
 
 // authEnabled returns true if any authentication mechanism is enabled, or false
@@ -74,11 +130,20 @@ func NewClient(config Config) *Client {
 	}
 
 	newClient := &Client{
-		Cfg:    config,
-		client: httpclient,
+		Cfg:      config,
+		client:   httpclient,
+		throttle: newThrottle(config),
 	}
+	if config.CacheEnabled {
+		newClient.cache = newResponseCache(config)
+	}
+	newClient.Firewall = &FirewallService{client: newClient}
+	newClient.GraphQL = &GraphQLService{client: newClient}
 	newClient.Interface = &InterfaceService{client: newClient}
+	newClient.NAT = &NATService{client: newClient}
+	newClient.Status = &StatusService{client: newClient}
 	newClient.User = &UserService{client: newClient}
+	newClient.VirtualIP = &VirtualIPService{client: newClient}
 	return newClient
 }
 
@@ -143,6 +208,32 @@ type service struct {
 }
 
 func (c *Client) do(ctx context.Context, method, endpoint string, queryMap map[string]string, body []byte) (*http.Response, error) {
+	mutation := method != http.MethodGet && !isReadOnly(ctx)
+	if c.Cfg.DryRun && mutation {
+		return c.record(method, endpoint, queryMap, body)
+	}
+
+	res, err := c.throttled(ctx, mutation, func(ctx context.Context) (*http.Response, error) {
+		if c.Cfg.APIVersion == APIVersionV1 {
+			return c.doV1(ctx, method, endpoint, queryMap, body)
+		}
+		return c.send(ctx, method, endpoint, queryMap, body)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// any request other than a read may have changed the resource
+	if mutation {
+		c.InvalidateCache(endpoint)
+	}
+
+	return res, nil
+}
+
+// send makes a single API call, retrying once with a fresh token if the JWT
+// has expired.
+func (c *Client) send(ctx context.Context, method, endpoint string, queryMap map[string]string, body []byte) (*http.Response, error) {
 	res, err := c.doRequest(ctx, method, endpoint, queryMap, body)
 	if err != nil {
 		return nil, err
@@ -160,6 +251,14 @@ func (c *Client) do(ctx context.Context, method, endpoint string, queryMap map[s
 		}
 	}
 
+	// tell an endpoint missing from an old package apart from a missing object
+	if res.StatusCode == http.StatusNotFound {
+		if err = c.unsupportedError(ctx, endpoint); err != nil {
+			_ = res.Body.Close()
+			return nil, err
+		}
+	}
+
 	return res, nil
 }
 
@@ -246,6 +345,15 @@ func (c *Client) generateToken(ctx context.Context) (string, error) {
 }
 
 func (c *Client) get(ctx context.Context, endpoint string, queryMap map[string]string) ([]byte, error) {
+	var generation uint64
+	if c.cache != nil {
+		cached, gen, ok := c.cache.get(endpoint, queryMap)
+		if ok && !cacheBypassed(ctx) {
+			return cached, nil
+		}
+		generation = gen
+	}
+
 	res, err := c.do(ctx, http.MethodGet, endpoint, queryMap, nil)
 	if err != nil {
 		return nil, err
@@ -261,16 +369,11 @@ func (c *Client) get(ctx context.Context, endpoint string, queryMap map[string]s
 	}
 
 	if res.StatusCode < 200 || res.StatusCode > 299 {
-		err, ok := responseCodeErrorMap[res.StatusCode]
-		if !ok {
-			err = fmt.Errorf("non 2xx response code received: %d", res.StatusCode)
-		}
+		return nil, statusError(res.StatusCode, respbody)
+	}
 
-		resp := new(apiResponse)
-		if jsonerr := json.Unmarshal(respbody, resp); jsonerr != nil {
-			return nil, err
-		}
-		return nil, fmt.Errorf("%w: %s", err, resp.Message)
+	if c.cache != nil {
+		c.cache.set(endpoint, queryMap, respbody, generation)
 	}
 
 	return respbody, nil
@@ -292,16 +395,7 @@ func (c *Client) post(ctx context.Context, endpoint string, queryMap map[string]
 	}
 
 	if res.StatusCode < 200 || res.StatusCode > 299 {
-		err, ok := responseCodeErrorMap[res.StatusCode]
-		if !ok {
-			err = fmt.Errorf("non 2xx response code received: %d", res.StatusCode)
-		}
-
-		resp := new(apiResponse)
-		if jsonerr := json.Unmarshal(respbody, resp); jsonerr != nil {
-			return nil, err
-		}
-		return nil, fmt.Errorf("%w: %s", err, resp.Message)
+		return nil, statusError(res.StatusCode, respbody)
 	}
 
 	return respbody, nil
@@ -315,7 +409,7 @@ func (c *Client) patch(ctx context.Context, endpoint string, queryMap map[string
 	}
 	defer func() {
 		_, _ = io.Copy(io.Discard, res.Body)
-		_ = res.Body.Close
+		_ = res.Body.Close()
 	}()
 
 	respbody, err := io.ReadAll(res.Body)
@@ -324,16 +418,7 @@ func (c *Client) patch(ctx context.Context, endpoint string, queryMap map[string
 	}
 
 	if res.StatusCode < 200 || res.StatusCode > 299 {
-		err, ok := responseCodeErrorMap[res.StatusCode]
-		if !ok {
-			err = fmt.Errorf("non 2xx response code received: %d", res.StatusCode)
-		}
-
-		resp := new(apiResponse)
-		if jsonerr := json.Unmarshal(respbody, resp); jsonerr != nil {
-			return nil, err
-		}
-		return nil, fmt.Errorf("%w: %s", err, resp.Message)
+		return nil, statusError(res.StatusCode, respbody)
 	}
 
 	return respbody, nil
@@ -355,16 +440,7 @@ func (c *Client) put(ctx context.Context, endpoint string, queryMap map[string]s
 	}
 
 	if res.StatusCode < 200 || res.StatusCode > 299 {
-		err, ok := responseCodeErrorMap[res.StatusCode]
-		if !ok {
-			err = fmt.Errorf("non 2xx response code received: %d", res.StatusCode)
-		}
-
-		resp := new(apiResponse)
-		if jsonerr := json.Unmarshal(respbody, resp); jsonerr != nil {
-			return nil, err
-		}
-		return nil, fmt.Errorf("%w: %s", err, resp.Message)
+		return nil, statusError(res.StatusCode, respbody)
 	}
 
 	return respbody, nil
@@ -386,19 +462,69 @@ func (c *Client) delete(ctx context.Context, endpoint string, queryMap map[strin
 	}
 
 	if res.StatusCode < 200 || res.StatusCode > 299 {
-		err, ok := responseCodeErrorMap[res.StatusCode]
-		if !ok {
-			err = fmt.Errorf("non 2xx response code received: %d", res.StatusCode)
-		}
+		return nil, statusError(res.StatusCode, respbody)
+	}
+
+	return respbody, nil
+}
 
-		resp := new(apiResponse)
-		if jsonerr := json.Unmarshal(respbody, resp); jsonerr != nil {
+// statusError returns the error for a non 2xx response, carrying the message
+// of the API's error envelope when the body holds one.
+func statusError(statusCode int, respbody []byte) error {
+	err := statusCodeError(statusCode)
+
+	resp := new(apiResponse)
+	if jsonerr := json.Unmarshal(respbody, resp); jsonerr != nil {
+		return err
+	}
+	return fmt.Errorf("%w: %s", err, resp.Message)
+}
+
+// statusCodeError returns the sentinel error for a non 2xx status code.
+func statusCodeError(statusCode int) error {
+	if err, ok := responseCodeErrorMap[statusCode]; ok {
+		return err
+	}
+	return fmt.Errorf("non 2xx response code received: %d", statusCode)
+}
+
+// lockKey waits until no other holder of key in locks is running on this
+// client, and returns the func that lets the next one go. locks maps keys to
+// chan struct{} with a buffer of one.
+func lockKey(ctx context.Context, locks *sync.Map, key string) (func(), error) {
+	lock, _ := locks.LoadOrStore(key, make(chan struct{}, 1))
+	ch := lock.(chan struct{})
+	select {
+	case ch <- struct{}{}:
+		return func() { <-ch }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// lockKeys locks each of keys with lockKey, in sorted order so that two
+// callers locking overlapping sets cannot deadlock, and returns the func
+// that unlocks them all.
+func lockKeys(ctx context.Context, locks *sync.Map, keys []string) (func(), error) {
+	sorted := append([]string(nil), keys...)
+	slices.Sort(sorted)
+	sorted = slices.Compact(sorted)
+
+	var unlocks []func()
+	unlockAll := func() {
+		for i := len(unlocks) - 1; i >= 0; i-- {
+			unlocks[i]()
+		}
+	}
+	for _, key := range sorted {
+		unlock, err := lockKey(ctx, locks, key)
+		if err != nil {
+			unlockAll()
 			return nil, err
 		}
-		return nil, fmt.Errorf("%w: %s", err, resp.Message)
+		unlocks = append(unlocks, unlock)
 	}
-
-	return respbody, nil
+	return unlockAll, nil
 }
 
 type apiResponse struct {
@@ -0,0 +1,130 @@
+package pfsenseapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// PlannedOperation is a change that a client in dry-run mode would have sent
+// to the firewall.
+type PlannedOperation struct {
+	Method   string            `json:"method"`
+	Endpoint string            `json:"endpoint"`
+	Query    map[string]string `json:"query,omitempty"`
+	Body     json.RawMessage   `json:"body,omitempty"`
+}
+
+// DryRunPlan is the list of changes recorded by a client in dry-run mode, in
+// the order they were made. It encodes as JSON for review before the changes
+// are applied for real.
+type DryRunPlan struct {
+	Host       string             `json:"host"`
+	Operations []PlannedOperation `json:"operations"`
+}
+
+type readOnlyKey struct{}
+
+// readOnly marks a request that is sent with a method other than GET but does
+// not change anything, such as a GraphQL query, so dry-run mode lets it
+// through.
+func readOnly(ctx context.Context) context.Context {
+	return context.WithValue(ctx, readOnlyKey{}, true)
+}
+
+func isReadOnly(ctx context.Context) bool {
+	ro, _ := ctx.Value(readOnlyKey{}).(bool)
+	return ro
+}
+
+// Plan returns the changes recorded since the client was created or the plan
+// was last reset. It is empty unless Config.DryRun is set.
+func (c *Client) Plan() DryRunPlan {
+	c.planMu.Lock()
+	defer c.planMu.Unlock()
+
+	ops := make([]PlannedOperation, len(c.plan))
+	copy(ops, c.plan)
+	return DryRunPlan{Host: c.Cfg.Host, Operations: ops}
+}
+
+// ResetPlan discards the recorded changes.
+func (c *Client) ResetPlan() {
+	c.planMu.Lock()
+	defer c.planMu.Unlock()
+	c.plan = nil
+}
+
+// record adds a change to the plan and returns the response the firewall
+// would most likely have given for it.
+func (c *Client) record(method, endpoint string, queryMap map[string]string, body []byte) (*http.Response, error) {
+	op := PlannedOperation{
+		Method:   method,
+		Endpoint: strings.Trim(endpoint, "/"),
+		Body:     bytes.Clone(body),
+	}
+	if len(queryMap) > 0 {
+		op.Query = make(map[string]string, len(queryMap))
+		for k, v := range queryMap {
+			op.Query[k] = v
+		}
+	}
+
+	c.planMu.Lock()
+	c.plan = append(c.plan, op)
+	c.planMu.Unlock()
+
+	return plannedResponse(op)
+}
+
+// plannedResponse synthesizes a successful response to op from the request
+// itself: the object sent, or for requests without a body the object the
+// query names. Query values are all strings, so those holding a whole
+// number, such as IDs, are sent back as numbers.
+func plannedResponse(op PlannedOperation) (*http.Response, error) {
+	if op.Endpoint == graphQLEndpoint {
+		return inMemoryResponse(http.StatusOK, []byte(`{"data": null}`)), nil
+	}
+
+	var data any
+	switch {
+	case len(op.Body) > 0:
+		data = op.Body
+	case isPluralEndpoint(op.Endpoint):
+		data = []any{}
+	case len(op.Query) > 0:
+		object := make(map[string]any, len(op.Query))
+		for key, value := range op.Query {
+			if n, err := strconv.Atoi(value); err == nil {
+				object[key] = n
+			} else {
+				object[key] = value
+			}
+		}
+		data = object
+	}
+
+	body, err := json.Marshal(struct {
+		apiResponse
+		Data any `json:"data"`
+	}{
+		apiResponse: apiResponse{
+			Status:  "ok",
+			Code:    http.StatusOK,
+			Message: "dry run: request not sent",
+		},
+		Data: data,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return inMemoryResponse(http.StatusOK, body), nil
+}
+
+func isPluralEndpoint(endpoint string) bool {
+	_, ok := endpointFamilies[endpoint]
+	return ok
+}
@@ -0,0 +1,106 @@
+package pfsenseapi
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/markphelps/optional"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_DryRun(t *testing.T) {
+	responses := map[string]string{
+		"/" + interfaceVLANsEndpoint: mustReadFileString(t, "testdata/multiplevlan.json"),
+		"/" + graphQLEndpoint:        mustReadFileString(t, "testdata/graphql.json"),
+	}
+
+	var mu sync.Mutex
+	var methods []string
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		methods = append(methods, r.Method+" "+r.URL.Path)
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		_, err := io.WriteString(w, responses[r.URL.Path])
+		require.NoError(t, err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(handler))
+	defer server.Close()
+
+	newClient := NewClient(Config{Host: server.URL, DryRun: true})
+	ctx := context.Background()
+
+	vlans, err := newClient.Interface.ListVLANs(ctx)
+	require.NoError(t, err)
+	require.Len(t, vlans, 2)
+
+	out := new(graphQLTestData)
+	require.NoError(t, newClient.GraphQL.Query(ctx, "{ interfaces { id } }", nil, out))
+	require.Len(t, out.Interfaces, 2)
+
+	desc := optional.NewString("planned")
+	created, err := newClient.Interface.CreateVLAN(ctx, VLANRequest{If: "em1", Tag: 300, Descr: &desc})
+	require.NoError(t, err)
+	require.Equal(t, 300, created.Tag)
+	require.Equal(t, "em1", created.If)
+
+	deleted, err := newClient.Interface.DeleteVLAN(ctx, 4)
+	require.NoError(t, err)
+	require.Equal(t, 4, deleted.Id)
+
+	many, err := newClient.Interface.DeleteManyVLANs(ctx, DeleteQuery{All: true})
+	require.NoError(t, err)
+	require.Empty(t, many)
+
+	require.NoError(t, newClient.Interface.Apply(ctx))
+	require.NoError(t, newClient.GraphQL.Mutate(ctx, "mutation { x }", nil, nil))
+
+	// only the reads reached the firewall
+	require.Equal(t, []string{
+		"GET /" + interfaceVLANsEndpoint,
+		"POST /" + graphQLEndpoint,
+	}, methods)
+
+	plan := newClient.Plan()
+	require.Equal(t, server.URL, plan.Host)
+	require.Len(t, plan.Operations, 5)
+	require.Equal(t, PlannedOperation{
+		Method:   http.MethodDelete,
+		Endpoint: interfaceVLANEndpoint,
+		Query:    map[string]string{"id": "4"},
+	}, plan.Operations[1])
+	require.Equal(t, http.MethodPost, plan.Operations[3].Method)
+	require.Equal(t, interfaceApplyEndpoint, plan.Operations[3].Endpoint)
+
+	data, err := json.Marshal(plan)
+	require.NoError(t, err)
+	var exported struct {
+		Operations []struct {
+			Method string         `json:"method"`
+			Body   map[string]any `json:"body"`
+		} `json:"operations"`
+	}
+	require.NoError(t, json.Unmarshal(data, &exported))
+	require.Equal(t, http.MethodPost, exported.Operations[0].Method)
+	require.Equal(t, float64(300), exported.Operations[0].Body["tag"])
+	require.Equal(t, "planned", exported.Operations[0].Body["descr"])
+
+	newClient.ResetPlan()
+	require.Empty(t, newClient.Plan().Operations)
+}
+
+func TestClient_DryRunOff(t *testing.T) {
+	data := mustReadFileString(t, "testdata/singlevlan.json")
+	server := setupTestServer(t, data)
+	defer server.Close()
+
+	newClient := NewClientWithNoAuth(server.URL)
+	_, err := newClient.Interface.DeleteVLAN(context.Background(), 1)
+	require.NoError(t, err)
+	require.Empty(t, newClient.Plan().Operations)
+}
@@ -0,0 +1,549 @@
+package pfsenseapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// The models keep the fields of an object that they do not declare in an
+// Extra map, so they can be sent back unchanged. marshalWithExtra and
+// unmarshalWithExtra implement this for any model from its json struct tags;
+// the model types only have to call them from MarshalJSON and UnmarshalJSON.
+
+// jsonField is a field of a struct as encoding/json sees it.
+type jsonField struct {
+	name      string
+	omitEmpty bool
+	depth     int
+	value     reflect.Value
+}
+
+// jsonFields returns the JSON fields of the struct v points to, including
+// those of embedded structs, and the Extra map it holds them next to. As in
+// encoding/json, a field hides fields of the same name nested deeper. Nil
+// embedded pointers are allocated when alloc is set and skipped otherwise.
+func jsonFields(v reflect.Value, alloc bool) ([]jsonField, reflect.Value) {
+	var fields []jsonField
+	var extra reflect.Value
+	var walk func(v reflect.Value, depth int)
+	walk = func(v reflect.Value, depth int) {
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			tag := f.Tag.Get("json")
+			if f.Name == "Extra" && f.Type == reflect.TypeOf(map[string]json.RawMessage(nil)) {
+				extra = v.Field(i)
+				continue
+			}
+			if tag == "-" {
+				continue
+			}
+			name, opts, _ := strings.Cut(tag, ",")
+
+			fv := v.Field(i)
+			if f.Anonymous && name == "" {
+				if fv.Kind() == reflect.Pointer && fv.Type().Elem().Kind() == reflect.Struct {
+					if fv.IsNil() {
+						if !alloc {
+							continue
+						}
+						fv.Set(reflect.New(fv.Type().Elem()))
+					}
+					fv = fv.Elem()
+				}
+				if fv.Kind() == reflect.Struct {
+					walk(fv, depth+1)
+					continue
+				}
+			}
+			if !f.IsExported() {
+				continue
+			}
+			if name == "" {
+				name = f.Name
+			}
+			fields = append(fields, jsonField{name: name, omitEmpty: opts == "omitempty", depth: depth, value: fv})
+		}
+	}
+	walk(v.Elem(), 0)
+
+	shallowest := make(map[string]int, len(fields))
+	for _, f := range fields {
+		if d, ok := shallowest[f.name]; !ok || f.depth < d {
+			shallowest[f.name] = f.depth
+		}
+	}
+	visible := fields[:0]
+	for _, f := range fields {
+		if f.depth == shallowest[f.name] {
+			visible = append(visible, f)
+			shallowest[f.name] = -1
+		}
+	}
+	return visible, extra
+}
+
+// marshalWithExtra encodes the model v points to, followed by the members of
+// its Extra map that no field declares, sorted by key.
+func marshalWithExtra[T any](v *T) ([]byte, error) {
+	fields, extra := jsonFields(reflect.ValueOf(v), false)
+
+	buf := new(bytes.Buffer)
+	buf.WriteByte('{')
+	write := func(key string, value []byte) error {
+		if buf.Len() > 1 {
+			buf.WriteByte(',')
+		}
+		name, err := json.Marshal(key)
+		if err != nil {
+			return err
+		}
+		buf.Write(name)
+		buf.WriteByte(':')
+		return json.Compact(buf, value)
+	}
+
+	for _, f := range fields {
+		if f.omitEmpty && isEmptyValue(f.value) {
+			continue
+		}
+		value, err := json.Marshal(f.value.Addr().Interface())
+		if err != nil {
+			return nil, err
+		}
+		if err = write(f.name, value); err != nil {
+			return nil, err
+		}
+	}
+
+	if extra.IsValid() && extra.Len() > 0 {
+		members := extra.Interface().(map[string]json.RawMessage)
+		keys := make([]string, 0, len(members))
+		for key := range members {
+			if _, ok := declared(fields, key); !ok {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if err := write(key, members[key]); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// unmarshalWithExtra decodes data into the model v points to, keeping the
+// members no field declares in its Extra map. An empty array, which PHP also
+// uses for an empty object, decodes as an empty object.
+func unmarshalWithExtra[T any](data []byte, v *T) error {
+	if isNull(data) || isEmptyArray(data) {
+		return nil
+	}
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(data, &members); err != nil {
+		return &json.UnmarshalTypeError{Value: jsonKind(data), Type: reflect.TypeOf(v).Elem()}
+	}
+
+	fields, extra := jsonFields(reflect.ValueOf(v), true)
+	var unknown map[string]json.RawMessage
+	for key, value := range members {
+		f, ok := declared(fields, key)
+		if !ok {
+			if unknown == nil {
+				unknown = make(map[string]json.RawMessage)
+			}
+			unknown[key] = value
+			continue
+		}
+		if err := json.Unmarshal(value, f.value.Addr().Interface()); err != nil {
+			var typeErr *json.UnmarshalTypeError
+			if errors.As(err, &typeErr) && typeErr.Field == "" {
+				typeErr.Struct = reflect.TypeOf(v).Elem().Name()
+				typeErr.Field = f.name
+			}
+			return err
+		}
+	}
+	if extra.IsValid() {
+		extra.Set(reflect.ValueOf(unknown))
+	}
+	return nil
+}
+
+// declared returns the field key names, matching the way encoding/json
+// does: exactly, or failing that without regard to case.
+func declared(fields []jsonField, key string) (jsonField, bool) {
+	for _, f := range fields {
+		if f.name == key {
+			return f, true
+		}
+	}
+	for _, f := range fields {
+		if strings.EqualFold(f.name, key) {
+			return f, true
+		}
+	}
+	return jsonField{}, false
+}
+
+// isEmptyValue reports whether v is empty in the sense of omitempty.
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64,
+		reflect.Interface, reflect.Pointer:
+		return v.IsZero()
+	}
+	return false
+}
+
+// MarshalJSON encodes the request along with its Extra fields.
+func (r InterfaceRequest) MarshalJSON() ([]byte, error) {
+	return marshalWithExtra(&r)
+}
+
+// UnmarshalJSON decodes the request, keeping the fields it does not declare
+// in Extra.
+func (r *InterfaceRequest) UnmarshalJSON(data []byte) error {
+	return unmarshalWithExtra(data, r)
+}
+
+// MarshalJSON encodes the interface along with its Extra fields.
+func (i Interface) MarshalJSON() ([]byte, error) {
+	return marshalWithExtra(&i)
+}
+
+// UnmarshalJSON decodes the interface, keeping the fields it does not declare
+// in Extra.
+func (i *Interface) UnmarshalJSON(data []byte) error {
+	return unmarshalWithExtra(data, i)
+}
+
+// MarshalJSON encodes the request along with its Extra fields.
+func (r VLANRequest) MarshalJSON() ([]byte, error) {
+	return marshalWithExtra(&r)
+}
+
+// UnmarshalJSON decodes the request, keeping the fields it does not declare
+// in Extra.
+func (r *VLANRequest) UnmarshalJSON(data []byte) error {
+	return unmarshalWithExtra(data, r)
+}
+
+// MarshalJSON encodes the VLAN along with its Extra fields.
+func (v VLAN) MarshalJSON() ([]byte, error) {
+	return marshalWithExtra(&v)
+}
+
+// UnmarshalJSON decodes the VLAN, keeping the fields it does not declare
+// in Extra.
+func (v *VLAN) UnmarshalJSON(data []byte) error {
+	return unmarshalWithExtra(data, v)
+}
+
+// MarshalJSON encodes the request along with its Extra fields.
+func (r InterfaceGroupRequest) MarshalJSON() ([]byte, error) {
+	return marshalWithExtra(&r)
+}
+
+// UnmarshalJSON decodes the request, keeping the fields it does not declare
+// in Extra.
+func (r *InterfaceGroupRequest) UnmarshalJSON(data []byte) error {
+	return unmarshalWithExtra(data, r)
+}
+
+// MarshalJSON encodes the interface group along with its Extra fields.
+func (g InterfaceGroup) MarshalJSON() ([]byte, error) {
+	return marshalWithExtra(&g)
+}
+
+// UnmarshalJSON decodes the interface group, keeping the fields it does not declare
+// in Extra.
+func (g *InterfaceGroup) UnmarshalJSON(data []byte) error {
+	return unmarshalWithExtra(data, g)
+}
+
+// MarshalJSON encodes the request along with its Extra fields.
+func (r InterfaceBridgeRequest) MarshalJSON() ([]byte, error) {
+	return marshalWithExtra(&r)
+}
+
+// UnmarshalJSON decodes the request, keeping the fields it does not declare
+// in Extra.
+func (r *InterfaceBridgeRequest) UnmarshalJSON(data []byte) error {
+	return unmarshalWithExtra(data, r)
+}
+
+// MarshalJSON encodes the bridge along with its Extra fields.
+func (b InterfaceBridge) MarshalJSON() ([]byte, error) {
+	return marshalWithExtra(&b)
+}
+
+// UnmarshalJSON decodes the bridge, keeping the fields it does not declare
+// in Extra.
+func (b *InterfaceBridge) UnmarshalJSON(data []byte) error {
+	return unmarshalWithExtra(data, b)
+}
+
+// MarshalJSON encodes the request along with its Extra fields.
+func (r UserRequest) MarshalJSON() ([]byte, error) {
+	return marshalWithExtra(&r)
+}
+
+// UnmarshalJSON decodes the request, keeping the fields it does not declare
+// in Extra.
+func (r *UserRequest) UnmarshalJSON(data []byte) error {
+	return unmarshalWithExtra(data, r)
+}
+
+// MarshalJSON encodes the user along with its Extra fields.
+func (u User) MarshalJSON() ([]byte, error) {
+	return marshalWithExtra(&u)
+}
+
+// UnmarshalJSON decodes the user, keeping the fields it does not declare
+// in Extra.
+func (u *User) UnmarshalJSON(data []byte) error {
+	return unmarshalWithExtra(data, u)
+}
+
+// MarshalJSON encodes the request along with its Extra fields.
+func (r UserGroupRequest) MarshalJSON() ([]byte, error) {
+	return marshalWithExtra(&r)
+}
+
+// UnmarshalJSON decodes the request, keeping the fields it does not declare
+// in Extra.
+func (r *UserGroupRequest) UnmarshalJSON(data []byte) error {
+	return unmarshalWithExtra(data, r)
+}
+
+// MarshalJSON encodes the user group along with its Extra fields.
+func (g UserGroup) MarshalJSON() ([]byte, error) {
+	return marshalWithExtra(&g)
+}
+
+// UnmarshalJSON decodes the user group, keeping the fields it does not declare
+// in Extra.
+func (g *UserGroup) UnmarshalJSON(data []byte) error {
+	return unmarshalWithExtra(data, g)
+}
+
+// MarshalJSON encodes the request along with its Extra fields.
+func (r FirewallRuleRequest) MarshalJSON() ([]byte, error) {
+	return marshalWithExtra(&r)
+}
+
+// UnmarshalJSON decodes the request, keeping the fields it does not declare
+// in Extra.
+func (r *FirewallRuleRequest) UnmarshalJSON(data []byte) error {
+	return unmarshalWithExtra(data, r)
+}
+
+// MarshalJSON encodes the rule along with its Extra fields.
+func (f FirewallRule) MarshalJSON() ([]byte, error) {
+	return marshalWithExtra(&f)
+}
+
+// UnmarshalJSON decodes the rule, keeping the fields it does not declare
+// in Extra.
+func (f *FirewallRule) UnmarshalJSON(data []byte) error {
+	return unmarshalWithExtra(data, f)
+}
+
+// aliasEntryLists holds the entries of an alias the way the API sends them,
+// as two lists of the same length.
+type aliasEntryLists struct {
+	Address FlexStringList `json:"address"`
+	Detail  FlexStringList `json:"detail"`
+}
+
+func splitAliasEntries(entries []AliasEntry) aliasEntryLists {
+	lists := aliasEntryLists{
+		Address: make([]string, len(entries)),
+		Detail:  make([]string, len(entries)),
+	}
+	for i, entry := range entries {
+		lists.Address[i] = entry.Address
+		lists.Detail[i] = entry.Detail
+	}
+	return lists
+}
+
+// entries pairs each address with its detail. pfSense leaves the detail list
+// short when the last entries have no detail.
+func (l aliasEntryLists) entries() []AliasEntry {
+	if l.Address == nil {
+		return nil
+	}
+	entries := make([]AliasEntry, len(l.Address))
+	for i, address := range l.Address {
+		entries[i].Address = address
+		if i < len(l.Detail) {
+			entries[i].Detail = l.Detail[i]
+		}
+	}
+	return entries
+}
+
+// MarshalJSON encodes the request along with its entries and Extra fields.
+func (r AliasRequest) MarshalJSON() ([]byte, error) {
+	return marshalWithExtra(&struct {
+		*AliasRequest
+		aliasEntryLists
+	}{&r, splitAliasEntries(r.Entries)})
+}
+
+// UnmarshalJSON decodes the request, pairing up its entries and keeping the
+// fields it does not declare in Extra.
+func (r *AliasRequest) UnmarshalJSON(data []byte) error {
+	var lists aliasEntryLists
+	err := unmarshalWithExtra(data, &struct {
+		*AliasRequest
+		*aliasEntryLists
+	}{r, &lists})
+	r.Entries = lists.entries()
+	return err
+}
+
+// MarshalJSON encodes the alias along with its entries and Extra fields.
+func (a Alias) MarshalJSON() ([]byte, error) {
+	return marshalWithExtra(&struct {
+		*Alias
+		aliasEntryLists
+	}{&a, splitAliasEntries(a.Entries)})
+}
+
+// UnmarshalJSON decodes the alias, pairing up its entries and keeping the
+// fields it does not declare in Extra.
+func (a *Alias) UnmarshalJSON(data []byte) error {
+	var lists aliasEntryLists
+	err := unmarshalWithExtra(data, &struct {
+		*Alias
+		*aliasEntryLists
+	}{a, &lists})
+	a.Entries = lists.entries()
+	return err
+}
+
+// MarshalJSON encodes the request along with its Extra fields.
+func (r PortForwardRequest) MarshalJSON() ([]byte, error) {
+	return marshalWithExtra(&r)
+}
+
+// UnmarshalJSON decodes the request, keeping the fields it does not declare
+// in Extra.
+func (r *PortForwardRequest) UnmarshalJSON(data []byte) error {
+	return unmarshalWithExtra(data, r)
+}
+
+// MarshalJSON encodes the port forward along with its Extra fields.
+func (p PortForward) MarshalJSON() ([]byte, error) {
+	return marshalWithExtra(&p)
+}
+
+// UnmarshalJSON decodes the port forward, keeping the fields it does not declare
+// in Extra.
+func (p *PortForward) UnmarshalJSON(data []byte) error {
+	return unmarshalWithExtra(data, p)
+}
+
+// MarshalJSON encodes the request along with its Extra fields.
+func (r OutboundMappingRequest) MarshalJSON() ([]byte, error) {
+	return marshalWithExtra(&r)
+}
+
+// UnmarshalJSON decodes the request, keeping the fields it does not declare
+// in Extra.
+func (r *OutboundMappingRequest) UnmarshalJSON(data []byte) error {
+	return unmarshalWithExtra(data, r)
+}
+
+// MarshalJSON encodes the mapping along with its Extra fields.
+func (m OutboundMapping) MarshalJSON() ([]byte, error) {
+	return marshalWithExtra(&m)
+}
+
+// UnmarshalJSON decodes the mapping, keeping the fields it does not declare
+// in Extra.
+func (m *OutboundMapping) UnmarshalJSON(data []byte) error {
+	return unmarshalWithExtra(data, m)
+}
+
+// MarshalJSON encodes the request along with its Extra fields.
+func (r OneToOneMappingRequest) MarshalJSON() ([]byte, error) {
+	return marshalWithExtra(&r)
+}
+
+// UnmarshalJSON decodes the request, keeping the fields it does not declare
+// in Extra.
+func (r *OneToOneMappingRequest) UnmarshalJSON(data []byte) error {
+	return unmarshalWithExtra(data, r)
+}
+
+// MarshalJSON encodes the mapping along with its Extra fields.
+func (m OneToOneMapping) MarshalJSON() ([]byte, error) {
+	return marshalWithExtra(&m)
+}
+
+// UnmarshalJSON decodes the mapping, keeping the fields it does not declare
+// in Extra.
+func (m *OneToOneMapping) UnmarshalJSON(data []byte) error {
+	return unmarshalWithExtra(data, m)
+}
+
+// MarshalJSON encodes the request along with its Extra fields.
+func (r ScheduleRequest) MarshalJSON() ([]byte, error) {
+	return marshalWithExtra(&r)
+}
+
+// UnmarshalJSON decodes the request, keeping the fields it does not declare
+// in Extra.
+func (r *ScheduleRequest) UnmarshalJSON(data []byte) error {
+	return unmarshalWithExtra(data, r)
+}
+
+// MarshalJSON encodes the schedule along with its Extra fields.
+func (s Schedule) MarshalJSON() ([]byte, error) {
+	return marshalWithExtra(&s)
+}
+
+// UnmarshalJSON decodes the schedule, keeping the fields it does not declare
+// in Extra.
+func (s *Schedule) UnmarshalJSON(data []byte) error {
+	return unmarshalWithExtra(data, s)
+}
+
+// MarshalJSON encodes the request along with its Extra fields.
+func (r VirtualIPRequest) MarshalJSON() ([]byte, error) {
+	return marshalWithExtra(&r)
+}
+
+// UnmarshalJSON decodes the request, keeping the fields it does not declare
+// in Extra.
+func (r *VirtualIPRequest) UnmarshalJSON(data []byte) error {
+	return unmarshalWithExtra(data, r)
+}
+
+// MarshalJSON encodes the virtual IP along with its Extra fields.
+func (v VirtualIP) MarshalJSON() ([]byte, error) {
+	return marshalWithExtra(&v)
+}
+
+// UnmarshalJSON decodes the virtual IP, keeping the fields it does not declare
+// in Extra.
+func (v *VirtualIP) UnmarshalJSON(data []byte) error {
+	return unmarshalWithExtra(data, v)
+}
@@ -0,0 +1,114 @@
+package pfsenseapi
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtraFields_Decode(t *testing.T) {
+	data := mustReadFileString(t, "testdata/singlevlanextra.json")
+	server := setupTestServer(t, data)
+	defer server.Close()
+
+	newClient := NewClientWithNoAuth(server.URL)
+	vlan, err := newClient.Interface.GetVLAN(context.Background(), 2)
+	require.NoError(t, err)
+	require.Equal(t, 2, vlan.Id)
+	require.Equal(t, 30, vlan.Tag)
+	require.Len(t, vlan.Extra, 2)
+	require.JSONEq(t, `"em1.10"`, string(vlan.Extra["qinq_parent"]))
+	require.JSONEq(t, `{"mtu": 1500, "tags": ["a", "b"]}`, string(vlan.Extra["options"]))
+
+	vlan, err = newClient.Interface.GetVLAN(context.Background(), 2)
+	require.Error(t, err)
+	require.Nil(t, vlan)
+
+	vlan, err = newClient.Interface.GetVLAN(context.Background(), 2)
+	require.Error(t, err)
+	require.Nil(t, vlan)
+}
+
+func TestExtraFields_RoundTrip(t *testing.T) {
+	data := mustReadFileString(t, "testdata/singlevlanextra.json")
+
+	var received map[string]any
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPatch {
+			body, err := io.ReadAll(r.Body)
+			require.NoError(t, err)
+			require.NoError(t, json.Unmarshal(body, &received))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, err := io.WriteString(w, data)
+		require.NoError(t, err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(handler))
+	defer server.Close()
+
+	newClient := NewClientWithNoAuth(server.URL)
+	vlan, err := newClient.Interface.GetVLAN(context.Background(), 2)
+	require.NoError(t, err)
+
+	vlan.Tag = 31
+	_, err = newClient.Interface.UpdateVLAN(context.Background(), vlan.Id, vlan.VLANRequest)
+	require.NoError(t, err)
+	require.Equal(t, float64(2), received["id"])
+	require.Equal(t, float64(31), received["tag"])
+	require.Equal(t, "em1.10", received["qinq_parent"])
+	require.Equal(t, map[string]any{"mtu": float64(1500), "tags": []any{"a", "b"}}, received["options"])
+}
+
+func TestExtraFields_DeclaredFieldsWin(t *testing.T) {
+	user := User{
+		UserRequest: UserRequest{
+			Name: "user1",
+			Extra: map[string]json.RawMessage{
+				"name":    json.RawMessage(`"shadowed"`),
+				"sshkeys": json.RawMessage(`[ "key1" ]`),
+			},
+		},
+		Id:  3,
+		UID: 2000,
+	}
+
+	data, err := json.Marshal(user)
+	require.NoError(t, err)
+
+	var decoded map[string]any
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	require.Equal(t, "user1", decoded["name"])
+	require.Equal(t, float64(3), decoded["id"])
+	require.Equal(t, float64(2000), decoded["uid"])
+	require.Equal(t, []any{"key1"}, decoded["sshkeys"])
+
+	var roundTripped User
+	require.NoError(t, json.Unmarshal(data, &roundTripped))
+	require.Equal(t, 3, roundTripped.Id)
+	require.Equal(t, map[string]json.RawMessage{"sshkeys": json.RawMessage(`["key1"]`)}, roundTripped.Extra)
+
+	// models without unknown fields leave Extra nil
+	var plain InterfaceGroup
+	require.NoError(t, json.Unmarshal([]byte(`{"id": 1, "ifname": "servers", "members": ["lan"]}`), &plain))
+	require.Nil(t, plain.Extra)
+	require.Equal(t, "servers", plain.Ifname)
+}
+
+func TestExtraFields_Errors(t *testing.T) {
+	var vlan VLAN
+	err := json.Unmarshal([]byte(`{"id": 2, "tag": "thirty"}`), &vlan)
+	require.ErrorContains(t, err, "VLAN.tag")
+
+	err = json.Unmarshal([]byte(`"vlan"`), &vlan)
+	require.ErrorContains(t, err, "cannot unmarshal string")
+
+	// null leaves the model as it is
+	vlan = VLAN{Id: 2}
+	require.NoError(t, json.Unmarshal([]byte(`null`), &vlan))
+	require.Equal(t, 2, vlan.Id)
+}
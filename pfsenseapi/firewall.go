@@ -0,0 +1,286 @@
+package pfsenseapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/markphelps/optional"
+)
+
+const (
+	firewallRuleEndpoint  = "api/v2/firewall/rule"
+	firewallRulesEndpoint = "api/v2/firewall/rules"
+	firewallApplyEndpoint = "api/v2/firewall/apply"
+)
+
+// FirewallService provides firewall API methods
+type FirewallService service
+
+// RuleType is the action a firewall rule takes on matching traffic.
+type RuleType string
+
+const (
+	RuleTypePass   RuleType = "pass"
+	RuleTypeBlock  RuleType = "block"
+	RuleTypeReject RuleType = "reject"
+)
+
+// IPProtocol is the address family a rule matches.
+type IPProtocol string
+
+const (
+	IPProtocolInet   IPProtocol = "inet"
+	IPProtocolInet6  IPProtocol = "inet6"
+	IPProtocolInet46 IPProtocol = "inet46"
+)
+
+// RuleProtocol is the layer 4 protocol a rule matches. The empty value
+// matches any protocol.
+type RuleProtocol string
+
+const (
+	ProtocolAny    RuleProtocol = ""
+	ProtocolTCP    RuleProtocol = "tcp"
+	ProtocolUDP    RuleProtocol = "udp"
+	ProtocolTCPUDP RuleProtocol = "tcp/udp"
+	ProtocolICMP   RuleProtocol = "icmp"
+	ProtocolESP    RuleProtocol = "esp"
+	ProtocolAH     RuleProtocol = "ah"
+	ProtocolGRE    RuleProtocol = "gre"
+	ProtocolIPv6   RuleProtocol = "ipv6"
+	ProtocolIGMP   RuleProtocol = "igmp"
+	ProtocolPIM    RuleProtocol = "pim"
+	ProtocolOSPF   RuleProtocol = "ospf"
+	ProtocolCARP   RuleProtocol = "carp"
+	ProtocolPFSync RuleProtocol = "pfsync"
+)
+
+// hasPorts reports whether the protocol has ports a rule can match on.
+func (p RuleProtocol) hasPorts() bool {
+	return p == ProtocolTCP || p == ProtocolUDP || p == ProtocolTCPUDP
+}
+
+// RuleDirection is the direction of traffic a floating rule matches.
+type RuleDirection string
+
+const (
+	DirectionAny RuleDirection = "any"
+	DirectionIn  RuleDirection = "in"
+	DirectionOut RuleDirection = "out"
+)
+
+// RuleAddress is the source or destination of a rule: "any", an interface
+// such as "lan" or "lan:ip", an address or network, or an alias. Not inverts
+// the match, which pfSense encodes as a leading "!".
+type RuleAddress struct {
+	Address string
+	Not     bool
+}
+
+// AnyAddress matches every address.
+var AnyAddress = RuleAddress{Address: "any"}
+
+func (a RuleAddress) String() string {
+	if a.Not {
+		return "!" + a.Address
+	}
+	return a.Address
+}
+
+// ParseRuleAddress parses an address in the form pfSense returns it, e.g.
+// "!10.0.0.0/8".
+func ParseRuleAddress(s string) RuleAddress {
+	if rest, ok := strings.CutPrefix(s, "!"); ok {
+		return RuleAddress{Address: rest, Not: true}
+	}
+	return RuleAddress{Address: s}
+}
+
+// MarshalJSON encodes the address as a string.
+func (a RuleAddress) MarshalJSON() ([]byte, error) {
+	return json.Marshal(a.String())
+}
+
+// UnmarshalJSON decodes an address from a string.
+func (a *RuleAddress) UnmarshalJSON(data []byte) error {
+	var s *string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	if s == nil {
+		*a = RuleAddress{}
+		return nil
+	}
+	*a = ParseRuleAddress(*s)
+	return nil
+}
+
+// FirewallRule represents a single firewall rule. Its Id is its position in
+// the rule list, so it changes when rules before it are added or removed;
+// Tracker identifies a rule for good.
+type FirewallRule struct {
+	FirewallRuleRequest
+	Id int `json:"id"`
+}
+
+type firewallRuleListResponse struct {
+	apiResponse
+	Data flexList[*FirewallRule] `json:"data"`
+}
+
+// ListRules returns the firewall rules in the order they are evaluated.
+func (s FirewallService) ListRules(ctx context.Context) ([]*FirewallRule, error) {
+	response, err := s.client.get(ctx, firewallRulesEndpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := new(firewallRuleListResponse)
+	if err = s.client.decode(response, resp); err != nil {
+		return nil, fmt.Errorf("error unmarshalling response: %w", err)
+	}
+	return resp.Data, nil
+}
+
+// GetRule returns the firewall rule with the given ID.
+func (s FirewallService) GetRule(ctx context.Context, id int) (*FirewallRule, error) {
+	response, err := s.client.get(
+		ctx,
+		firewallRuleEndpoint,
+		map[string]string{
+			"id": strconv.Itoa(id),
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := new(firewallRuleResponse)
+	if err = s.client.decode(response, resp); err != nil {
+		return nil, fmt.Errorf("error unmarshalling response: %w", err)
+	}
+	return resp.Data, nil
+}
+
+// DeleteRule deletes a firewall rule.
+func (s FirewallService) DeleteRule(ctx context.Context, idToDelete int) (*FirewallRule, error) {
+	response, err := s.client.delete(
+		ctx,
+		firewallRuleEndpoint,
+		map[string]string{
+			"id": strconv.Itoa(idToDelete),
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := new(firewallRuleResponse)
+	if err = s.client.decode(response, resp); err != nil {
+		return nil, fmt.Errorf("error unmarshalling response: %w", err)
+	}
+	return resp.Data, nil
+}
+
+type FirewallRuleRequest struct {
+	Type            RuleType         `json:"type"`
+	Interface       []string         `json:"interface"`
+	Ipprotocol      IPProtocol       `json:"ipprotocol"`
+	Protocol        RuleProtocol     `json:"protocol,omitempty"`
+	Icmptype        []string         `json:"icmptype,omitempty"`
+	Source          RuleAddress      `json:"source"`
+	SourcePort      *optional.String `json:"source_port,omitempty"`
+	Destination     RuleAddress      `json:"destination"`
+	DestinationPort *optional.String `json:"destination_port,omitempty"`
+	Descr           *optional.String `json:"descr,omitempty"`
+	Disabled        bool             `json:"disabled"`
+	Log             bool             `json:"log"`
+	Statetype       *optional.String `json:"statetype,omitempty"`
+	Gateway         *optional.String `json:"gateway,omitempty"`
+	Sched           *optional.String `json:"sched,omitempty"`
+	Floating        bool             `json:"floating"`
+	Quick           bool             `json:"quick"`
+	Direction       RuleDirection    `json:"direction,omitempty"`
+	Tracker         *optional.Int    `json:"tracker,omitempty"`
+
+	// AssociatedRuleId links the rule to the NAT port forward with the same
+	// id; pfSense keeps such rules in step with their port forward.
+	AssociatedRuleId string `json:"associated_rule_id,omitempty"`
+
+	// Extra holds the fields this library does not model.
+	Extra map[string]json.RawMessage `json:"-"`
+}
+
+type firewallRuleResponse struct {
+	apiResponse
+	Data *FirewallRule `json:"data"`
+}
+
+// CreateRule creates a new firewall rule at the end of the rule list. The
+// change takes effect once Apply is called.
+func (s FirewallService) CreateRule(
+	ctx context.Context,
+	newRule FirewallRuleRequest,
+) (*FirewallRule, error) {
+	jsonData, err := json.Marshal(newRule)
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling request payload into json: %w", err)
+	}
+
+	response, err := s.client.post(ctx, firewallRuleEndpoint, nil, jsonData)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := new(firewallRuleResponse)
+	if err = s.client.decode(response, resp); err != nil {
+		return nil, fmt.Errorf("error unmarshalling response: %w", err)
+	}
+	return resp.Data, nil
+}
+
+// UpdateRule modifies an existing firewall rule.
+func (s FirewallService) UpdateRule(
+	ctx context.Context,
+	idToUpdate int,
+	ruleData FirewallRuleRequest,
+) (*FirewallRule, error) {
+	requestData := FirewallRule{
+		FirewallRuleRequest: ruleData,
+		Id:                  idToUpdate,
+	}
+
+	jsonData, err := json.Marshal(requestData)
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling request payload into json: %w", err)
+	}
+
+	response, err := s.client.patch(ctx, firewallRuleEndpoint, nil, jsonData)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := new(firewallRuleResponse)
+	if err = s.client.decode(response, resp); err != nil {
+		return nil, fmt.Errorf("error unmarshalling response: %w", err)
+	}
+	return resp.Data, nil
+}
+
+// Apply applies pending firewall changes, reloading the filter.
+func (s FirewallService) Apply(ctx context.Context) error {
+	response, err := s.client.post(ctx, firewallApplyEndpoint, nil, nil)
+	if err != nil {
+		return err
+	}
+
+	resp := new(apiResponse)
+	if err = s.client.decode(response, resp); err != nil {
+		return fmt.Errorf("error unmarshalling response: %w", err)
+	}
+
+	return nil
+}
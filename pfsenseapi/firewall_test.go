@@ -0,0 +1,186 @@
+package pfsenseapi
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/markphelps/optional"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFirewallService_ListRules(t *testing.T) {
+	data := mustReadFileString(t, "testdata/multiplefirewallrule.json")
+	server := setupTestServer(t, data)
+	defer server.Close()
+
+	newClient := NewClientWithNoAuth(server.URL)
+	response, err := newClient.Firewall.ListRules(context.Background())
+	require.NoError(t, err)
+	require.Len(t, response, 2)
+	require.Equal(t, RuleTypeBlock, response[0].Type)
+	require.Equal(t, ProtocolAny, response[0].Protocol)
+	require.Equal(t, []string{"lan", "opt1"}, response[1].Interface)
+	require.Equal(t, DirectionIn, response[1].Direction)
+	require.True(t, response[1].Floating)
+
+	response, err = newClient.Firewall.ListRules(context.Background())
+	require.Error(t, err)
+	require.Nil(t, response)
+
+	response, err = newClient.Firewall.ListRules(context.Background())
+	require.Error(t, err)
+	require.Nil(t, response)
+}
+
+func TestFirewallService_GetRule(t *testing.T) {
+	data := mustReadFileString(t, "testdata/singlefirewallrule.json")
+	server := setupTestServer(t, data)
+	defer server.Close()
+
+	newClient := NewClientWithNoAuth(server.URL)
+	response, err := newClient.Firewall.GetRule(context.Background(), 2)
+	require.NoError(t, err)
+	require.Equal(t, 2, response.Id)
+	require.Equal(t, RuleAddress{Address: "lan"}, response.Source)
+	require.Equal(t, RuleAddress{Address: "10.0.0.0/8", Not: true}, response.Destination)
+	require.Equal(t, "443", response.DestinationPort.MustGet())
+	require.Equal(t, 1700000002, response.Tracker.MustGet())
+	require.Contains(t, response.Extra, "created_by")
+
+	response, err = newClient.Firewall.GetRule(context.Background(), 2)
+	require.Error(t, err)
+	require.Nil(t, response)
+
+	response, err = newClient.Firewall.GetRule(context.Background(), 2)
+	require.Error(t, err)
+	require.Nil(t, response)
+}
+
+func TestFirewallService_DeleteRule(t *testing.T) {
+	data := mustReadFileString(t, "testdata/singlefirewallrule.json")
+	server := setupTestServer(t, data)
+	defer server.Close()
+
+	newClient := NewClientWithNoAuth(server.URL)
+	response, err := newClient.Firewall.DeleteRule(context.Background(), 2)
+	require.NoError(t, err)
+	require.NotNil(t, response)
+
+	response, err = newClient.Firewall.DeleteRule(context.Background(), 2)
+	require.Error(t, err)
+	require.Nil(t, response)
+
+	response, err = newClient.Firewall.DeleteRule(context.Background(), 2)
+	require.Error(t, err)
+	require.Nil(t, response)
+}
+
+func TestFirewallService_CreateRule(t *testing.T) {
+	data := mustReadFileString(t, "testdata/singlefirewallrule.json")
+	server := setupTestServer(t, data)
+	defer server.Close()
+
+	newClient := NewClientWithNoAuth(server.URL)
+	port := optional.NewString("443")
+	newRule := FirewallRuleRequest{
+		Type:            RuleTypePass,
+		Interface:       []string{"lan"},
+		Ipprotocol:      IPProtocolInet,
+		Protocol:        ProtocolTCP,
+		Source:          RuleAddress{Address: "lan"},
+		Destination:     RuleAddress{Address: "10.0.0.0/8", Not: true},
+		DestinationPort: &port,
+		Log:             true,
+	}
+	response, err := newClient.Firewall.CreateRule(context.Background(), newRule)
+	require.NoError(t, err)
+	require.NotNil(t, response)
+
+	response, err = newClient.Firewall.CreateRule(context.Background(), newRule)
+	require.Error(t, err)
+	require.Nil(t, response)
+
+	response, err = newClient.Firewall.CreateRule(context.Background(), newRule)
+	require.Error(t, err)
+	require.Nil(t, response)
+}
+
+func TestFirewallService_UpdateRule(t *testing.T) {
+	data := mustReadFileString(t, "testdata/singlefirewallrule.json")
+
+	var received map[string]any
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodPatch, r.Method)
+		require.Equal(t, "/"+firewallRuleEndpoint, r.URL.Path)
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		require.NoError(t, json.Unmarshal(body, &received))
+
+		w.Header().Set("Content-Type", "application/json")
+		_, err = io.WriteString(w, data)
+		require.NoError(t, err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(handler))
+	defer server.Close()
+
+	newClient := NewClientWithNoAuth(server.URL)
+	response, err := newClient.Firewall.UpdateRule(context.Background(), 2, FirewallRuleRequest{
+		Type:        RuleTypeReject,
+		Interface:   []string{"lan"},
+		Source:      AnyAddress,
+		Destination: RuleAddress{Address: "blocked_hosts", Not: true},
+	})
+	require.NoError(t, err)
+	require.NotNil(t, response)
+
+	require.Equal(t, float64(2), received["id"])
+	require.Equal(t, "reject", received["type"])
+	require.Equal(t, "any", received["source"])
+	require.Equal(t, "!blocked_hosts", received["destination"])
+	require.NotContains(t, received, "protocol")
+	require.NotContains(t, received, "destination_port")
+}
+
+func TestFirewallService_Apply(t *testing.T) {
+	server := setupTestServer(t, "{}")
+	defer server.Close()
+
+	newClient := NewClientWithNoAuth(server.URL)
+	err := newClient.Firewall.Apply(context.Background())
+	require.NoError(t, err)
+
+	err = newClient.Firewall.Apply(context.Background())
+	require.Error(t, err)
+
+	err = newClient.Firewall.Apply(context.Background())
+	require.Error(t, err)
+}
+
+func TestRuleAddress_JSON(t *testing.T) {
+	tests := []struct {
+		json    string
+		address RuleAddress
+	}{
+		{json: `"any"`, address: AnyAddress},
+		{json: `"lan:ip"`, address: RuleAddress{Address: "lan:ip"}},
+		{json: `"!192.168.1.0/24"`, address: RuleAddress{Address: "192.168.1.0/24", Not: true}},
+	}
+
+	for _, tt := range tests {
+		var address RuleAddress
+		require.NoError(t, json.Unmarshal([]byte(tt.json), &address))
+		require.Equal(t, tt.address, address)
+
+		data, err := json.Marshal(tt.address)
+		require.NoError(t, err)
+		require.JSONEq(t, tt.json, string(data))
+	}
+
+	address := RuleAddress{Address: "lan"}
+	require.NoError(t, json.Unmarshal([]byte(`null`), &address))
+	require.Equal(t, RuleAddress{}, address)
+}
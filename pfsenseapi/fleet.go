@@ -0,0 +1,264 @@
+package pfsenseapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const defaultFleetConcurrency = 10
+
+// ErrUnknownHost is returned for a host that was selected but never added to
+// the Fleet.
+var ErrUnknownHost = errors.New("unknown fleet host")
+
+// FleetConfig provides configuration for a Fleet.
+type FleetConfig struct {
+	// Concurrency is the maximum number of hosts operated on at once.
+	// Defaults to 10.
+	Concurrency int
+	// HostTimeout bounds the time spent on each host. Zero leaves only the
+	// timeouts of the individual Clients.
+	HostTimeout time.Duration
+}
+
+// Fleet holds many named Clients and runs operations against all, or a
+// selection, of them concurrently. A failure on one host never stops the
+// operation on the others.
+type Fleet struct {
+	cfg FleetConfig
+
+	mu      sync.RWMutex
+	clients map[string]*Client
+}
+
+// NewFleet constructs an empty Fleet.
+func NewFleet(config FleetConfig) *Fleet {
+	if config.Concurrency <= 0 {
+		config.Concurrency = defaultFleetConcurrency
+	}
+
+	return &Fleet{
+		cfg:     config,
+		clients: make(map[string]*Client),
+	}
+}
+
+// Add adds a client under the given host name, replacing any client already
+// added under that name.
+func (f *Fleet) Add(host string, client *Client) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.clients[host] = client
+}
+
+// Remove removes the client with the given host name.
+func (f *Fleet) Remove(host string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	delete(f.clients, host)
+}
+
+// Client returns the client with the given host name.
+func (f *Fleet) Client(host string) (*Client, bool) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	client, ok := f.clients[host]
+	return client, ok
+}
+
+// Hosts returns the sorted names of every host in the fleet.
+func (f *Fleet) Hosts() []string {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	hosts := make([]string, 0, len(f.clients))
+	for host := range f.clients {
+		hosts = append(hosts, host)
+	}
+	sort.Strings(hosts)
+	return hosts
+}
+
+// FleetErrors holds the error of every host an operation failed on, keyed by
+// host name.
+type FleetErrors map[string]error
+
+func (e FleetErrors) Error() string {
+	hosts := make([]string, 0, len(e))
+	for host := range e {
+		hosts = append(hosts, host)
+	}
+	sort.Strings(hosts)
+
+	msgs := make([]string, 0, len(hosts))
+	for _, host := range hosts {
+		msgs = append(msgs, fmt.Sprintf("%s: %v", host, e[host]))
+	}
+	return fmt.Sprintf("%d host(s) failed: %s", len(e), strings.Join(msgs, "; "))
+}
+
+// Unwrap returns the per host errors so errors.Is and errors.As look through
+// them.
+func (e FleetErrors) Unwrap() []error {
+	errs := make([]error, 0, len(e))
+	for _, err := range e {
+		errs = append(errs, err)
+	}
+	return errs
+}
+
+// Run calls fn for each of the given hosts, or for every host when none are
+// given. It returns a FleetErrors holding the hosts that failed, or nil if
+// all succeeded.
+func (f *Fleet) Run(
+	ctx context.Context,
+	fn func(ctx context.Context, host string, client *Client) error,
+	hosts ...string,
+) error {
+	_, err := RunFleet(ctx, f, func(ctx context.Context, host string, client *Client) (struct{}, error) {
+		return struct{}{}, fn(ctx, host, client)
+	}, hosts...)
+	return err
+}
+
+// RunFleet calls fn for each of the given hosts, or for every host when none
+// are given, and returns the results of the hosts that succeeded. The error
+// is a FleetErrors holding the hosts that failed, or nil if all succeeded.
+func RunFleet[T any](
+	ctx context.Context,
+	f *Fleet,
+	fn func(ctx context.Context, host string, client *Client) (T, error),
+	hosts ...string,
+) (map[string]T, error) {
+	if len(hosts) == 0 {
+		hosts = f.Hosts()
+	}
+
+	var mu sync.Mutex
+	results := make(map[string]T, len(hosts))
+	errs := make(FleetErrors)
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, f.cfg.Concurrency)
+
+	for _, host := range hosts {
+		client, ok := f.Client(host)
+		if !ok {
+			mu.Lock()
+			errs[host] = ErrUnknownHost
+			mu.Unlock()
+			continue
+		}
+
+		wg.Add(1)
+		go func(host string, client *Client) {
+			defer wg.Done()
+
+			result, err := runHost(ctx, f.cfg, sem, host, client, fn)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs[host] = err
+				return
+			}
+			results[host] = result
+		}(host, client)
+	}
+
+	wg.Wait()
+
+	if len(errs) > 0 {
+		return results, errs
+	}
+	return results, nil
+}
+
+// runHost waits for a free slot and runs fn against a single host, turning a
+// panic into an error so it cannot take down the rest of the run.
+func runHost[T any](
+	ctx context.Context,
+	cfg FleetConfig,
+	sem chan struct{},
+	host string,
+	client *Client,
+	fn func(ctx context.Context, host string, client *Client) (T, error),
+) (result T, err error) {
+	select {
+	case sem <- struct{}{}:
+		defer func() { <-sem }()
+	case <-ctx.Done():
+		return result, ctx.Err()
+	}
+
+	if cfg.HostTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cfg.HostTimeout)
+		defer cancel()
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+
+	return fn(ctx, host, client)
+}
+
+// ListInterfaces returns the interfaces of each host.
+func (f *Fleet) ListInterfaces(ctx context.Context, hosts ...string) (map[string][]*Interface, error) {
+	return RunFleet(ctx, f, func(ctx context.Context, _ string, client *Client) ([]*Interface, error) {
+		return client.Interface.ListInterfaces(ctx)
+	}, hosts...)
+}
+
+// ListVLANs returns the VLANs of each host.
+func (f *Fleet) ListVLANs(ctx context.Context, hosts ...string) (map[string][]*VLAN, error) {
+	return RunFleet(ctx, f, func(ctx context.Context, _ string, client *Client) ([]*VLAN, error) {
+		return client.Interface.ListVLANs(ctx)
+	}, hosts...)
+}
+
+// ListInterfaceGroups returns the interface groups of each host.
+func (f *Fleet) ListInterfaceGroups(ctx context.Context, hosts ...string) (map[string][]*InterfaceGroup, error) {
+	return RunFleet(ctx, f, func(ctx context.Context, _ string, client *Client) ([]*InterfaceGroup, error) {
+		return client.Interface.ListInterfaceGroups(ctx)
+	}, hosts...)
+}
+
+// ListInterfaceBridges returns the bridges of each host.
+func (f *Fleet) ListInterfaceBridges(ctx context.Context, hosts ...string) (map[string][]*InterfaceBridge, error) {
+	return RunFleet(ctx, f, func(ctx context.Context, _ string, client *Client) ([]*InterfaceBridge, error) {
+		return client.Interface.ListInterfaceBridges(ctx)
+	}, hosts...)
+}
+
+// ListUsers returns the users of each host.
+func (f *Fleet) ListUsers(ctx context.Context, hosts ...string) (map[string][]*User, error) {
+	return RunFleet(ctx, f, func(ctx context.Context, _ string, client *Client) ([]*User, error) {
+		return client.User.ListUsers(ctx)
+	}, hosts...)
+}
+
+// ListUserGroups returns the user groups of each host.
+func (f *Fleet) ListUserGroups(ctx context.Context, hosts ...string) (map[string][]*UserGroup, error) {
+	return RunFleet(ctx, f, func(ctx context.Context, _ string, client *Client) ([]*UserGroup, error) {
+		return client.User.ListUserGroups(ctx)
+	}, hosts...)
+}
+
+// ApplyInterfaces applies pending interface changes on each host.
+func (f *Fleet) ApplyInterfaces(ctx context.Context, hosts ...string) error {
+	return f.Run(ctx, func(ctx context.Context, _ string, client *Client) error {
+		return client.Interface.Apply(ctx)
+	}, hosts...)
+}
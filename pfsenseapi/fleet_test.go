@@ -0,0 +1,81 @@
+package pfsenseapi
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFleet_ListUsers(t *testing.T) {
+	good, _ := setupCountingServer(t, mustReadFileString(t, "testdata/multipleuser.json"))
+	defer good.Close()
+	bad, _ := setupCountingServer(t, mustReadFileString(t, "testdata/badjson.json"))
+	defer bad.Close()
+
+	fleet := NewFleet(FleetConfig{Concurrency: 2})
+	fleet.Add("fw1", NewClientWithNoAuth(good.URL))
+	fleet.Add("fw2", NewClientWithNoAuth(good.URL))
+	fleet.Add("fw3", NewClientWithNoAuth(bad.URL))
+	require.Equal(t, []string{"fw1", "fw2", "fw3"}, fleet.Hosts())
+
+	users, err := fleet.ListUsers(context.Background())
+	require.Error(t, err)
+	require.Len(t, users, 2)
+	require.Len(t, users["fw1"], 2)
+	require.Len(t, users["fw2"], 2)
+
+	var fleetErrs FleetErrors
+	require.True(t, errors.As(err, &fleetErrs))
+	require.Len(t, fleetErrs, 1)
+	require.Contains(t, fleetErrs, "fw3")
+
+	users, err = fleet.ListUsers(context.Background(), "fw1", "fw9")
+	require.ErrorIs(t, err, ErrUnknownHost)
+	require.Len(t, users, 1)
+
+	users, err = fleet.ListUsers(context.Background(), "fw2")
+	require.NoError(t, err)
+	require.Len(t, users, 1)
+}
+
+func TestFleet_Run(t *testing.T) {
+	fleet := NewFleet(FleetConfig{Concurrency: 2, HostTimeout: 10 * time.Millisecond})
+	for _, host := range []string{"fw1", "fw2", "fw3", "fw4", "fw5"} {
+		fleet.Add(host, NewClientWithNoAuth("http://"+host))
+	}
+	fleet.Remove("fw5")
+
+	var running, maxRunning int32
+	err := fleet.Run(context.Background(), func(ctx context.Context, host string, _ *Client) error {
+		n := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+		for {
+			m := atomic.LoadInt32(&maxRunning)
+			if n <= m || atomic.CompareAndSwapInt32(&maxRunning, m, n) {
+				break
+			}
+		}
+
+		switch host {
+		case "fw1":
+			<-ctx.Done()
+			return ctx.Err()
+		case "fw2":
+			panic("boom")
+		default:
+			return nil
+		}
+	})
+
+	require.LessOrEqual(t, maxRunning, int32(2))
+
+	var fleetErrs FleetErrors
+	require.True(t, errors.As(err, &fleetErrs))
+	require.Len(t, fleetErrs, 2)
+	require.ErrorIs(t, fleetErrs["fw1"], context.DeadlineExceeded)
+	require.ErrorContains(t, fleetErrs["fw2"], "panic: boom")
+}
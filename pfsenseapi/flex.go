@@ -0,0 +1,352 @@
+package pfsenseapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/markphelps/optional"
+)
+
+// pfSense is loose about the JSON types it returns: numbers come as strings
+// ("24"), flags as "yes", "on" or "", and empty lists and objects as [], {}
+// or "". The Flex types below are used for the model fields known to arrive
+// that way. They encode like the plain type they wrap and accept all of
+// these encodings when decoding.
+
+// FlexBool is a bool that also decodes from the strings and numbers pfSense
+// writes flags as. "true", "yes", "on", "enabled" and non-zero numbers are
+// true; "false", "no", "off", "disabled", "", 0 and [] are false.
+type FlexBool bool
+
+// FlexInt is an int that also decodes from a number in a string, such as
+// "24". An empty string decodes as 0.
+type FlexInt int
+
+// FlexInt32 is an int32 that decodes like FlexInt.
+type FlexInt32 int32
+
+// FlexStringList is a list of strings that also decodes from a single
+// string, and from "" or {} as an empty list.
+type FlexStringList []string
+
+// FlexOptionalBool is an optional.Bool that decodes like FlexBool.
+type FlexOptionalBool struct {
+	optional.Bool
+}
+
+// FlexOptionalInt32 is an optional.Int32 that decodes like FlexInt32.
+type FlexOptionalInt32 struct {
+	optional.Int32
+}
+
+// NewFlexOptionalBool creates a FlexOptionalBool holding v.
+func NewFlexOptionalBool(v bool) FlexOptionalBool {
+	return FlexOptionalBool{optional.NewBool(v)}
+}
+
+// NewFlexOptionalInt32 creates a FlexOptionalInt32 holding v.
+func NewFlexOptionalInt32(v int32) FlexOptionalInt32 {
+	return FlexOptionalInt32{optional.NewInt32(v)}
+}
+
+// UnmarshalJSON decodes a flag in any of the encodings pfSense uses.
+func (b *FlexBool) UnmarshalJSON(data []byte) error {
+	if isNull(data) {
+		return nil
+	}
+	v, err := parseFlexBool(data)
+	if err != nil {
+		return err
+	}
+	*b = FlexBool(v)
+	return nil
+}
+
+// UnmarshalJSON decodes a number or a number in a string.
+func (i *FlexInt) UnmarshalJSON(data []byte) error {
+	if isNull(data) {
+		return nil
+	}
+	v, err := parseFlexInt(data, strconv.IntSize, reflect.TypeOf(0))
+	if err != nil {
+		return err
+	}
+	*i = FlexInt(v)
+	return nil
+}
+
+// UnmarshalJSON decodes a number or a number in a string.
+func (i *FlexInt32) UnmarshalJSON(data []byte) error {
+	if isNull(data) {
+		return nil
+	}
+	v, err := parseFlexInt(data, 32, reflect.TypeOf(int32(0)))
+	if err != nil {
+		return err
+	}
+	*i = FlexInt32(v)
+	return nil
+}
+
+// UnmarshalJSON decodes a list, a single string or an empty value.
+func (l *FlexStringList) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	switch {
+	case isNull(data):
+		*l = nil
+		return nil
+	case len(data) > 0 && data[0] == '[':
+		return json.Unmarshal(data, (*[]string)(l))
+	case isEmptyObject(data):
+		*l = FlexStringList{}
+		return nil
+	}
+
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return &json.UnmarshalTypeError{Value: jsonKind(data), Type: reflect.TypeOf([]string(nil))}
+	}
+	if s == "" {
+		*l = FlexStringList{}
+	} else {
+		*l = FlexStringList{s}
+	}
+	return nil
+}
+
+// UnmarshalJSON decodes a flag in any of the encodings FlexBool accepts.
+func (b *FlexOptionalBool) UnmarshalJSON(data []byte) error {
+	if isNull(data) {
+		b.Bool = optional.Bool{}
+		return nil
+	}
+	v, err := parseFlexBool(data)
+	if err != nil {
+		return err
+	}
+	b.Bool = optional.NewBool(v)
+	return nil
+}
+
+// UnmarshalJSON decodes a number or a number in a string.
+func (i *FlexOptionalInt32) UnmarshalJSON(data []byte) error {
+	if isNull(data) {
+		i.Int32 = optional.Int32{}
+		return nil
+	}
+	v, err := parseFlexInt(data, 32, reflect.TypeOf(int32(0)))
+	if err != nil {
+		return err
+	}
+	i.Int32 = optional.NewInt32(int32(v))
+	return nil
+}
+
+func parseFlexBool(data []byte) (bool, error) {
+	var value any
+	if err := json.Unmarshal(data, &value); err != nil {
+		return false, err
+	}
+
+	switch v := value.(type) {
+	case bool:
+		return v, nil
+	case float64:
+		return v != 0, nil
+	case string:
+		switch strings.ToLower(strings.TrimSpace(v)) {
+		case "true", "yes", "on", "1", "enabled":
+			return true, nil
+		case "false", "no", "off", "0", "disabled", "":
+			return false, nil
+		}
+	case []any:
+		// PHP encodes an unset flag as an empty array
+		if len(v) == 0 {
+			return false, nil
+		}
+	}
+	return false, &json.UnmarshalTypeError{Value: jsonKind(data), Type: reflect.TypeOf(false)}
+}
+
+func parseFlexInt(data []byte, bits int, t reflect.Type) (int64, error) {
+	var value any
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&value); err != nil {
+		return 0, err
+	}
+
+	var s string
+	switch v := value.(type) {
+	case json.Number:
+		s = v.String()
+	case string:
+		s = strings.TrimSpace(v)
+		if s == "" {
+			return 0, nil
+		}
+	default:
+		return 0, &json.UnmarshalTypeError{Value: jsonKind(data), Type: t}
+	}
+
+	if n, err := strconv.ParseInt(s, 10, bits); err == nil {
+		return n, nil
+	}
+	// numbers written with a zero fraction, such as "64.0"
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil || f != math.Trunc(f) || f < math.MinInt64 || f > math.MaxInt64 {
+		return 0, &json.UnmarshalTypeError{Value: "number " + s, Type: t}
+	}
+	n := int64(f)
+	if reflect.Zero(t).OverflowInt(n) {
+		return 0, &json.UnmarshalTypeError{Value: "number " + s, Type: t}
+	}
+	return n, nil
+}
+
+// flexList is a list that also decodes from {} or "" as an empty list, and
+// from a single object as a list holding that object, the ways pfSense
+// sends lists with fewer than two items.
+type flexList[T any] []T
+
+func (l *flexList[T]) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	switch {
+	case isNull(data):
+		*l = nil
+		return nil
+	case len(data) > 0 && data[0] == '[':
+		return json.Unmarshal(data, (*[]T)(l))
+	case isEmptyObject(data), string(data) == `""`:
+		*l = flexList[T]{}
+		return nil
+	case len(data) > 0 && data[0] == '{':
+		var item T
+		if err := json.Unmarshal(data, &item); err != nil {
+			return err
+		}
+		*l = flexList[T]{item}
+		return nil
+	}
+	return &json.UnmarshalTypeError{Value: jsonKind(data), Type: reflect.TypeOf([]T(nil))}
+}
+
+func isNull(data []byte) bool {
+	return string(bytes.TrimSpace(data)) == "null"
+}
+
+// isEmptyArray reports whether data is [], which PHP also uses for an empty
+// object.
+func isEmptyArray(data []byte) bool {
+	var items []json.RawMessage
+	data = bytes.TrimSpace(data)
+	return len(data) > 0 && data[0] == '[' && json.Unmarshal(data, &items) == nil && len(items) == 0
+}
+
+func isEmptyObject(data []byte) bool {
+	var members map[string]json.RawMessage
+	data = bytes.TrimSpace(data)
+	return len(data) > 0 && data[0] == '{' && json.Unmarshal(data, &members) == nil && len(members) == 0
+}
+
+// jsonKind names the kind of the JSON value in data for error messages.
+func jsonKind(data []byte) string {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return "empty value"
+	}
+	switch data[0] {
+	case '{':
+		return "object"
+	case '[':
+		return "array"
+	case '"':
+		return "string"
+	case 't', 'f':
+		return "bool"
+	case 'n':
+		return "null"
+	}
+	return "number " + string(data)
+}
+
+// decode unmarshals a response body into v. Unless Config.StrictDecoding is
+// set, the loose encodings the Flex types accept are taken as they are. In
+// strict mode they are rejected, by checking that every value in data has
+// the JSON type v encodes it back as.
+func (c *Client) decode(data []byte, v any) error {
+	if err := json.Unmarshal(data, v); err != nil {
+		return err
+	}
+	if !c.Cfg.StrictDecoding {
+		return nil
+	}
+
+	encoded, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	var in, out any
+	if err = json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+	if err = json.Unmarshal(encoded, &out); err != nil {
+		return err
+	}
+	return sameShape("", in, out)
+}
+
+// sameShape returns an error naming the first value in in whose JSON type
+// differs from the value at the same place in out. Values missing or null
+// on either side are not compared.
+func sameShape(path string, in, out any) error {
+	if in == nil || out == nil {
+		return nil
+	}
+
+	switch in := in.(type) {
+	case map[string]any:
+		if out, ok := out.(map[string]any); ok {
+			for key, value := range in {
+				if err := sameShape(path+"."+key, value, out[key]); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	case []any:
+		if out, ok := out.([]any); ok {
+			for i := 0; i < len(in) && i < len(out); i++ {
+				if err := sameShape(fmt.Sprintf("%s[%d]", path, i), in[i], out[i]); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	default:
+		if reflect.TypeOf(in) == reflect.TypeOf(out) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s: got %s, want %s", strings.TrimPrefix(path, "."), shapeName(in), shapeName(out))
+}
+
+func shapeName(v any) string {
+	switch v.(type) {
+	case map[string]any:
+		return "object"
+	case []any:
+		return "array"
+	case string:
+		return "string"
+	case bool:
+		return "bool"
+	}
+	return "number"
+}
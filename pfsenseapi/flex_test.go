@@ -0,0 +1,170 @@
+package pfsenseapi
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_decodeFlexible(t *testing.T) {
+	data := mustReadFileString(t, "testdata/multipleinterfacelenient.json")
+	server := setupTestServer(t, data)
+	defer server.Close()
+
+	newClient := NewClientWithNoAuth(server.URL)
+	response, err := newClient.Interface.ListInterfaces(context.Background())
+	require.NoError(t, err)
+	require.Len(t, response, 2)
+	// PHP writes false as an empty string
+	require.False(t, response[0].Enable.MustGet())
+	require.Equal(t, int32(1500), response[0].Mtu.MustGet())
+	require.True(t, response[0].Blockpriv.MustGet())
+	require.False(t, response[0].Blockbogons.MustGet())
+	require.Equal(t, FlexInt32(0), response[0].Subnet)
+	require.Equal(t, FlexInt32(64), response[0].Subnetv6)
+	require.Equal(t, FlexInt32(24), response[1].Subnet)
+
+	response, err = newClient.Interface.ListInterfaces(context.Background())
+	require.Error(t, err)
+	require.Nil(t, response)
+
+	response, err = newClient.Interface.ListInterfaces(context.Background())
+	require.Error(t, err)
+	require.Nil(t, response)
+}
+
+func TestClient_decodeStrict(t *testing.T) {
+	data := mustReadFileString(t, "testdata/multipleinterfacelenient.json")
+	server := setupTestServer(t, data)
+	defer server.Close()
+
+	newClient := NewClient(Config{Host: server.URL, StrictDecoding: true})
+	response, err := newClient.Interface.ListInterfaces(context.Background())
+	require.ErrorContains(t, err, "error unmarshalling response")
+	require.Nil(t, response)
+
+	// fixtures that match the API exactly still decode
+	data = mustReadFileString(t, "testdata/multipleinterface.json")
+	strictServer := setupTestServer(t, data)
+	defer strictServer.Close()
+
+	newClient = NewClient(Config{Host: strictServer.URL, StrictDecoding: true})
+	response, err = newClient.Interface.ListInterfaces(context.Background())
+	require.NoError(t, err)
+	require.Len(t, response, 2)
+}
+
+func TestClient_decodeFlexibleUser(t *testing.T) {
+	data := mustReadFileString(t, "testdata/singleuserlenient.json")
+	server := setupTestServer(t, data)
+	defer server.Close()
+
+	newClient := NewClientWithNoAuth(server.URL)
+	response, err := newClient.User.GetUser(context.Background(), 1)
+	require.NoError(t, err)
+	require.Equal(t, 1, response.Id)
+	require.Equal(t, FlexInt(1001), response.UID)
+	require.True(t, bool(response.Disabled))
+	require.Equal(t, FlexStringList{"page-all"}, response.Priv)
+	require.Empty(t, response.Cert)
+	require.Equal(t, "", response.Expires.OrElse("unset"))
+}
+
+func TestClient_decodeEmptyObject(t *testing.T) {
+	data := mustReadFileString(t, "testdata/emptyobject.json")
+	server := setupTestServer(t, data)
+	defer server.Close()
+
+	newClient := NewClientWithNoAuth(server.URL)
+	response, err := newClient.Status.GetCARPStatus(context.Background())
+	require.NoError(t, err)
+	require.NotNil(t, response)
+	require.Empty(t, response.VIPs)
+}
+
+func TestFlexTypes(t *testing.T) {
+	var out struct {
+		Count FlexInt            `json:"count"`
+		Bits  FlexInt32          `json:"bits"`
+		Flag  FlexBool           `json:"flag"`
+		Opt   *FlexOptionalInt32 `json:"opt"`
+		On    *FlexOptionalBool  `json:"on"`
+		Tags  FlexStringList     `json:"tags"`
+		Names FlexStringList     `json:"names"`
+	}
+
+	err := json.Unmarshal([]byte(`{
+		"count": "7",
+		"bits": "24.0",
+		"flag": "on",
+		"opt": "12",
+		"on": null,
+		"tags": "",
+		"names": "one"
+	}`), &out)
+	require.NoError(t, err)
+	require.Equal(t, FlexInt(7), out.Count)
+	require.Equal(t, FlexInt32(24), out.Bits)
+	require.True(t, bool(out.Flag))
+	require.Equal(t, int32(12), out.Opt.MustGet())
+	require.Nil(t, out.On)
+	require.Empty(t, out.Tags)
+	require.Equal(t, FlexStringList{"one"}, out.Names)
+
+	// the loose encodings of false
+	for _, flag := range []string{`""`, `"no"`, `"off"`, `0`, `[]`, `false`} {
+		out.Flag = true
+		require.NoError(t, json.Unmarshal([]byte(`{"flag": `+flag+`}`), &out), flag)
+		require.False(t, bool(out.Flag), flag)
+	}
+
+	// values that cannot be read still fail
+	require.Error(t, json.Unmarshal([]byte(`{"count": "seven"}`), &out))
+	require.Error(t, json.Unmarshal([]byte(`{"count": 1.5}`), &out))
+	require.Error(t, json.Unmarshal([]byte(`{"bits": 4294967296}`), &out))
+	require.Error(t, json.Unmarshal([]byte(`{"flag": "maybe"}`), &out))
+	require.Error(t, json.Unmarshal([]byte(`{"tags": 3}`), &out))
+
+	// they encode as the plain types
+	encoded, err := json.Marshal(out)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"count": 7, "bits": 24, "flag": false, "opt": 12, "on": null, "tags": [], "names": ["one"]}`, string(encoded))
+}
+
+func TestFlexList(t *testing.T) {
+	for body, want := range map[string]int{
+		`[{"name": "a"}, {"name": "b"}]`: 2,
+		`{"name": "a"}`:                  1,
+		`{}`:                             0,
+		`""`:                             0,
+		`null`:                           0,
+	} {
+		var items flexList[*User]
+		require.NoError(t, json.Unmarshal([]byte(body), &items), body)
+		require.Len(t, items, want, body)
+	}
+
+	var items flexList[*User]
+	require.Error(t, json.Unmarshal([]byte(`"users"`), &items))
+}
+
+func TestFlexModel(t *testing.T) {
+	var alias Alias
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"id": 3,
+		"name": "web",
+		"type": "host",
+		"address": "192.0.2.1",
+		"detail": "",
+		"updated_by": "admin"
+	}`), &alias))
+	require.Equal(t, []AliasEntry{{Address: "192.0.2.1"}}, alias.Entries)
+	require.Equal(t, map[string]json.RawMessage{"updated_by": json.RawMessage(`"admin"`)}, alias.Extra)
+
+	// PHP encodes an empty object as []
+	var vlan VLAN
+	require.NoError(t, json.Unmarshal([]byte(`[]`), &vlan))
+	require.Equal(t, VLAN{}, vlan)
+}
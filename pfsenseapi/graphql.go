@@ -0,0 +1,137 @@
+package pfsenseapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+const (
+	graphQLEndpoint = "api/v2/graphql"
+)
+
+// GraphQLService provides access to the GraphQL endpoint of the REST API,
+// which can fetch several related resources in a single round trip.
+type GraphQLService service
+
+// GraphQLLocation is a position in a GraphQL document.
+type GraphQLLocation struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// GraphQLError is a single error reported by the GraphQL endpoint.
+type GraphQLError struct {
+	Message    string            `json:"message"`
+	Locations  []GraphQLLocation `json:"locations,omitempty"`
+	Path       []any             `json:"path,omitempty"`
+	Extensions map[string]any    `json:"extensions,omitempty"`
+}
+
+func (e *GraphQLError) Error() string {
+	if len(e.Path) == 0 {
+		return e.Message
+	}
+
+	path := make([]string, 0, len(e.Path))
+	for _, p := range e.Path {
+		path = append(path, fmt.Sprint(p))
+	}
+	return fmt.Sprintf("%s: %s", strings.Join(path, "."), e.Message)
+}
+
+// GraphQLErrors holds the errors of a GraphQL response. The data that was
+// resolved despite the errors is still decoded.
+type GraphQLErrors []*GraphQLError
+
+func (e GraphQLErrors) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, err := range e {
+		msgs = append(msgs, err.Error())
+	}
+	return "graphql: " + strings.Join(msgs, "; ")
+}
+
+// Unwrap returns the individual errors so errors.As can find them.
+func (e GraphQLErrors) Unwrap() []error {
+	errs := make([]error, 0, len(e))
+	for _, err := range e {
+		errs = append(errs, err)
+	}
+	return errs
+}
+
+type graphQLRequest struct {
+	Query     string         `json:"query"`
+	Variables map[string]any `json:"variables,omitempty"`
+}
+
+type graphQLResponse struct {
+	Data   json.RawMessage `json:"data"`
+	Errors GraphQLErrors   `json:"errors"`
+}
+
+// Query runs a GraphQL query and decodes its data into out, which must be a
+// pointer to a struct or map shaped like the query. out may be nil to discard
+// the data. Queries are sent even in dry-run mode.
+func (s GraphQLService) Query(ctx context.Context, query string, variables map[string]any, out any) error {
+	return s.execute(readOnly(ctx), query, variables, out)
+}
+
+// Mutate runs a GraphQL mutation and decodes its data into out. Since a
+// mutation may change any resource, the response cache is flushed.
+func (s GraphQLService) Mutate(ctx context.Context, mutation string, variables map[string]any, out any) error {
+	defer s.client.FlushCache()
+	return s.execute(ctx, mutation, variables, out)
+}
+
+func (s GraphQLService) execute(ctx context.Context, query string, variables map[string]any, out any) error {
+	jsonData, err := json.Marshal(graphQLRequest{Query: query, Variables: variables})
+	if err != nil {
+		return fmt.Errorf("error marshalling request payload into json: %w", err)
+	}
+
+	res, err := s.client.do(ctx, http.MethodPost, graphQLEndpoint, nil, jsonData)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_, _ = io.Copy(io.Discard, res.Body)
+		_ = res.Body.Close()
+	}()
+
+	respbody, err := io.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+
+	// errors in the query itself come back with a 4xx status, but still in
+	// the GraphQL response format
+	resp := new(graphQLResponse)
+	jsonerr := json.Unmarshal(respbody, resp)
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		if jsonerr == nil && len(resp.Errors) > 0 {
+			return fmt.Errorf("%w: %w", statusCodeError(res.StatusCode), resp.Errors)
+		}
+		return statusError(res.StatusCode, respbody)
+	}
+
+	if jsonerr != nil {
+		return fmt.Errorf("error unmarshalling response: %w", jsonerr)
+	}
+
+	if out != nil && len(resp.Data) > 0 && string(resp.Data) != "null" {
+		if err = s.client.decode(resp.Data, out); err != nil {
+			return fmt.Errorf("error unmarshalling response: %w", err)
+		}
+	}
+
+	if len(resp.Errors) > 0 {
+		return resp.Errors
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package pfsenseapi
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type graphQLTestData struct {
+	Interfaces []struct {
+		Id    string `json:"id"`
+		If    string `json:"if"`
+		Descr string `json:"descr"`
+	} `json:"interfaces"`
+	VLANs []struct {
+		If  string `json:"if"`
+		Tag int    `json:"tag"`
+	} `json:"vlans"`
+}
+
+func TestGraphQLService_Query(t *testing.T) {
+	data := mustReadFileString(t, "testdata/graphql.json")
+	server := setupTestServer(t, data)
+	defer server.Close()
+
+	newClient := NewClientWithNoAuth(server.URL)
+	out := new(graphQLTestData)
+	err := newClient.GraphQL.Query(context.Background(), "{ interfaces { id if descr } vlans { if tag } }", nil, out)
+	require.NoError(t, err)
+	require.Len(t, out.Interfaces, 2)
+	require.Equal(t, "lan", out.Interfaces[1].Id)
+	require.Equal(t, 10, out.VLANs[0].Tag)
+
+	err = newClient.GraphQL.Query(context.Background(), "{ interfaces { id } }", nil, new(graphQLTestData))
+	require.ErrorIs(t, err, ErrBadRequest)
+
+	err = newClient.GraphQL.Query(context.Background(), "{ interfaces { id } }", nil, new(graphQLTestData))
+	require.Error(t, err)
+}
+
+func TestGraphQLService_Errors(t *testing.T) {
+	data := mustReadFileString(t, "testdata/graphqlerrors.json")
+	server := setupTestServer(t, data)
+	defer server.Close()
+
+	newClient := NewClientWithNoAuth(server.URL)
+	out := new(graphQLTestData)
+	err := newClient.GraphQL.Query(context.Background(), "{ interfaces { id } users { name } }", nil, out)
+	require.Error(t, err)
+	require.Len(t, out.Interfaces, 1)
+
+	var gqlErr *GraphQLError
+	require.ErrorAs(t, err, &gqlErr)
+	require.Equal(t, "Insufficient privileges to read users", gqlErr.Message)
+	require.Equal(t, "FORBIDDEN", gqlErr.Extensions["code"])
+	require.Equal(t, 3, gqlErr.Locations[0].Line)
+	require.Equal(t, "graphql: users: Insufficient privileges to read users", err.Error())
+}
+
+func TestGraphQLService_Mutate(t *testing.T) {
+	var received graphQLRequest
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodPost, r.Method)
+		require.Equal(t, "/"+graphQLEndpoint, r.URL.Path)
+		require.Equal(t, "admin token", r.Header.Get("Authorization"))
+
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		require.NoError(t, json.Unmarshal(body, &received))
+
+		w.Header().Set("Content-Type", "application/json")
+		_, err = io.WriteString(w, `{"data": {"createVLAN": {"id": 2, "if": "em1", "tag": 30}}}`)
+		require.NoError(t, err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(handler))
+	defer server.Close()
+
+	newClient := NewClientWithTokenAuth(server.URL, "admin", "token")
+	var out struct {
+		CreateVLAN VLAN `json:"createVLAN"`
+	}
+	err := newClient.GraphQL.Mutate(
+		context.Background(),
+		"mutation($tag: Int!) { createVLAN(if: \"em1\", tag: $tag) { id if tag } }",
+		map[string]any{"tag": 30},
+		&out,
+	)
+	require.NoError(t, err)
+	require.Equal(t, 2, out.CreateVLAN.Id)
+	require.Equal(t, float64(30), received.Variables["tag"])
+}
+
+func TestGraphQLService_QueryKeepsCache(t *testing.T) {
+	data := mustReadFileString(t, "testdata/graphql.json")
+	server, _ := setupCountingServer(t, data)
+	defer server.Close()
+
+	newClient := NewClient(Config{Host: server.URL, CacheEnabled: true})
+	generation := newClient.cache.generation
+
+	// a query is a POST but changes nothing
+	err := newClient.GraphQL.Query(context.Background(), "{ interfaces { id } }", nil, nil)
+	require.NoError(t, err)
+	require.Equal(t, generation, newClient.cache.generation)
+
+	err = newClient.GraphQL.Mutate(context.Background(), "mutation { applyInterfaces }", nil, nil)
+	require.NoError(t, err)
+	require.Greater(t, newClient.cache.generation, generation)
+}
@@ -0,0 +1,149 @@
+package pfsenseapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"sync"
+)
+
+var (
+	// ErrNoCARPPrimary is returned when neither node of an HA pair is CARP
+	// master.
+	ErrNoCARPPrimary = errors.New("no CARP primary found")
+
+	// ErrCARPSplitBrain is returned when both nodes of an HA pair claim to be
+	// CARP master.
+	ErrCARPSplitBrain = errors.New("both nodes report CARP master")
+)
+
+// HAClient provides access to a pair of firewalls running as a CARP HA pair.
+// Writes go to the node that is currently CARP master, so that XMLRPC sync
+// carries them to the other node. Reads go to the last known primary and
+// fail over to the other node when it cannot be reached.
+type HAClient struct {
+	nodes [2]*Client
+
+	mu      sync.Mutex
+	primary int
+}
+
+// NewHAClient constructs a new HAClient for the two nodes of an HA pair. The
+// first node is assumed to be primary until the CARP status says otherwise.
+func NewHAClient(first, second Config) *HAClient {
+	return &HAClient{
+		nodes: [2]*Client{NewClient(first), NewClient(second)},
+	}
+}
+
+// Nodes returns the clients of both nodes in the order they were given.
+func (h *HAClient) Nodes() (*Client, *Client) {
+	return h.nodes[0], h.nodes[1]
+}
+
+// Primary queries the CARP status of both nodes and returns the client of the
+// node that is currently master. A node that cannot be reached is treated as
+// not being master.
+func (h *HAClient) Primary(ctx context.Context) (*Client, error) {
+	type result struct {
+		status *CARPStatus
+		err    error
+	}
+
+	var results [2]result
+	var wg sync.WaitGroup
+	for i, node := range h.nodes {
+		wg.Add(1)
+		go func(i int, node *Client) {
+			defer wg.Done()
+			status, err := node.Status.GetCARPStatus(WithoutCache(ctx))
+			results[i] = result{status: status, err: err}
+		}(i, node)
+	}
+	wg.Wait()
+
+	var primaries []int
+	for i, res := range results {
+		if res.err == nil && res.status != nil && res.status.IsPrimary() {
+			primaries = append(primaries, i)
+		}
+	}
+
+	switch len(primaries) {
+	case 1:
+		h.mu.Lock()
+		h.primary = primaries[0]
+		h.mu.Unlock()
+		return h.nodes[primaries[0]], nil
+	case 2:
+		return nil, ErrCARPSplitBrain
+	default:
+		if err := errors.Join(results[0].err, results[1].err); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrNoCARPPrimary, err)
+		}
+		return nil, ErrNoCARPPrimary
+	}
+}
+
+// Write detects the current CARP primary and calls fn with its client. It
+// returns the host of the node that served the call.
+func (h *HAClient) Write(ctx context.Context, fn func(ctx context.Context, client *Client) error) (string, error) {
+	_, host, err := HAWrite(ctx, h, func(ctx context.Context, client *Client) (struct{}, error) {
+		return struct{}{}, fn(ctx, client)
+	})
+	return host, err
+}
+
+// Read calls fn with the client of the last known primary, and again with the
+// other node's client if the primary cannot be reached. It returns the host
+// of the node that served the call.
+func (h *HAClient) Read(ctx context.Context, fn func(ctx context.Context, client *Client) error) (string, error) {
+	_, host, err := HARead(ctx, h, func(ctx context.Context, client *Client) (struct{}, error) {
+		return struct{}{}, fn(ctx, client)
+	})
+	return host, err
+}
+
+// HAWrite is Write for operations that return a result.
+func HAWrite[T any](ctx context.Context, h *HAClient, fn func(ctx context.Context, client *Client) (T, error)) (T, string, error) {
+	var zero T
+
+	primary, err := h.Primary(ctx)
+	if err != nil {
+		return zero, "", err
+	}
+
+	result, err := fn(ctx, primary)
+	return result, primary.Cfg.Host, err
+}
+
+// HARead is Read for operations that return a result.
+func HARead[T any](ctx context.Context, h *HAClient, fn func(ctx context.Context, client *Client) (T, error)) (T, string, error) {
+	h.mu.Lock()
+	primary := h.primary
+	h.mu.Unlock()
+
+	node := h.nodes[primary]
+	result, err := fn(ctx, node)
+	if err == nil || !isUnreachable(ctx, err) {
+		return result, node.Cfg.Host, err
+	}
+
+	node = h.nodes[1-primary]
+	result, err = fn(ctx, node)
+	return result, node.Cfg.Host, err
+}
+
+// isUnreachable returns true if err means the firewall could not be reached,
+// as opposed to the firewall answering with an error.
+func isUnreachable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	var urlErr *url.Error
+	var netErr net.Error
+	return errors.As(err, &urlErr) || errors.As(err, &netErr)
+}
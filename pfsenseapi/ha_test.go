@@ -0,0 +1,118 @@
+package pfsenseapi
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// setupHANodeServer returns a server that answers the CARP status endpoint
+// with carpFile and every other endpoint with the VLAN list.
+func setupHANodeServer(t *testing.T, carpFile string) *httptest.Server {
+	carp := mustReadFileString(t, carpFile)
+	vlans := mustReadFileString(t, "testdata/multiplevlan.json")
+
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		response := vlans
+		if r.URL.Path == "/"+statusCARPEndpoint {
+			response = carp
+		}
+		_, err := io.WriteString(w, response)
+		require.NoError(t, err)
+	}
+
+	return httptest.NewServer(http.HandlerFunc(handler))
+}
+
+func TestHAClient_Write(t *testing.T) {
+	backup := setupHANodeServer(t, "testdata/carpstatusbackup.json")
+	defer backup.Close()
+	master := setupHANodeServer(t, "testdata/carpstatusmaster.json")
+	defer master.Close()
+
+	ha := NewHAClient(
+		Config{Host: backup.URL, Timeout: defaultTimeout},
+		Config{Host: master.URL, Timeout: defaultTimeout},
+	)
+
+	host, err := ha.Write(context.Background(), func(ctx context.Context, client *Client) error {
+		_, err := client.Interface.ListVLANs(ctx)
+		return err
+	})
+	require.NoError(t, err)
+	require.Equal(t, master.URL, host)
+
+	// reads now go to the detected primary
+	vlans, host, err := HARead(context.Background(), ha, func(ctx context.Context, client *Client) ([]*VLAN, error) {
+		return client.Interface.ListVLANs(ctx)
+	})
+	require.NoError(t, err)
+	require.Equal(t, master.URL, host)
+	require.Len(t, vlans, 2)
+}
+
+func TestHAClient_WriteNoPrimary(t *testing.T) {
+	first := setupHANodeServer(t, "testdata/carpstatusbackup.json")
+	defer first.Close()
+	second := setupHANodeServer(t, "testdata/carpstatusbackup.json")
+	defer second.Close()
+
+	ha := NewHAClient(
+		Config{Host: first.URL, Timeout: defaultTimeout},
+		Config{Host: second.URL, Timeout: defaultTimeout},
+	)
+	_, err := ha.Write(context.Background(), func(ctx context.Context, client *Client) error {
+		return nil
+	})
+	require.ErrorIs(t, err, ErrNoCARPPrimary)
+
+	second.Close()
+	second = setupHANodeServer(t, "testdata/carpstatusmaster.json")
+	defer second.Close()
+	first.Close()
+	first = setupHANodeServer(t, "testdata/carpstatusmaster.json")
+	defer first.Close()
+
+	ha = NewHAClient(
+		Config{Host: first.URL, Timeout: defaultTimeout},
+		Config{Host: second.URL, Timeout: defaultTimeout},
+	)
+	_, err = ha.Write(context.Background(), func(ctx context.Context, client *Client) error {
+		return nil
+	})
+	require.ErrorIs(t, err, ErrCARPSplitBrain)
+}
+
+func TestHAClient_ReadFailover(t *testing.T) {
+	down := setupHANodeServer(t, "testdata/carpstatusmaster.json")
+	down.Close()
+	up := setupHANodeServer(t, "testdata/carpstatusbackup.json")
+	defer up.Close()
+
+	ha := NewHAClient(
+		Config{Host: down.URL, Timeout: defaultTimeout},
+		Config{Host: up.URL, Timeout: defaultTimeout},
+	)
+
+	host, err := ha.Read(context.Background(), func(ctx context.Context, client *Client) error {
+		_, err := client.Interface.ListVLANs(ctx)
+		return err
+	})
+	require.NoError(t, err)
+	require.Equal(t, up.URL, host)
+
+	// errors returned by a reachable firewall do not fail over
+	host, err = ha.Read(context.Background(), func(ctx context.Context, client *Client) error {
+		if client.Cfg.Host == up.URL {
+			return nil
+		}
+		return ErrNotFound
+	})
+	require.ErrorIs(t, err, ErrNotFound)
+	require.Equal(t, down.URL, host)
+}
@@ -31,7 +31,7 @@ type Interface struct {
 
 type interfaceListResponse struct {
 	apiResponse
-	Data []*Interface `json:"data"`
+	Data flexList[*Interface] `json:"data"`
 }
 
 // GetInterface returns a single interface.
@@ -48,7 +48,7 @@ func (s InterfaceService) GetInterface(ctx context.Context, interfaceID string)
 	}
 
 	resp := new(createInterfaceResponse)
-	if err = json.Unmarshal(response, resp); err != nil {
+	if err = s.client.decode(response, resp); err != nil {
 		return nil, fmt.Errorf("error unmarshalling response: %w", err)
 	}
 	return resp.Data, nil
@@ -62,7 +62,7 @@ func (s InterfaceService) ListInterfaces(ctx context.Context) ([]*Interface, err
 	}
 
 	resp := new(interfaceListResponse)
-	if err = json.Unmarshal(response, resp); err != nil {
+	if err = s.client.decode(response, resp); err != nil {
 		return nil, fmt.Errorf("error unmarshalling response: %w", err)
 	}
 
@@ -85,48 +85,94 @@ func (s InterfaceService) DeleteInterface(ctx context.Context, interfaceID strin
 	}
 
 	resp := new(createInterfaceResponse)
-	if err = json.Unmarshal(response, resp); err != nil {
+	if err = s.client.decode(response, resp); err != nil {
 		return nil, fmt.Errorf("error unmarshalling response: %w", err)
 	}
 
 	return resp.Data, nil
 }
 
+// Typev4 is the IPv4 configuration type of an interface. It is an alias of
+// string, so InterfaceRequest.Typev4 takes both the constants below and plain
+// strings.
+type Typev4 = string
+
+const (
+	Typev4Static Typev4 = "static"
+	Typev4DHCP   Typev4 = "dhcp"
+	Typev4PPPoE  Typev4 = "pppoe"
+	Typev4PPTP   Typev4 = "pptp"
+	Typev4L2TP   Typev4 = "l2tp"
+	Typev4None   Typev4 = "none"
+)
+
+// Typev6 is the IPv6 configuration type of an interface, to wrap with
+// optional.NewString for InterfaceRequest.Typev6.
+type Typev6 = string
+
+const (
+	Typev6Static Typev6 = "staticv6"
+	Typev6DHCP6  Typev6 = "dhcp6"
+	Typev6SLAAC  Typev6 = "slaac"
+	Typev66RD    Typev6 = "6rd"
+	Typev66To4   Typev6 = "6to4"
+	Typev6Track6 Typev6 = "track6"
+	Typev6None   Typev6 = "none"
+)
+
+// Media is the link speed and duplex of an interface, to wrap with
+// optional.NewString for InterfaceRequest.Media. The media a NIC supports
+// depends on its driver, so other values than these are allowed.
+type Media = string
+
+const (
+	MediaAutoselect Media = "autoselect"
+	Media10BaseT    Media = "10baseT"
+	Media100BaseTX  Media = "100baseTX"
+	Media1000BaseT  Media = "1000baseT"
+	Media2500BaseT  Media = "2500baseT"
+	Media10GBaseT   Media = "10Gbase-T"
+)
+
 type InterfaceRequest struct {
-	If                            string           `json:"if"`
-	Enable                        *optional.Bool   `json:"enable,omitempty"`
-	Descr                         string           `json:"descr"`
-	Spoofmac                      *optional.String `json:"spoofmac,omitempty"`
-	Mtu                           *optional.Int32  `json:"mtu,omitempty"`
-	Mss                           *optional.Int32  `json:"mss,omitempty"`
-	Media                         *optional.String `json:"media,omitempty"`
-	Mediaopt                      *optional.String `json:"mediaopt,omitempty"`
-	Blockpriv                     *optional.Bool   `json:"blockpriv,omitempty"`
-	Blockbogons                   *optional.Bool   `json:"blockbogons,omitempty"`
-	Typev4                        string           `json:"typev4"`
-	Ipaddr                        string           `json:"ipaddr"`
-	Subnet                        int32            `json:"subnet"`
-	Gateway                       *optional.String `json:"gateway,omitempty"`
-	AliasSubnet                   *optional.Int32  `json:"alias_subnet,omitempty"`
-	AdvDhcpPtTimeout              *optional.Int32  `json:"adv_dhcp_pt_timeout,omitempty"`
-	AdvDhcpPtRetry                *optional.Int32  `json:"adv_dhcp_pt_retry,omitempty"`
-	AdvDhcpPtSelectTimeout        *optional.Int32  `json:"adv_dhcp_pt_select_timeout,omitempty"`
-	AdvDhcpPtReboot               *optional.Int32  `json:"adv_dhcp_pt_reboot,omitempty"`
-	AdvDhcpPtBackoffCutoff        *optional.Int32  `json:"adv_dhcp_pt_backoff_cutoff,omitempty"`
-	AdvDhcpPtInitialInterval      *optional.Int32  `json:"adv_dhcp_pt_initial_interval,omitempty"`
-	AdvDhcpSendOptions            *optional.String `json:"adv_dhcp_send_options,omitempty"`
-	AdvDhcpRequestOptions         *optional.String `json:"adv_dhcp_request_options,omitempty"`
-	AdvDhcpRequiredOptions        *optional.String `json:"adv_dhcp_required_options,omitempty"`
-	AdvDhcpOptionModifiers        *optional.String `json:"adv_dhcp_option_modifiers,omitempty"`
-	AdvDhcpConfigFileOverridePath *optional.String `json:"adv_dhcp_config_file_override_path,omitempty"`
-	Typev6                        *optional.String `json:"typev6,omitempty"`
-	Ipaddrv6                      string           `json:"ipaddrv6"`
-	Subnetv6                      int32            `json:"subnetv6"`
-	Gatewayv6                     *optional.String `json:"gatewayv6,omitempty"`
-	Prefix6Rd                     string           `json:"prefix_6rd"`
-	Gateway6Rd                    string           `json:"gateway_6rd"`
-	Prefix6RdV4Plen               int32            `json:"prefix_6rd_v4plen"`
-	Track6Interface               string           `json:"track6_interface"`
+	If                            string             `json:"if"`
+	Enable                        *FlexOptionalBool  `json:"enable,omitempty"`
+	Descr                         string             `json:"descr"`
+	Spoofmac                      *optional.String   `json:"spoofmac,omitempty"`
+	Mtu                           *FlexOptionalInt32 `json:"mtu,omitempty"`
+	Mss                           *FlexOptionalInt32 `json:"mss,omitempty"`
+	Media                         *optional.String   `json:"media,omitempty"`
+	Mediaopt                      *optional.String   `json:"mediaopt,omitempty"`
+	Blockpriv                     *FlexOptionalBool  `json:"blockpriv,omitempty"`
+	Blockbogons                   *FlexOptionalBool  `json:"blockbogons,omitempty"`
+	Typev4                        Typev4             `json:"typev4"`
+	Ipaddr                        string             `json:"ipaddr"`
+	Subnet                        FlexInt32          `json:"subnet"`
+	Gateway                       *optional.String   `json:"gateway,omitempty"`
+	AliasSubnet                   *FlexOptionalInt32 `json:"alias_subnet,omitempty"`
+	AdvDhcpPtTimeout              *FlexOptionalInt32 `json:"adv_dhcp_pt_timeout,omitempty"`
+	AdvDhcpPtRetry                *FlexOptionalInt32 `json:"adv_dhcp_pt_retry,omitempty"`
+	AdvDhcpPtSelectTimeout        *FlexOptionalInt32 `json:"adv_dhcp_pt_select_timeout,omitempty"`
+	AdvDhcpPtReboot               *FlexOptionalInt32 `json:"adv_dhcp_pt_reboot,omitempty"`
+	AdvDhcpPtBackoffCutoff        *FlexOptionalInt32 `json:"adv_dhcp_pt_backoff_cutoff,omitempty"`
+	AdvDhcpPtInitialInterval      *FlexOptionalInt32 `json:"adv_dhcp_pt_initial_interval,omitempty"`
+	AdvDhcpSendOptions            *optional.String   `json:"adv_dhcp_send_options,omitempty"`
+	AdvDhcpRequestOptions         *optional.String   `json:"adv_dhcp_request_options,omitempty"`
+	AdvDhcpRequiredOptions        *optional.String   `json:"adv_dhcp_required_options,omitempty"`
+	AdvDhcpOptionModifiers        *optional.String   `json:"adv_dhcp_option_modifiers,omitempty"`
+	AdvDhcpConfigFileOverridePath *optional.String   `json:"adv_dhcp_config_file_override_path,omitempty"`
+	Typev6                        *optional.String   `json:"typev6,omitempty"`
+	Ipaddrv6                      string             `json:"ipaddrv6"`
+	Subnetv6                      FlexInt32          `json:"subnetv6"`
+	Gatewayv6                     *optional.String   `json:"gatewayv6,omitempty"`
+	Prefix6Rd                     string             `json:"prefix_6rd"`
+	Gateway6Rd                    string             `json:"gateway_6rd"`
+	Prefix6RdV4Plen               FlexInt32          `json:"prefix_6rd_v4plen"`
+	Track6Interface               string             `json:"track6_interface"`
+
+	// Extra holds the fields of the object that this library does not model.
+	// They are sent back unchanged when the request is encoded.
+	Extra map[string]json.RawMessage `json:"-"`
 }
 
 type createInterfaceResponse struct {
@@ -150,7 +196,7 @@ func (s InterfaceService) CreateInterface(
 	}
 
 	resp := new(createInterfaceResponse)
-	if err = json.Unmarshal(response, resp); err != nil {
+	if err = s.client.decode(response, resp); err != nil {
 		return nil, fmt.Errorf("error unmarshalling response: %w", err)
 	}
 	return resp.Data, nil
@@ -178,7 +224,7 @@ func (s InterfaceService) UpdateInterface(
 	}
 
 	resp := new(createInterfaceResponse)
-	if err = json.Unmarshal(response, resp); err != nil {
+	if err = s.client.decode(response, resp); err != nil {
 		return nil, err
 	}
 	return resp.Data, nil
@@ -192,7 +238,7 @@ type VLAN struct {
 
 type vlanListResponse struct {
 	apiResponse
-	Data []*VLAN `json:"data"`
+	Data flexList[*VLAN] `json:"data"`
 }
 
 // ListVLANs returns the VLANs
@@ -203,7 +249,7 @@ func (s InterfaceService) ListVLANs(ctx context.Context) ([]*VLAN, error) {
 	}
 
 	resp := new(vlanListResponse)
-	if err = json.Unmarshal(response, resp); err != nil {
+	if err = s.client.decode(response, resp); err != nil {
 		return nil, fmt.Errorf("error unmarshalling response: %w", err)
 	}
 
@@ -224,7 +270,7 @@ func (s InterfaceService) GetVLAN(ctx context.Context, id int) (*VLAN, error) {
 	}
 
 	resp := new(createVLANResponse)
-	if err = json.Unmarshal(response, resp); err != nil {
+	if err = s.client.decode(response, resp); err != nil {
 		return nil, fmt.Errorf("error unmarshalling response: %w", err)
 	}
 	return resp.Data, nil
@@ -244,7 +290,7 @@ func (s InterfaceService) DeleteVLAN(ctx context.Context, idToDelete int) (*VLAN
 	}
 
 	resp := new(createVLANResponse)
-	if err = json.Unmarshal(response, resp); err != nil {
+	if err = s.client.decode(response, resp); err != nil {
 		return nil, fmt.Errorf("error unmarshalling response: %w", err)
 	}
 	return resp.Data, nil
@@ -256,6 +302,9 @@ type VLANRequest struct {
 	Vlanif *optional.String `json:"vlanif,omitempty"`
 	Pcp    *optional.Int    `json:"pcp,omitempty"`
 	Descr  *optional.String `json:"descr,omitempty"`
+
+	// Extra holds the fields this library does not model.
+	Extra map[string]json.RawMessage `json:"-"`
 }
 
 type createVLANResponse struct {
@@ -279,7 +328,7 @@ func (s InterfaceService) CreateVLAN(
 	}
 
 	resp := new(createVLANResponse)
-	if err = json.Unmarshal(response, resp); err != nil {
+	if err = s.client.decode(response, resp); err != nil {
 		return nil, fmt.Errorf("error unmarshalling response: %w", err)
 	}
 	return resp.Data, nil
@@ -307,7 +356,7 @@ func (s InterfaceService) UpdateVLAN(
 	}
 
 	resp := new(createVLANResponse)
-	if err = json.Unmarshal(response, resp); err != nil {
+	if err = s.client.decode(response, resp); err != nil {
 		return nil, fmt.Errorf("error unmarshalling response: %w", err)
 	}
 	return resp.Data, nil
@@ -320,7 +369,7 @@ type InterfaceGroup struct {
 
 type interfaceGroupListResponse struct {
 	apiResponse
-	Data []*InterfaceGroup `json:"data"`
+	Data flexList[*InterfaceGroup] `json:"data"`
 }
 
 // ListInterfaceGroups returns the interface groups.
@@ -331,31 +380,17 @@ func (s InterfaceService) ListInterfaceGroups(ctx context.Context) ([]*Interface
 	}
 
 	resp := new(interfaceGroupListResponse)
-	if err = json.Unmarshal(response, resp); err != nil {
+	if err = s.client.decode(response, resp); err != nil {
 		return nil, fmt.Errorf("error unmarshalling response: %w", err)
 	}
 
 	return resp.Data, nil
 }
 
-// PutInterfaceGroups replaces all interface groups with the given list.
+// PutInterfaceGroups replaces all interface groups with the given list. It is
+// equivalent to ReplaceAllInterfaceGroups.
 func (s InterfaceService) PutInterfaceGroups(ctx context.Context, groups []*InterfaceGroupRequest) ([]*InterfaceGroup, error) {
-	jsonData, err := json.Marshal(groups)
-	if err != nil {
-		return nil, fmt.Errorf("error marshalling request payload into json: %w", err)
-	}
-
-	response, err := s.client.put(ctx, interfaceGroupsEndpoint, nil, jsonData)
-	if err != nil {
-		return nil, err
-	}
-
-	resp := new(interfaceGroupListResponse)
-	if err = json.Unmarshal(response, resp); err != nil {
-		return nil, fmt.Errorf("error unmarshalling response: %w", err)
-	}
-
-	return resp.Data, nil
+	return s.ReplaceAllInterfaceGroups(ctx, groups)
 }
 
 // GetInterfaceGroup returns the interface group with the given ID.
@@ -372,7 +407,7 @@ func (s InterfaceService) GetInterfaceGroup(ctx context.Context, id int) (*Inter
 	}
 
 	resp := new(interfaceGroupResponse)
-	if err = json.Unmarshal(response, resp); err != nil {
+	if err = s.client.decode(response, resp); err != nil {
 		return nil, fmt.Errorf("error unmarshalling response: %w", err)
 	}
 	return resp.Data, nil
@@ -393,7 +428,7 @@ func (s InterfaceService) DeleteInterfaceGroup(ctx context.Context, idToDelete i
 	}
 
 	resp := new(interfaceGroupResponse)
-	if err = json.Unmarshal(response, resp); err != nil {
+	if err = s.client.decode(response, resp); err != nil {
 		return nil, fmt.Errorf("error unmarshalling response: %w", err)
 	}
 	return resp.Data, nil
@@ -401,9 +436,12 @@ func (s InterfaceService) DeleteInterfaceGroup(ctx context.Context, idToDelete i
 
 // InterfaceGroupRequest represents the request to create or update an interface group.
 type InterfaceGroupRequest struct {
-	Ifname  string   `json:"ifname"`
-	Members []string `json:"members"`
-	Descr   string   `json:"descr"`
+	Ifname  string         `json:"ifname"`
+	Members FlexStringList `json:"members"`
+	Descr   string         `json:"descr"`
+
+	// Extra holds the fields this library does not model.
+	Extra map[string]json.RawMessage `json:"-"`
 }
 
 type interfaceGroupResponse struct {
@@ -426,7 +464,7 @@ func (s InterfaceService) CreateInterfaceGroup(
 	}
 
 	resp := new(interfaceGroupResponse)
-	if err = json.Unmarshal(response, resp); err != nil {
+	if err = s.client.decode(response, resp); err != nil {
 		return nil, fmt.Errorf("error unmarshalling response: %w", err)
 	}
 	return resp.Data, nil
@@ -454,7 +492,7 @@ func (s InterfaceService) UpdateInterfaceGroup(
 	}
 
 	resp := new(interfaceGroupResponse)
-	if err = json.Unmarshal(response, resp); err != nil {
+	if err = s.client.decode(response, resp); err != nil {
 		return nil, fmt.Errorf("error unmarshalling response: %w", err)
 	}
 	return resp.Data, nil
@@ -468,7 +506,7 @@ func (s InterfaceService) Apply(ctx context.Context) error {
 	}
 
 	resp := new(apiResponse)
-	if err = json.Unmarshal(response, resp); err != nil {
+	if err = s.client.decode(response, resp); err != nil {
 		return fmt.Errorf("error unmarshalling response: %w", err)
 	}
 
@@ -483,7 +521,7 @@ type InterfaceBridge struct {
 
 type interfaceBridgeListResponse struct {
 	apiResponse
-	Data []*InterfaceBridge `json:"data"`
+	Data flexList[*InterfaceBridge] `json:"data"`
 }
 
 // ListInterfaceBridges returns the bridges.
@@ -494,7 +532,7 @@ func (s InterfaceService) ListInterfaceBridges(ctx context.Context) ([]*Interfac
 	}
 
 	resp := new(interfaceBridgeListResponse)
-	if err = json.Unmarshal(response, resp); err != nil {
+	if err = s.client.decode(response, resp); err != nil {
 		return nil, fmt.Errorf("error unmarshalling response: %w", err)
 	}
 
@@ -520,7 +558,7 @@ func (s InterfaceService) GetInterfaceBridge(ctx context.Context, id string) (*I
 	}
 
 	resp := new(interfaceBridgeResponse)
-	if err = json.Unmarshal(response, resp); err != nil {
+	if err = s.client.decode(response, resp); err != nil {
 		return nil, fmt.Errorf("error unmarshalling response: %w", err)
 	}
 	return resp.Data, nil
@@ -540,7 +578,7 @@ func (s InterfaceService) DeleteInterfaceBridge(ctx context.Context, idToDelete
 	}
 
 	resp := new(interfaceBridgeResponse)
-	if err = json.Unmarshal(response, resp); err != nil {
+	if err = s.client.decode(response, resp); err != nil {
 		return nil, fmt.Errorf("error unmarshalling response: %w", err)
 	}
 	return resp.Data, nil
@@ -562,7 +600,7 @@ func (s InterfaceService) CreateInterfaceBridge(
 	}
 
 	resp := new(interfaceBridgeResponse)
-	if err = json.Unmarshal(response, resp); err != nil {
+	if err = s.client.decode(response, resp); err != nil {
 		return nil, fmt.Errorf("error unmarshalling response: %w", err)
 	}
 	return resp.Data, nil
@@ -590,7 +628,7 @@ func (s InterfaceService) UpdateInterfaceBridge(
 	}
 
 	resp := new(interfaceBridgeResponse)
-	if err = json.Unmarshal(response, resp); err != nil {
+	if err = s.client.decode(response, resp); err != nil {
 		return nil, fmt.Errorf("error unmarshalling response: %w", err)
 	}
 	return resp.Data, nil
@@ -598,7 +636,10 @@ func (s InterfaceService) UpdateInterfaceBridge(
 
 // InterfaceBridgeRequest represents the request to create or update a bridge.
 type InterfaceBridgeRequest struct {
-	Members  []string `json:"members"`
-	Descr    string   `json:"descr"`
-	Bridgeif string   `json:"bridgeif"`
+	Members  FlexStringList `json:"members"`
+	Descr    string         `json:"descr"`
+	Bridgeif string         `json:"bridgeif"`
+
+	// Extra holds the fields this library does not model.
+	Extra map[string]json.RawMessage `json:"-"`
 }
@@ -182,7 +182,7 @@ func TestInterfaceService_CreateInterface(t *testing.T) {
 	defer server.Close()
 
 	newClient := NewClientWithNoAuth(server.URL)
-	enable := optional.NewBool(true)
+	enable := NewFlexOptionalBool(true)
 	newInterface := InterfaceRequest{
 		If:     "em3",
 		Enable: &enable,
@@ -359,7 +359,7 @@ func TestInterfaceService_UpdateInterface(t *testing.T) {
 	defer server.Close()
 
 	newClient := NewClientWithNoAuth(server.URL)
-	enable := optional.NewBool(true)
+	enable := NewFlexOptionalBool(true)
 	updatedInterface := InterfaceRequest{
 		If:     "em3",
 		Enable: &enable,
@@ -0,0 +1,602 @@
+package pfsenseapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/markphelps/optional"
+)
+
+const (
+	natPortForwardEndpoint  = "api/v2/firewall/nat/port_forward"
+	natPortForwardsEndpoint = "api/v2/firewall/nat/port_forwards"
+
+	natOutboundModeEndpoint     = "api/v2/firewall/nat/outbound/mode"
+	natOutboundMappingEndpoint  = "api/v2/firewall/nat/outbound/mapping"
+	natOutboundMappingsEndpoint = "api/v2/firewall/nat/outbound/mappings"
+
+	natOneToOneMappingEndpoint  = "api/v2/firewall/nat/one_to_one/mapping"
+	natOneToOneMappingsEndpoint = "api/v2/firewall/nat/one_to_one/mappings"
+)
+
+// NATService provides NAT API methods. NAT changes take effect once
+// Firewall.Apply is called.
+type NATService service
+
+// NATReflection is how a port forward handles traffic to its external
+// address coming from the inside. The empty value uses the system default.
+type NATReflection string
+
+const (
+	NATReflectionDefault NATReflection = ""
+	NATReflectionEnable  NATReflection = "enable"
+	NATReflectionDisable NATReflection = "disable"
+	NATReflectionPureNAT NATReflection = "purenat"
+)
+
+// AssociatedRule is the filter rule pfSense adds alongside a port forward
+// when it is created.
+type AssociatedRule string
+
+const (
+	// AssociatedRuleNone adds no filter rule; traffic is passed only if
+	// another rule allows it.
+	AssociatedRuleNone AssociatedRule = ""
+	// AssociatedRuleNew adds a filter rule linked to the port forward, which
+	// pfSense keeps in step with it and removes along with it.
+	AssociatedRuleNew AssociatedRule = "new"
+	// AssociatedRulePass passes the forwarded traffic without a separate
+	// filter rule.
+	AssociatedRulePass AssociatedRule = "pass"
+)
+
+// PortForward represents a single NAT port forward.
+type PortForward struct {
+	PortForwardRequest
+	Id int `json:"id"`
+}
+
+// LinkedRuleId returns the associated_rule_id of the filter rule linked to
+// the port forward, or false if it has none.
+func (p *PortForward) LinkedRuleId() (string, bool) {
+	switch p.AssociatedRule {
+	case AssociatedRuleNone, AssociatedRuleNew, AssociatedRulePass:
+		return "", false
+	}
+	return string(p.AssociatedRule), true
+}
+
+type portForwardListResponse struct {
+	apiResponse
+	Data flexList[*PortForward] `json:"data"`
+}
+
+// ListPortForwards returns the NAT port forwards.
+func (s NATService) ListPortForwards(ctx context.Context) ([]*PortForward, error) {
+	response, err := s.client.get(ctx, natPortForwardsEndpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := new(portForwardListResponse)
+	if err = s.client.decode(response, resp); err != nil {
+		return nil, fmt.Errorf("error unmarshalling response: %w", err)
+	}
+	return resp.Data, nil
+}
+
+// GetPortForward returns the port forward with the given ID.
+func (s NATService) GetPortForward(ctx context.Context, id int) (*PortForward, error) {
+	response, err := s.client.get(
+		ctx,
+		natPortForwardEndpoint,
+		map[string]string{
+			"id": strconv.Itoa(id),
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := new(portForwardResponse)
+	if err = s.client.decode(response, resp); err != nil {
+		return nil, fmt.Errorf("error unmarshalling response: %w", err)
+	}
+	return resp.Data, nil
+}
+
+// DeletePortForward deletes a port forward. pfSense deletes its linked filter
+// rule along with it.
+func (s NATService) DeletePortForward(ctx context.Context, idToDelete int) (*PortForward, error) {
+	response, err := s.client.delete(
+		ctx,
+		natPortForwardEndpoint,
+		map[string]string{
+			"id": strconv.Itoa(idToDelete),
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := new(portForwardResponse)
+	if err = s.client.decode(response, resp); err != nil {
+		return nil, fmt.Errorf("error unmarshalling response: %w", err)
+	}
+	return resp.Data, nil
+}
+
+// PortForwardRequest is a port forward as it is created or updated.
+// Destination and DestinationPort are the external address and ports the
+// traffic arrives on; Target and LocalPort are where it is sent instead.
+type PortForwardRequest struct {
+	Interface       string           `json:"interface"`
+	Ipprotocol      IPProtocol       `json:"ipprotocol"`
+	Protocol        RuleProtocol     `json:"protocol"`
+	Source          RuleAddress      `json:"source"`
+	SourcePort      *optional.String `json:"source_port,omitempty"`
+	Destination     RuleAddress      `json:"destination"`
+	DestinationPort *optional.String `json:"destination_port,omitempty"`
+	Target          string           `json:"target"`
+	LocalPort       *optional.String `json:"local_port,omitempty"`
+	Disabled        bool             `json:"disabled"`
+	Nordr           bool             `json:"nordr"`
+	Nosync          bool             `json:"nosync"`
+	Descr           *optional.String `json:"descr,omitempty"`
+	Natreflection   NATReflection    `json:"natreflection,omitempty"`
+
+	// AssociatedRule picks the filter rule to add when the port forward is
+	// created. In a port forward read back it holds the associated_rule_id
+	// of the linked rule instead, see PortForward.LinkedRuleId.
+	AssociatedRule AssociatedRule `json:"associated_rule_id,omitempty"`
+
+	// Extra holds the fields this library does not model.
+	Extra map[string]json.RawMessage `json:"-"`
+}
+
+type portForwardResponse struct {
+	apiResponse
+	Data *PortForward `json:"data"`
+}
+
+// CreatePortForward creates a new port forward.
+func (s NATService) CreatePortForward(
+	ctx context.Context,
+	newPortForward PortForwardRequest,
+) (*PortForward, error) {
+	jsonData, err := json.Marshal(newPortForward)
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling request payload into json: %w", err)
+	}
+
+	response, err := s.client.post(ctx, natPortForwardEndpoint, nil, jsonData)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := new(portForwardResponse)
+	if err = s.client.decode(response, resp); err != nil {
+		return nil, fmt.Errorf("error unmarshalling response: %w", err)
+	}
+	return resp.Data, nil
+}
+
+// UpdatePortForward modifies an existing port forward. A linked filter rule
+// is updated to match by pfSense.
+func (s NATService) UpdatePortForward(
+	ctx context.Context,
+	idToUpdate int,
+	portForwardData PortForwardRequest,
+) (*PortForward, error) {
+	requestData := PortForward{
+		PortForwardRequest: portForwardData,
+		Id:                 idToUpdate,
+	}
+
+	jsonData, err := json.Marshal(requestData)
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling request payload into json: %w", err)
+	}
+
+	response, err := s.client.patch(ctx, natPortForwardEndpoint, nil, jsonData)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := new(portForwardResponse)
+	if err = s.client.decode(response, resp); err != nil {
+		return nil, fmt.Errorf("error unmarshalling response: %w", err)
+	}
+	return resp.Data, nil
+}
+
+// GetLinkedRule returns the filter rule linked to the port forward.
+func (s NATService) GetLinkedRule(ctx context.Context, portForward *PortForward) (*FirewallRule, error) {
+	id, ok := portForward.LinkedRuleId()
+	if !ok {
+		return nil, fmt.Errorf("%w: port forward %d has no linked filter rule", ErrRuleNotFound, portForward.Id)
+	}
+
+	rules, err := s.client.Firewall.ListRules(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, rule := range rules {
+		if rule.AssociatedRuleId == id {
+			return rule, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: no rule with associated_rule_id %s", ErrRuleNotFound, id)
+}
+
+// OutboundNATMode is how pfSense builds the outbound NAT rules.
+type OutboundNATMode string
+
+const (
+	// OutboundNATModeAutomatic generates the rules from the interfaces and
+	// ignores the mappings.
+	OutboundNATModeAutomatic OutboundNATMode = "automatic"
+	// OutboundNATModeHybrid applies the mappings ahead of the generated
+	// rules.
+	OutboundNATModeHybrid OutboundNATMode = "hybrid"
+	// OutboundNATModeManual applies only the mappings. pfSense calls this
+	// mode "advanced".
+	OutboundNATModeManual OutboundNATMode = "advanced"
+	// OutboundNATModeDisabled turns outbound NAT off.
+	OutboundNATModeDisabled OutboundNATMode = "disabled"
+)
+
+type outboundNATModeSettings struct {
+	Mode OutboundNATMode `json:"mode"`
+}
+
+type outboundNATModeResponse struct {
+	apiResponse
+	Data *outboundNATModeSettings `json:"data"`
+}
+
+// GetOutboundNATMode returns the outbound NAT mode.
+func (s NATService) GetOutboundNATMode(ctx context.Context) (OutboundNATMode, error) {
+	response, err := s.client.get(ctx, natOutboundModeEndpoint, nil)
+	if err != nil {
+		return "", err
+	}
+
+	resp := new(outboundNATModeResponse)
+	if err = s.client.decode(response, resp); err != nil {
+		return "", fmt.Errorf("error unmarshalling response: %w", err)
+	}
+	if resp.Data == nil {
+		return "", nil
+	}
+	return resp.Data.Mode, nil
+}
+
+// SetOutboundNATMode switches the outbound NAT mode and returns the mode now
+// in effect. Switching from automatic to hybrid or manual makes pfSense add
+// mappings for the rules it generated until then.
+func (s NATService) SetOutboundNATMode(ctx context.Context, mode OutboundNATMode) (OutboundNATMode, error) {
+	jsonData, err := json.Marshal(outboundNATModeSettings{Mode: mode})
+	if err != nil {
+		return "", fmt.Errorf("error marshalling request payload into json: %w", err)
+	}
+
+	response, err := s.client.patch(ctx, natOutboundModeEndpoint, nil, jsonData)
+	if err != nil {
+		return "", err
+	}
+
+	resp := new(outboundNATModeResponse)
+	if err = s.client.decode(response, resp); err != nil {
+		return "", fmt.Errorf("error unmarshalling response: %w", err)
+	}
+	if resp.Data == nil {
+		return "", nil
+	}
+	return resp.Data.Mode, nil
+}
+
+// PoolOption is how an outbound mapping picks the translation address when
+// its target holds more than one. The empty value uses round-robin for
+// aliases and the pf default otherwise.
+type PoolOption string
+
+const (
+	PoolOptionDefault                 PoolOption = ""
+	PoolOptionRoundRobin              PoolOption = "round-robin"
+	PoolOptionRoundRobinStickyAddress PoolOption = "round-robin sticky-address"
+	PoolOptionRandom                  PoolOption = "random"
+	PoolOptionRandomStickyAddress     PoolOption = "random sticky-address"
+	PoolOptionSourceHash              PoolOption = "source-hash"
+	PoolOptionBitmask                 PoolOption = "bitmask"
+)
+
+// OutboundMapping represents a single outbound NAT mapping. Mappings are
+// evaluated in order, so like a FirewallRule its Id is its position in the
+// list.
+type OutboundMapping struct {
+	OutboundMappingRequest
+	Id int `json:"id"`
+}
+
+type outboundMappingListResponse struct {
+	apiResponse
+	Data flexList[*OutboundMapping] `json:"data"`
+}
+
+// ListOutboundMappings returns the outbound NAT mappings in the order they
+// are evaluated.
+func (s NATService) ListOutboundMappings(ctx context.Context) ([]*OutboundMapping, error) {
+	response, err := s.client.get(ctx, natOutboundMappingsEndpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := new(outboundMappingListResponse)
+	if err = s.client.decode(response, resp); err != nil {
+		return nil, fmt.Errorf("error unmarshalling response: %w", err)
+	}
+	return resp.Data, nil
+}
+
+// GetOutboundMapping returns the outbound NAT mapping with the given ID.
+func (s NATService) GetOutboundMapping(ctx context.Context, id int) (*OutboundMapping, error) {
+	response, err := s.client.get(
+		ctx,
+		natOutboundMappingEndpoint,
+		map[string]string{
+			"id": strconv.Itoa(id),
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := new(outboundMappingResponse)
+	if err = s.client.decode(response, resp); err != nil {
+		return nil, fmt.Errorf("error unmarshalling response: %w", err)
+	}
+	return resp.Data, nil
+}
+
+// DeleteOutboundMapping deletes an outbound NAT mapping.
+func (s NATService) DeleteOutboundMapping(ctx context.Context, idToDelete int) (*OutboundMapping, error) {
+	response, err := s.client.delete(
+		ctx,
+		natOutboundMappingEndpoint,
+		map[string]string{
+			"id": strconv.Itoa(idToDelete),
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := new(outboundMappingResponse)
+	if err = s.client.decode(response, resp); err != nil {
+		return nil, fmt.Errorf("error unmarshalling response: %w", err)
+	}
+	return resp.Data, nil
+}
+
+// OutboundMappingRequest is an outbound NAT mapping as it is created or
+// updated. Target is the translation address; when it is left empty the
+// address of Interface is used.
+type OutboundMappingRequest struct {
+	Interface       string           `json:"interface"`
+	Protocol        RuleProtocol     `json:"protocol,omitempty"`
+	Source          RuleAddress      `json:"source"`
+	SourcePort      *optional.String `json:"source_port,omitempty"`
+	Destination     RuleAddress      `json:"destination"`
+	DestinationPort *optional.String `json:"destination_port,omitempty"`
+	Target          *optional.String `json:"target,omitempty"`
+	TargetSubnet    *optional.Int    `json:"target_subnet,omitempty"`
+	NatPort         *optional.String `json:"nat_port,omitempty"`
+	StaticNatPort   bool             `json:"static_nat_port"`
+	Nonat           bool             `json:"nonat"`
+	Disabled        bool             `json:"disabled"`
+	Nosync          bool             `json:"nosync"`
+	Poolopts        PoolOption       `json:"poolopts,omitempty"`
+	SourceHashKey   *optional.String `json:"source_hash_key,omitempty"`
+	Descr           *optional.String `json:"descr,omitempty"`
+
+	// Extra holds the fields this library does not model.
+	Extra map[string]json.RawMessage `json:"-"`
+}
+
+type outboundMappingResponse struct {
+	apiResponse
+	Data *OutboundMapping `json:"data"`
+}
+
+// CreateOutboundMapping creates a new outbound NAT mapping at the end of the
+// list. Mappings only take effect in hybrid and manual mode.
+func (s NATService) CreateOutboundMapping(
+	ctx context.Context,
+	newMapping OutboundMappingRequest,
+) (*OutboundMapping, error) {
+	jsonData, err := json.Marshal(newMapping)
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling request payload into json: %w", err)
+	}
+
+	response, err := s.client.post(ctx, natOutboundMappingEndpoint, nil, jsonData)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := new(outboundMappingResponse)
+	if err = s.client.decode(response, resp); err != nil {
+		return nil, fmt.Errorf("error unmarshalling response: %w", err)
+	}
+	return resp.Data, nil
+}
+
+// UpdateOutboundMapping modifies an existing outbound NAT mapping.
+func (s NATService) UpdateOutboundMapping(
+	ctx context.Context,
+	idToUpdate int,
+	mappingData OutboundMappingRequest,
+) (*OutboundMapping, error) {
+	requestData := OutboundMapping{
+		OutboundMappingRequest: mappingData,
+		Id:                     idToUpdate,
+	}
+
+	jsonData, err := json.Marshal(requestData)
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling request payload into json: %w", err)
+	}
+
+	response, err := s.client.patch(ctx, natOutboundMappingEndpoint, nil, jsonData)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := new(outboundMappingResponse)
+	if err = s.client.decode(response, resp); err != nil {
+		return nil, fmt.Errorf("error unmarshalling response: %w", err)
+	}
+	return resp.Data, nil
+}
+
+// OneToOneMapping represents a single 1:1 NAT mapping.
+type OneToOneMapping struct {
+	OneToOneMappingRequest
+	Id int `json:"id"`
+}
+
+type oneToOneMappingListResponse struct {
+	apiResponse
+	Data flexList[*OneToOneMapping] `json:"data"`
+}
+
+// ListOneToOneMappings returns the 1:1 NAT mappings.
+func (s NATService) ListOneToOneMappings(ctx context.Context) ([]*OneToOneMapping, error) {
+	response, err := s.client.get(ctx, natOneToOneMappingsEndpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := new(oneToOneMappingListResponse)
+	if err = s.client.decode(response, resp); err != nil {
+		return nil, fmt.Errorf("error unmarshalling response: %w", err)
+	}
+	return resp.Data, nil
+}
+
+// GetOneToOneMapping returns the 1:1 NAT mapping with the given ID.
+func (s NATService) GetOneToOneMapping(ctx context.Context, id int) (*OneToOneMapping, error) {
+	response, err := s.client.get(
+		ctx,
+		natOneToOneMappingEndpoint,
+		map[string]string{
+			"id": strconv.Itoa(id),
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := new(oneToOneMappingResponse)
+	if err = s.client.decode(response, resp); err != nil {
+		return nil, fmt.Errorf("error unmarshalling response: %w", err)
+	}
+	return resp.Data, nil
+}
+
+// DeleteOneToOneMapping deletes a 1:1 NAT mapping.
+func (s NATService) DeleteOneToOneMapping(ctx context.Context, idToDelete int) (*OneToOneMapping, error) {
+	response, err := s.client.delete(
+		ctx,
+		natOneToOneMappingEndpoint,
+		map[string]string{
+			"id": strconv.Itoa(idToDelete),
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := new(oneToOneMappingResponse)
+	if err = s.client.decode(response, resp); err != nil {
+		return nil, fmt.Errorf("error unmarshalling response: %w", err)
+	}
+	return resp.Data, nil
+}
+
+// OneToOneMappingRequest is a 1:1 NAT mapping as it is created or updated.
+// Source is the internal address or subnet and External the first address
+// of the public block it maps onto, which has the size of Source. The
+// mapping only applies to traffic to or from Destination.
+type OneToOneMappingRequest struct {
+	Interface     string           `json:"interface"`
+	Ipprotocol    IPProtocol       `json:"ipprotocol,omitempty"`
+	External      string           `json:"external"`
+	Source        RuleAddress      `json:"source"`
+	Destination   RuleAddress      `json:"destination"`
+	Disabled      bool             `json:"disabled"`
+	Nobinat       bool             `json:"nobinat"`
+	Natreflection NATReflection    `json:"natreflection,omitempty"`
+	Descr         *optional.String `json:"descr,omitempty"`
+
+	// Extra holds the fields this library does not model.
+	Extra map[string]json.RawMessage `json:"-"`
+}
+
+type oneToOneMappingResponse struct {
+	apiResponse
+	Data *OneToOneMapping `json:"data"`
+}
+
+// CreateOneToOneMapping creates a new 1:1 NAT mapping.
+func (s NATService) CreateOneToOneMapping(
+	ctx context.Context,
+	newMapping OneToOneMappingRequest,
+) (*OneToOneMapping, error) {
+	jsonData, err := json.Marshal(newMapping)
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling request payload into json: %w", err)
+	}
+
+	response, err := s.client.post(ctx, natOneToOneMappingEndpoint, nil, jsonData)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := new(oneToOneMappingResponse)
+	if err = s.client.decode(response, resp); err != nil {
+		return nil, fmt.Errorf("error unmarshalling response: %w", err)
+	}
+	return resp.Data, nil
+}
+
+// UpdateOneToOneMapping modifies an existing 1:1 NAT mapping.
+func (s NATService) UpdateOneToOneMapping(
+	ctx context.Context,
+	idToUpdate int,
+	mappingData OneToOneMappingRequest,
+) (*OneToOneMapping, error) {
+	requestData := OneToOneMapping{
+		OneToOneMappingRequest: mappingData,
+		Id:                     idToUpdate,
+	}
+
+	jsonData, err := json.Marshal(requestData)
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling request payload into json: %w", err)
+	}
+
+	response, err := s.client.patch(ctx, natOneToOneMappingEndpoint, nil, jsonData)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := new(oneToOneMappingResponse)
+	if err = s.client.decode(response, resp); err != nil {
+		return nil, fmt.Errorf("error unmarshalling response: %w", err)
+	}
+	return resp.Data, nil
+}
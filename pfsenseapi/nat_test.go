@@ -0,0 +1,505 @@
+package pfsenseapi
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/markphelps/optional"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNATService_ListPortForwards(t *testing.T) {
+	data := mustReadFileString(t, "testdata/multipleportforward.json")
+	server := setupTestServer(t, data)
+	defer server.Close()
+
+	newClient := NewClientWithNoAuth(server.URL)
+	response, err := newClient.NAT.ListPortForwards(context.Background())
+	require.NoError(t, err)
+	require.Len(t, response, 2)
+	require.Equal(t, ProtocolUDP, response[1].Protocol)
+	require.Equal(t, RuleAddress{Address: "198.51.100.0/24", Not: true}, response[1].Source)
+	require.Equal(t, NATReflectionDefault, response[1].Natreflection)
+	require.Equal(t, AssociatedRulePass, response[1].AssociatedRule)
+	_, linked := response[1].LinkedRuleId()
+	require.False(t, linked)
+
+	response, err = newClient.NAT.ListPortForwards(context.Background())
+	require.Error(t, err)
+	require.Nil(t, response)
+
+	response, err = newClient.NAT.ListPortForwards(context.Background())
+	require.Error(t, err)
+	require.Nil(t, response)
+}
+
+func TestNATService_GetPortForward(t *testing.T) {
+	data := mustReadFileString(t, "testdata/singleportforward.json")
+	server := setupTestServer(t, data)
+	defer server.Close()
+
+	newClient := NewClientWithNoAuth(server.URL)
+	response, err := newClient.NAT.GetPortForward(context.Background(), 0)
+	require.NoError(t, err)
+	require.Equal(t, "10.0.0.5", response.Target)
+	require.Equal(t, "8443", response.LocalPort.MustGet())
+	require.Equal(t, NATReflectionEnable, response.Natreflection)
+	ruleID, linked := response.LinkedRuleId()
+	require.True(t, linked)
+	require.Equal(t, "nat_6560a1b2c3d4e5.12345678", ruleID)
+	require.Contains(t, response.Extra, "created_by")
+
+	response, err = newClient.NAT.GetPortForward(context.Background(), 0)
+	require.Error(t, err)
+	require.Nil(t, response)
+
+	response, err = newClient.NAT.GetPortForward(context.Background(), 0)
+	require.Error(t, err)
+	require.Nil(t, response)
+}
+
+func TestNATService_DeletePortForward(t *testing.T) {
+	data := mustReadFileString(t, "testdata/singleportforward.json")
+	server := setupTestServer(t, data)
+	defer server.Close()
+
+	newClient := NewClientWithNoAuth(server.URL)
+	response, err := newClient.NAT.DeletePortForward(context.Background(), 0)
+	require.NoError(t, err)
+	require.NotNil(t, response)
+
+	response, err = newClient.NAT.DeletePortForward(context.Background(), 0)
+	require.Error(t, err)
+	require.Nil(t, response)
+
+	response, err = newClient.NAT.DeletePortForward(context.Background(), 0)
+	require.Error(t, err)
+	require.Nil(t, response)
+}
+
+func TestNATService_CreatePortForward(t *testing.T) {
+	data := mustReadFileString(t, "testdata/singleportforward.json")
+	server := setupTestServer(t, data)
+	defer server.Close()
+
+	newClient := NewClientWithNoAuth(server.URL)
+	port, localPort := optional.NewString("443"), optional.NewString("8443")
+	newPortForward := PortForwardRequest{
+		Interface:       "wan",
+		Ipprotocol:      IPProtocolInet,
+		Protocol:        ProtocolTCP,
+		Source:          AnyAddress,
+		Destination:     RuleAddress{Address: "wan:ip"},
+		DestinationPort: &port,
+		Target:          "10.0.0.5",
+		LocalPort:       &localPort,
+		AssociatedRule:  AssociatedRuleNew,
+	}
+	response, err := newClient.NAT.CreatePortForward(context.Background(), newPortForward)
+	require.NoError(t, err)
+	require.NotNil(t, response)
+
+	response, err = newClient.NAT.CreatePortForward(context.Background(), newPortForward)
+	require.Error(t, err)
+	require.Nil(t, response)
+
+	response, err = newClient.NAT.CreatePortForward(context.Background(), newPortForward)
+	require.Error(t, err)
+	require.Nil(t, response)
+}
+
+func TestNATService_UpdatePortForward(t *testing.T) {
+	data := mustReadFileString(t, "testdata/singleportforward.json")
+
+	var received map[string]any
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodPatch, r.Method)
+		require.Equal(t, "/"+natPortForwardEndpoint, r.URL.Path)
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		require.NoError(t, json.Unmarshal(body, &received))
+
+		w.Header().Set("Content-Type", "application/json")
+		_, err = io.WriteString(w, data)
+		require.NoError(t, err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(handler))
+	defer server.Close()
+
+	newClient := NewClientWithNoAuth(server.URL)
+	response, err := newClient.NAT.UpdatePortForward(context.Background(), 3, PortForwardRequest{
+		Interface:   "wan",
+		Protocol:    ProtocolTCPUDP,
+		Source:      RuleAddress{Address: "trusted_hosts"},
+		Destination: RuleAddress{Address: "wan:ip"},
+		Target:      "10.0.0.6",
+	})
+	require.NoError(t, err)
+	require.NotNil(t, response)
+
+	require.Equal(t, float64(3), received["id"])
+	require.Equal(t, "tcp/udp", received["protocol"])
+	require.Equal(t, "trusted_hosts", received["source"])
+	require.Equal(t, "10.0.0.6", received["target"])
+	require.NotContains(t, received, "local_port")
+	require.NotContains(t, received, "natreflection")
+	require.NotContains(t, received, "associated_rule_id")
+}
+
+func TestNATService_GetLinkedRule(t *testing.T) {
+	portForward := mustReadFileString(t, "testdata/singleportforward.json")
+	rules := map[string]any{"code": 200, "status": "ok", "data": []map[string]any{
+		{"id": 0, "type": "block", "interface": []string{"wan"}, "source": "any", "destination": "any"},
+		{"id": 1, "type": "pass", "interface": []string{"wan"}, "source": "any", "destination": "10.0.0.5",
+			"associated_rule_id": "nat_6560a1b2c3d4e5.12345678"},
+	}}
+
+	var ruleReads int
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/" + firewallRulesEndpoint:
+			ruleReads++
+			require.NoError(t, json.NewEncoder(w).Encode(rules))
+		default:
+			_, err := io.WriteString(w, portForward)
+			require.NoError(t, err)
+		}
+	}
+	server := httptest.NewServer(http.HandlerFunc(handler))
+	defer server.Close()
+
+	newClient := NewClient(Config{Host: server.URL, Timeout: defaultTimeout, CacheEnabled: true})
+	ctx := context.Background()
+
+	pf, err := newClient.NAT.GetPortForward(ctx, 0)
+	require.NoError(t, err)
+	rule, err := newClient.NAT.GetLinkedRule(ctx, pf)
+	require.NoError(t, err)
+	require.Equal(t, 1, rule.Id)
+	require.Equal(t, 1, ruleReads)
+
+	// changing the port forward changes its linked rule, so the cached rule
+	// list is dropped too
+	_, err = newClient.NAT.DeletePortForward(ctx, 0)
+	require.NoError(t, err)
+	_, err = newClient.Firewall.ListRules(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 2, ruleReads)
+
+	pf.AssociatedRule = AssociatedRulePass
+	_, err = newClient.NAT.GetLinkedRule(ctx, pf)
+	require.ErrorIs(t, err, ErrRuleNotFound)
+
+	pf.AssociatedRule = "nat_missing"
+	_, err = newClient.NAT.GetLinkedRule(ctx, pf)
+	require.ErrorIs(t, err, ErrRuleNotFound)
+}
+
+func TestNATService_GetOutboundNATMode(t *testing.T) {
+	data := mustReadFileString(t, "testdata/outboundnatmode.json")
+	server := setupTestServer(t, data)
+	defer server.Close()
+
+	newClient := NewClientWithNoAuth(server.URL)
+	mode, err := newClient.NAT.GetOutboundNATMode(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, OutboundNATModeHybrid, mode)
+
+	mode, err = newClient.NAT.GetOutboundNATMode(context.Background())
+	require.Error(t, err)
+	require.Empty(t, mode)
+
+	mode, err = newClient.NAT.GetOutboundNATMode(context.Background())
+	require.Error(t, err)
+	require.Empty(t, mode)
+}
+
+func TestNATService_SetOutboundNATMode(t *testing.T) {
+	mappings := mustReadFileString(t, "testdata/multipleoutboundmapping.json")
+
+	var received map[string]any
+	var mappingReads int
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/" + natOutboundMappingsEndpoint:
+			mappingReads++
+			_, err := io.WriteString(w, mappings)
+			require.NoError(t, err)
+		case "/" + natOutboundModeEndpoint:
+			require.Equal(t, http.MethodPatch, r.Method)
+			body, err := io.ReadAll(r.Body)
+			require.NoError(t, err)
+			require.NoError(t, json.Unmarshal(body, &received))
+			require.NoError(t, json.NewEncoder(w).Encode(map[string]any{"code": 200, "status": "ok", "data": received}))
+		}
+	}
+	server := httptest.NewServer(http.HandlerFunc(handler))
+	defer server.Close()
+
+	newClient := NewClient(Config{Host: server.URL, Timeout: defaultTimeout, CacheEnabled: true})
+	ctx := context.Background()
+
+	_, err := newClient.NAT.ListOutboundMappings(ctx)
+	require.NoError(t, err)
+
+	mode, err := newClient.NAT.SetOutboundNATMode(ctx, OutboundNATModeManual)
+	require.NoError(t, err)
+	require.Equal(t, OutboundNATModeManual, mode)
+	require.Equal(t, map[string]any{"mode": "advanced"}, received)
+
+	// switching modes can add mappings, so they are read again
+	_, err = newClient.NAT.ListOutboundMappings(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 2, mappingReads)
+}
+
+func TestNATService_ListOutboundMappings(t *testing.T) {
+	data := mustReadFileString(t, "testdata/multipleoutboundmapping.json")
+	server := setupTestServer(t, data)
+	defer server.Close()
+
+	newClient := NewClientWithNoAuth(server.URL)
+	response, err := newClient.NAT.ListOutboundMappings(context.Background())
+	require.NoError(t, err)
+	require.Len(t, response, 2)
+	require.Equal(t, RuleAddress{Address: "10.0.0.0/8", Not: true}, response[0].Destination)
+	require.Equal(t, PoolOptionSourceHash, response[0].Poolopts)
+	require.Equal(t, ProtocolUDP, response[1].Protocol)
+	require.True(t, response[1].StaticNatPort)
+	require.Equal(t, PoolOptionDefault, response[1].Poolopts)
+
+	response, err = newClient.NAT.ListOutboundMappings(context.Background())
+	require.Error(t, err)
+	require.Nil(t, response)
+
+	response, err = newClient.NAT.ListOutboundMappings(context.Background())
+	require.Error(t, err)
+	require.Nil(t, response)
+}
+
+func TestNATService_GetOutboundMapping(t *testing.T) {
+	data := mustReadFileString(t, "testdata/singleoutboundmapping.json")
+	server := setupTestServer(t, data)
+	defer server.Close()
+
+	newClient := NewClientWithNoAuth(server.URL)
+	response, err := newClient.NAT.GetOutboundMapping(context.Background(), 0)
+	require.NoError(t, err)
+	require.Equal(t, "wan2", response.Interface)
+	require.Equal(t, "wan2_pool", response.Target.MustGet())
+	require.Equal(t, 32, response.TargetSubnet.MustGet())
+	require.Equal(t, ProtocolAny, response.Protocol)
+	require.Contains(t, response.Extra, "created_by")
+
+	response, err = newClient.NAT.GetOutboundMapping(context.Background(), 0)
+	require.Error(t, err)
+	require.Nil(t, response)
+
+	response, err = newClient.NAT.GetOutboundMapping(context.Background(), 0)
+	require.Error(t, err)
+	require.Nil(t, response)
+}
+
+func TestNATService_DeleteOutboundMapping(t *testing.T) {
+	data := mustReadFileString(t, "testdata/singleoutboundmapping.json")
+	server := setupTestServer(t, data)
+	defer server.Close()
+
+	newClient := NewClientWithNoAuth(server.URL)
+	response, err := newClient.NAT.DeleteOutboundMapping(context.Background(), 0)
+	require.NoError(t, err)
+	require.NotNil(t, response)
+
+	response, err = newClient.NAT.DeleteOutboundMapping(context.Background(), 0)
+	require.Error(t, err)
+	require.Nil(t, response)
+
+	response, err = newClient.NAT.DeleteOutboundMapping(context.Background(), 0)
+	require.Error(t, err)
+	require.Nil(t, response)
+}
+
+func TestNATService_CreateOutboundMapping(t *testing.T) {
+	data := mustReadFileString(t, "testdata/singleoutboundmapping.json")
+	server := setupTestServer(t, data)
+	defer server.Close()
+
+	newClient := NewClientWithNoAuth(server.URL)
+	target := optional.NewString("wan2_pool")
+	newMapping := OutboundMappingRequest{
+		Interface:   "wan2",
+		Source:      RuleAddress{Address: "10.0.20.0/24"},
+		Destination: RuleAddress{Address: "10.0.0.0/8", Not: true},
+		Target:      &target,
+		Poolopts:    PoolOptionRoundRobinStickyAddress,
+	}
+	response, err := newClient.NAT.CreateOutboundMapping(context.Background(), newMapping)
+	require.NoError(t, err)
+	require.NotNil(t, response)
+
+	response, err = newClient.NAT.CreateOutboundMapping(context.Background(), newMapping)
+	require.Error(t, err)
+	require.Nil(t, response)
+
+	response, err = newClient.NAT.CreateOutboundMapping(context.Background(), newMapping)
+	require.Error(t, err)
+	require.Nil(t, response)
+}
+
+func TestNATService_UpdateOutboundMapping(t *testing.T) {
+	data := mustReadFileString(t, "testdata/singleoutboundmapping.json")
+
+	var received map[string]any
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodPatch, r.Method)
+		require.Equal(t, "/"+natOutboundMappingEndpoint, r.URL.Path)
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		require.NoError(t, json.Unmarshal(body, &received))
+
+		w.Header().Set("Content-Type", "application/json")
+		_, err = io.WriteString(w, data)
+		require.NoError(t, err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(handler))
+	defer server.Close()
+
+	newClient := NewClientWithNoAuth(server.URL)
+	response, err := newClient.NAT.UpdateOutboundMapping(context.Background(), 1, OutboundMappingRequest{
+		Interface:     "wan",
+		Protocol:      ProtocolUDP,
+		Source:        RuleAddress{Address: "10.0.0.10/32"},
+		Destination:   AnyAddress,
+		StaticNatPort: true,
+	})
+	require.NoError(t, err)
+	require.NotNil(t, response)
+
+	require.Equal(t, float64(1), received["id"])
+	require.Equal(t, "udp", received["protocol"])
+	require.Equal(t, true, received["static_nat_port"])
+	require.NotContains(t, received, "target")
+	require.NotContains(t, received, "poolopts")
+}
+
+func TestNATService_ListOneToOneMappings(t *testing.T) {
+	data := mustReadFileString(t, "testdata/multipleonetoonemapping.json")
+	server := setupTestServer(t, data)
+	defer server.Close()
+
+	newClient := NewClientWithNoAuth(server.URL)
+	response, err := newClient.NAT.ListOneToOneMappings(context.Background())
+	require.NoError(t, err)
+	require.Len(t, response, 2)
+	require.Equal(t, "203.0.113.40", response[1].External)
+	require.Equal(t, RuleAddress{Address: "192.0.2.0/24", Not: true}, response[1].Destination)
+	require.True(t, response[1].Disabled)
+	require.Equal(t, NATReflectionDefault, response[1].Natreflection)
+
+	response, err = newClient.NAT.ListOneToOneMappings(context.Background())
+	require.Error(t, err)
+	require.Nil(t, response)
+
+	response, err = newClient.NAT.ListOneToOneMappings(context.Background())
+	require.Error(t, err)
+	require.Nil(t, response)
+}
+
+func TestNATService_GetOneToOneMapping(t *testing.T) {
+	data := mustReadFileString(t, "testdata/singleonetoonemapping.json")
+	server := setupTestServer(t, data)
+	defer server.Close()
+
+	newClient := NewClientWithNoAuth(server.URL)
+	response, err := newClient.NAT.GetOneToOneMapping(context.Background(), 0)
+	require.NoError(t, err)
+	require.Equal(t, "203.0.113.16", response.External)
+	require.Equal(t, RuleAddress{Address: "10.0.5.0/28"}, response.Source)
+	require.Equal(t, NATReflectionEnable, response.Natreflection)
+	require.Contains(t, response.Extra, "created_by")
+
+	response, err = newClient.NAT.GetOneToOneMapping(context.Background(), 0)
+	require.Error(t, err)
+	require.Nil(t, response)
+
+	response, err = newClient.NAT.GetOneToOneMapping(context.Background(), 0)
+	require.Error(t, err)
+	require.Nil(t, response)
+}
+
+func TestNATService_DeleteOneToOneMapping(t *testing.T) {
+	data := mustReadFileString(t, "testdata/singleonetoonemapping.json")
+	server := setupTestServer(t, data)
+	defer server.Close()
+
+	newClient := NewClientWithNoAuth(server.URL)
+	response, err := newClient.NAT.DeleteOneToOneMapping(context.Background(), 0)
+	require.NoError(t, err)
+	require.NotNil(t, response)
+
+	response, err = newClient.NAT.DeleteOneToOneMapping(context.Background(), 0)
+	require.Error(t, err)
+	require.Nil(t, response)
+
+	response, err = newClient.NAT.DeleteOneToOneMapping(context.Background(), 0)
+	require.Error(t, err)
+	require.Nil(t, response)
+}
+
+func TestNATService_CreateOneToOneMapping(t *testing.T) {
+	data := mustReadFileString(t, "testdata/singleonetoonemapping.json")
+	server := setupTestServer(t, data)
+	defer server.Close()
+
+	newClient := NewClientWithNoAuth(server.URL)
+	newMapping := OneToOneMappingRequest{
+		Interface:     "wan",
+		Ipprotocol:    IPProtocolInet,
+		External:      "203.0.113.16",
+		Source:        RuleAddress{Address: "10.0.5.0/28"},
+		Destination:   AnyAddress,
+		Natreflection: NATReflectionEnable,
+	}
+	response, err := newClient.NAT.CreateOneToOneMapping(context.Background(), newMapping)
+	require.NoError(t, err)
+	require.NotNil(t, response)
+
+	response, err = newClient.NAT.CreateOneToOneMapping(context.Background(), newMapping)
+	require.Error(t, err)
+	require.Nil(t, response)
+
+	response, err = newClient.NAT.CreateOneToOneMapping(context.Background(), newMapping)
+	require.Error(t, err)
+	require.Nil(t, response)
+}
+
+func TestNATService_UpdateOneToOneMapping(t *testing.T) {
+	data := mustReadFileString(t, "testdata/singleonetoonemapping.json")
+	server := setupTestServer(t, data)
+	defer server.Close()
+
+	newClient := NewClientWithNoAuth(server.URL)
+	mapping := OneToOneMappingRequest{
+		Interface:   "wan",
+		External:    "203.0.113.32",
+		Source:      RuleAddress{Address: "10.0.5.0/28"},
+		Destination: AnyAddress,
+	}
+	response, err := newClient.NAT.UpdateOneToOneMapping(context.Background(), 0, mapping)
+	require.NoError(t, err)
+	require.NotNil(t, response)
+
+	response, err = newClient.NAT.UpdateOneToOneMapping(context.Background(), 0, mapping)
+	require.Error(t, err)
+	require.Nil(t, response)
+
+	response, err = newClient.NAT.UpdateOneToOneMapping(context.Background(), 0, mapping)
+	require.Error(t, err)
+	require.Nil(t, response)
+}
@@ -0,0 +1,262 @@
+package pfsenseapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/markphelps/optional"
+)
+
+var (
+	// ErrRuleNotFound is returned when a RuleRef matches no firewall rule.
+	ErrRuleNotFound = errors.New("firewall rule not found")
+	// ErrRuleOrder is returned when the rule list read back after a reorder
+	// is not in the order that was written, e.g. because another client
+	// changed the rules at the same time.
+	ErrRuleOrder = errors.New("firewall rules are not in the requested order")
+)
+
+// RuleRef identifies a firewall rule by something that, unlike its Id, does
+// not change when the rules are reordered: its tracker, or failing that its
+// description, which must then be unique.
+type RuleRef struct {
+	Tracker int
+	Descr   string
+}
+
+// RuleByTracker refers to the rule with the given tracker.
+func RuleByTracker(tracker int) RuleRef {
+	return RuleRef{Tracker: tracker}
+}
+
+// RuleByDescr refers to the only rule with the given description.
+func RuleByDescr(descr string) RuleRef {
+	return RuleRef{Descr: descr}
+}
+
+func (r RuleRef) String() string {
+	if r.Tracker != 0 {
+		return fmt.Sprintf("tracker %d", r.Tracker)
+	}
+	return fmt.Sprintf("description %q", r.Descr)
+}
+
+// find returns the index of the rule r refers to.
+func (r RuleRef) find(rules []*FirewallRuleRequest) (int, error) {
+	found := -1
+	for i, rule := range rules {
+		if !r.matches(rule) {
+			continue
+		}
+		if found >= 0 {
+			return 0, fmt.Errorf("more than one firewall rule has %s", r)
+		}
+		found = i
+	}
+	if found < 0 {
+		return 0, fmt.Errorf("%w: %s", ErrRuleNotFound, r)
+	}
+	return found, nil
+}
+
+func (r RuleRef) matches(rule *FirewallRuleRequest) bool {
+	if r.Tracker != 0 {
+		return rule.Tracker != nil && rule.Tracker.OrElse(0) == r.Tracker
+	}
+	return rule.Descr != nil && rule.Descr.OrElse("") == r.Descr
+}
+
+// InsertRuleBefore creates a rule directly above the rule anchor refers to.
+func (s FirewallService) InsertRuleBefore(ctx context.Context, anchor RuleRef, rule FirewallRuleRequest) (*FirewallRule, error) {
+	return s.insertRule(ctx, anchor, 0, rule)
+}
+
+// InsertRuleAfter creates a rule directly below the rule anchor refers to.
+func (s FirewallService) InsertRuleAfter(ctx context.Context, anchor RuleRef, rule FirewallRuleRequest) (*FirewallRule, error) {
+	return s.insertRule(ctx, anchor, 1, rule)
+}
+
+func (s FirewallService) insertRule(ctx context.Context, anchor RuleRef, offset int, rule FirewallRuleRequest) (*FirewallRule, error) {
+	var at int
+	rules, err := s.rewriteRules(ctx, func(rules []*FirewallRuleRequest) ([]*FirewallRuleRequest, error) {
+		i, err := anchor.find(rules)
+		if err != nil {
+			return nil, err
+		}
+		at = i + offset
+		return insertAt(rules, at, &rule), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rules[at], nil
+}
+
+// MoveRuleBefore moves the rule ref refers to directly above the rule anchor
+// refers to, and returns the rules in their new order.
+func (s FirewallService) MoveRuleBefore(ctx context.Context, ref, anchor RuleRef) ([]*FirewallRule, error) {
+	return s.moveRule(ctx, ref, anchor, 0)
+}
+
+// MoveRuleAfter moves the rule ref refers to directly below the rule anchor
+// refers to, and returns the rules in their new order.
+func (s FirewallService) MoveRuleAfter(ctx context.Context, ref, anchor RuleRef) ([]*FirewallRule, error) {
+	return s.moveRule(ctx, ref, anchor, 1)
+}
+
+func (s FirewallService) moveRule(ctx context.Context, ref, anchor RuleRef, offset int) ([]*FirewallRule, error) {
+	return s.rewriteRules(ctx, func(rules []*FirewallRuleRequest) ([]*FirewallRuleRequest, error) {
+		from, err := ref.find(rules)
+		if err != nil {
+			return nil, err
+		}
+		to, err := anchor.find(rules)
+		if err != nil {
+			return nil, err
+		}
+		if from == to {
+			return nil, fmt.Errorf("cannot move the rule with %s relative to itself", ref)
+		}
+
+		rule := rules[from]
+		rules = append(rules[:from], rules[from+1:]...)
+		if to > from {
+			to--
+		}
+		return insertAt(rules, to+offset, rule), nil
+	})
+}
+
+// ReorderInterfaceRules puts the rules of an interface in the given order,
+// which must name each of them exactly once. Floating rules and the rules of
+// other interfaces keep their places. It returns all rules in their new
+// order.
+func (s FirewallService) ReorderInterfaceRules(ctx context.Context, iface string, order []RuleRef) ([]*FirewallRule, error) {
+	return s.rewriteRules(ctx, func(rules []*FirewallRuleRequest) ([]*FirewallRuleRequest, error) {
+		var slots []int
+		for i, rule := range rules {
+			if !rule.Floating && len(rule.Interface) == 1 && rule.Interface[0] == iface {
+				slots = append(slots, i)
+			}
+		}
+		if len(order) != len(slots) {
+			return nil, fmt.Errorf("interface %s has %d rules, but the order lists %d", iface, len(slots), len(order))
+		}
+
+		onInterface := make([]*FirewallRuleRequest, len(slots))
+		for i, slot := range slots {
+			onInterface[i] = rules[slot]
+		}
+
+		reordered := make([]*FirewallRuleRequest, len(rules))
+		copy(reordered, rules)
+		used := make(map[int]bool, len(order))
+		for i, ref := range order {
+			j, err := ref.find(onInterface)
+			if err != nil {
+				return nil, fmt.Errorf("%w on interface %s", err, iface)
+			}
+			if used[j] {
+				return nil, fmt.Errorf("the order lists %s more than once", ref)
+			}
+			used[j] = true
+			reordered[slots[i]] = onInterface[j]
+		}
+		return reordered, nil
+	})
+}
+
+// rewriteRules reads the rules, lets edit rearrange them, writes the result
+// back with a single PUT of the rule list and reads the rules again to check
+// they came out in the order written. The read and the write are not atomic,
+// so changes made by another client in between are lost or make the check
+// fail with ErrRuleOrder.
+func (s FirewallService) rewriteRules(ctx context.Context, edit func([]*FirewallRuleRequest) ([]*FirewallRuleRequest, error)) ([]*FirewallRule, error) {
+	current, err := s.ListRules(WithoutCache(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	rules := make([]*FirewallRuleRequest, len(current))
+	for i, rule := range current {
+		req := rule.FirewallRuleRequest
+		rules[i] = &req
+	}
+
+	want, err := edit(rules)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err = s.ReplaceAllRules(ctx, want); err != nil {
+		return nil, err
+	}
+
+	// nothing was written, so there is nothing to read back
+	if s.client.Cfg.DryRun {
+		planned := make([]*FirewallRule, len(want))
+		for i, rule := range want {
+			planned[i] = &FirewallRule{FirewallRuleRequest: *rule, Id: i}
+		}
+		return planned, nil
+	}
+
+	got, err := s.ListRules(WithoutCache(ctx))
+	if err != nil {
+		return nil, err
+	}
+	if err = checkRuleOrder(want, got); err != nil {
+		return nil, err
+	}
+	return got, nil
+}
+
+// checkRuleOrder compares the rules read back with the ones written. Rules
+// are compared by what they match and do rather than by tracker, since a new
+// rule only gets its tracker when it is written, and fields left empty in a
+// written rule are skipped since pfSense fills in their defaults.
+func checkRuleOrder(want []*FirewallRuleRequest, got []*FirewallRule) error {
+	if len(want) != len(got) {
+		return fmt.Errorf("%w: wrote %d rules, read back %d", ErrRuleOrder, len(want), len(got))
+	}
+	for i := range want {
+		wantKey, gotKey := ruleKey(want[i]), ruleKey(&got[i].FirewallRuleRequest)
+		for k := range wantKey {
+			if wantKey[k] != "" && wantKey[k] != gotKey[k] {
+				return fmt.Errorf("%w: rule %d differs from the one written", ErrRuleOrder, i)
+			}
+		}
+	}
+	return nil
+}
+
+// ruleKey lists the fields that tell rules apart.
+func ruleKey(r *FirewallRuleRequest) []string {
+	return []string{
+		string(r.Type),
+		strings.Join(r.Interface, ","),
+		string(r.Ipprotocol),
+		string(r.Protocol),
+		r.Source.String(),
+		optString(r.SourcePort),
+		r.Destination.String(),
+		optString(r.DestinationPort),
+		optString(r.Descr),
+	}
+}
+
+func optString(s *optional.String) string {
+	if s == nil {
+		return ""
+	}
+	return s.OrElse("")
+}
+
+func insertAt(rules []*FirewallRuleRequest, i int, rule *FirewallRuleRequest) []*FirewallRuleRequest {
+	rules = append(rules, nil)
+	copy(rules[i+1:], rules[i:])
+	rules[i] = rule
+	return rules
+}
@@ -0,0 +1,199 @@
+package pfsenseapi
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/markphelps/optional"
+	"github.com/stretchr/testify/require"
+)
+
+// ruleServer is a fake firewall holding a rule list. PUTs replace the list,
+// giving rules without a tracker a new one, unless ignoreOrder is set, in
+// which case the rules are stored in reverse.
+type ruleServer struct {
+	mu          sync.Mutex
+	rules       []map[string]any
+	nextTracker int
+	ignoreOrder bool
+	puts        int
+}
+
+func setupRuleServer(t *testing.T, descrs ...string) (*httptest.Server, *ruleServer) {
+	rs := &ruleServer{nextTracker: 1000}
+	for _, descr := range descrs {
+		rs.rules = append(rs.rules, map[string]any{
+			"type":        "pass",
+			"interface":   []any{"lan"},
+			"ipprotocol":  "inet",
+			"source":      "any",
+			"destination": "any",
+			"descr":       descr,
+			"tracker":     float64(rs.nextTracker),
+		})
+		rs.nextTracker++
+	}
+
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/"+firewallRulesEndpoint, r.URL.Path)
+
+		rs.mu.Lock()
+		defer rs.mu.Unlock()
+
+		if r.Method == http.MethodPut {
+			rs.puts++
+			body, err := io.ReadAll(r.Body)
+			require.NoError(t, err)
+
+			var rules []map[string]any
+			require.NoError(t, json.Unmarshal(body, &rules))
+			for _, rule := range rules {
+				if _, ok := rule["tracker"]; !ok {
+					rule["tracker"] = float64(rs.nextTracker)
+					rs.nextTracker++
+				}
+				if _, ok := rule["ipprotocol"]; !ok || rule["ipprotocol"] == "" {
+					rule["ipprotocol"] = "inet"
+				}
+			}
+			if rs.ignoreOrder {
+				for i, j := 0, len(rules)-1; i < j; i, j = i+1, j-1 {
+					rules[i], rules[j] = rules[j], rules[i]
+				}
+			}
+			rs.rules = rules
+		}
+
+		data := make([]map[string]any, len(rs.rules))
+		for i, rule := range rs.rules {
+			withID := map[string]any{"id": i}
+			for k, v := range rule {
+				withID[k] = v
+			}
+			data[i] = withID
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		require.NoError(t, json.NewEncoder(w).Encode(map[string]any{"code": 200, "status": "ok", "data": data}))
+	}
+	return httptest.NewServer(http.HandlerFunc(handler)), rs
+}
+
+func (rs *ruleServer) descrs() []string {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	descrs := make([]string, len(rs.rules))
+	for i, rule := range rs.rules {
+		descrs[i], _ = rule["descr"].(string)
+	}
+	return descrs
+}
+
+func newRuleRequest(iface, descr string) FirewallRuleRequest {
+	d := optional.NewString(descr)
+	return FirewallRuleRequest{
+		Type:        RuleTypeBlock,
+		Interface:   []string{iface},
+		Source:      AnyAddress,
+		Destination: AnyAddress,
+		Descr:       &d,
+	}
+}
+
+func TestFirewallService_InsertRule(t *testing.T) {
+	server, rs := setupRuleServer(t, "first", "second", "third")
+	defer server.Close()
+
+	newClient := NewClientWithNoAuth(server.URL)
+	rule, err := newClient.Firewall.InsertRuleBefore(context.Background(), RuleByDescr("second"), newRuleRequest("lan", "new"))
+	require.NoError(t, err)
+	require.Equal(t, 1, rule.Id)
+	require.Equal(t, "new", rule.Descr.MustGet())
+	require.Equal(t, 1003, rule.Tracker.MustGet())
+	require.Equal(t, []string{"first", "new", "second", "third"}, rs.descrs())
+
+	rule, err = newClient.Firewall.InsertRuleAfter(context.Background(), RuleByTracker(1002), newRuleRequest("lan", "last"))
+	require.NoError(t, err)
+	require.Equal(t, 4, rule.Id)
+	require.Equal(t, []string{"first", "new", "second", "third", "last"}, rs.descrs())
+
+	_, err = newClient.Firewall.InsertRuleAfter(context.Background(), RuleByDescr("missing"), newRuleRequest("lan", "x"))
+	require.ErrorIs(t, err, ErrRuleNotFound)
+	require.Equal(t, 2, rs.puts)
+}
+
+func TestFirewallService_MoveRule(t *testing.T) {
+	server, rs := setupRuleServer(t, "a", "b", "c", "d")
+	defer server.Close()
+
+	newClient := NewClientWithNoAuth(server.URL)
+	rules, err := newClient.Firewall.MoveRuleBefore(context.Background(), RuleByDescr("d"), RuleByDescr("b"))
+	require.NoError(t, err)
+	require.Len(t, rules, 4)
+	require.Equal(t, []string{"a", "d", "b", "c"}, rs.descrs())
+
+	_, err = newClient.Firewall.MoveRuleAfter(context.Background(), RuleByTracker(1000), RuleByDescr("c"))
+	require.NoError(t, err)
+	require.Equal(t, []string{"d", "b", "c", "a"}, rs.descrs())
+
+	_, err = newClient.Firewall.MoveRuleAfter(context.Background(), RuleByDescr("b"), RuleByDescr("b"))
+	require.Error(t, err)
+
+	// the firewall stored the rules in another order than written
+	rs.mu.Lock()
+	rs.ignoreOrder = true
+	rs.mu.Unlock()
+	_, err = newClient.Firewall.MoveRuleAfter(context.Background(), RuleByDescr("d"), RuleByDescr("a"))
+	require.ErrorIs(t, err, ErrRuleOrder)
+}
+
+func TestFirewallService_ReorderInterfaceRules(t *testing.T) {
+	server, rs := setupRuleServer(t, "lan1", "lan2", "lan3")
+	defer server.Close()
+
+	// interleave a WAN rule, which must stay where it is
+	rs.rules = append(rs.rules[:1], append([]map[string]any{{
+		"type": "block", "interface": []any{"wan"}, "ipprotocol": "inet",
+		"source": "any", "destination": "any", "descr": "wan1", "tracker": float64(2000),
+	}}, rs.rules[1:]...)...)
+
+	newClient := NewClientWithNoAuth(server.URL)
+	order := []RuleRef{RuleByDescr("lan3"), RuleByTracker(1000), RuleByDescr("lan2")}
+	rules, err := newClient.Firewall.ReorderInterfaceRules(context.Background(), "lan", order)
+	require.NoError(t, err)
+	require.Len(t, rules, 4)
+	require.Equal(t, []string{"lan3", "wan1", "lan1", "lan2"}, rs.descrs())
+
+	_, err = newClient.Firewall.ReorderInterfaceRules(context.Background(), "lan", order[:2])
+	require.Error(t, err)
+
+	_, err = newClient.Firewall.ReorderInterfaceRules(context.Background(), "lan", []RuleRef{
+		RuleByDescr("lan1"), RuleByDescr("lan1"), RuleByDescr("lan2"),
+	})
+	require.Error(t, err)
+
+	_, err = newClient.Firewall.ReorderInterfaceRules(context.Background(), "lan", []RuleRef{
+		RuleByDescr("lan1"), RuleByDescr("wan1"), RuleByDescr("lan2"),
+	})
+	require.ErrorIs(t, err, ErrRuleNotFound)
+	require.Equal(t, 1, rs.puts)
+}
+
+func TestFirewallService_MoveRuleDryRun(t *testing.T) {
+	server, rs := setupRuleServer(t, "a", "b")
+	defer server.Close()
+
+	newClient := NewClient(Config{Host: server.URL, DryRun: true})
+	rules, err := newClient.Firewall.MoveRuleAfter(context.Background(), RuleByDescr("a"), RuleByDescr("b"))
+	require.NoError(t, err)
+	require.Equal(t, "b", rules[0].Descr.MustGet())
+	require.Equal(t, "a", rules[1].Descr.MustGet())
+	require.Equal(t, []string{"a", "b"}, rs.descrs())
+	require.Len(t, newClient.Plan().Operations, 1)
+}
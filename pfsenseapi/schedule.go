@@ -0,0 +1,357 @@
+package pfsenseapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/markphelps/optional"
+)
+
+const (
+	firewallScheduleEndpoint  = "api/v2/firewall/schedule"
+	firewallSchedulesEndpoint = "api/v2/firewall/schedules"
+)
+
+// ClockTime is a time of day in minutes after midnight.
+type ClockTime int
+
+// Clock returns the time of day hour:minute.
+func Clock(hour, minute int) ClockTime {
+	return ClockTime(hour*60 + minute)
+}
+
+// EndOfDay is the end time pfSense uses for ranges that last until midnight.
+const EndOfDay = ClockTime(23*60 + 59)
+
+func (c ClockTime) String() string {
+	return fmt.Sprintf("%d:%02d", c/60, c%60)
+}
+
+// ParseClockTime parses a time of day such as "9:00" or "17:30".
+func ParseClockTime(s string) (ClockTime, error) {
+	hour, minute, ok := strings.Cut(strings.TrimSpace(s), ":")
+	if !ok {
+		return 0, fmt.Errorf("invalid time of day %q", s)
+	}
+	h, err := strconv.Atoi(hour)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time of day %q", s)
+	}
+	m, err := strconv.Atoi(minute)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time of day %q", s)
+	}
+	return Clock(h, m), nil
+}
+
+// ScheduleDate is a day of the year a schedule time range applies to. Like
+// in pfSense, it recurs every year.
+type ScheduleDate struct {
+	Month time.Month
+	Day   int
+}
+
+// ScheduleTimeRange is one time range of a schedule. It applies either on
+// the listed Weekdays every week, or on the listed Dates; in both cases from
+// Start until End. End is exclusive, except that EndOfDay runs until
+// midnight.
+type ScheduleTimeRange struct {
+	Weekdays []time.Weekday
+	Dates    []ScheduleDate
+	Start    ClockTime
+	End      ClockTime
+	Descr    string
+}
+
+// OnWeekdays returns a time range from start to end on each of the given
+// days of the week.
+func OnWeekdays(start, end ClockTime, days ...time.Weekday) ScheduleTimeRange {
+	return ScheduleTimeRange{Weekdays: days, Start: start, End: end}
+}
+
+// Weekdays returns a time range from start to end, Monday to Friday.
+func Weekdays(start, end ClockTime) ScheduleTimeRange {
+	return OnWeekdays(start, end, time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday)
+}
+
+// Weekends returns a time range from start to end on Saturday and Sunday.
+func Weekends(start, end ClockTime) ScheduleTimeRange {
+	return OnWeekdays(start, end, time.Saturday, time.Sunday)
+}
+
+// OnDates returns a time range from start to end on each of the given days
+// of the year.
+func OnDates(start, end ClockTime, dates ...ScheduleDate) ScheduleTimeRange {
+	return ScheduleTimeRange{Dates: dates, Start: start, End: end}
+}
+
+// Active reports whether t falls in the time range, going by the date and
+// time of day of t in its own location. Pass times in the firewall's time
+// zone.
+func (r ScheduleTimeRange) Active(t time.Time) bool {
+	now := Clock(t.Hour(), t.Minute())
+	if now < r.Start || now >= r.End && r.End != EndOfDay {
+		return false
+	}
+
+	for _, day := range r.Weekdays {
+		if day == t.Weekday() {
+			return true
+		}
+	}
+	for _, date := range r.Dates {
+		if date.Month == t.Month() && date.Day == t.Day() {
+			return true
+		}
+	}
+	return false
+}
+
+// scheduleTimeRangeJSON is a time range the way pfSense encodes it: comma
+// separated lists of weekdays, numbered from 1 for Monday to 7 for Sunday,
+// or of months and days, and the hours as "9:00-17:00".
+type scheduleTimeRangeJSON struct {
+	Position   string `json:"position,omitempty"`
+	Month      string `json:"month,omitempty"`
+	Day        string `json:"day,omitempty"`
+	Hour       string `json:"hour"`
+	Rangedescr string `json:"rangedescr"`
+}
+
+// MarshalJSON encodes the time range the way pfSense stores it.
+func (r ScheduleTimeRange) MarshalJSON() ([]byte, error) {
+	var position, months, days []string
+	for _, day := range r.Weekdays {
+		n := int(day)
+		if day == time.Sunday {
+			n = 7
+		}
+		position = append(position, strconv.Itoa(n))
+	}
+	for _, date := range r.Dates {
+		months = append(months, strconv.Itoa(int(date.Month)))
+		days = append(days, strconv.Itoa(date.Day))
+	}
+
+	return json.Marshal(scheduleTimeRangeJSON{
+		Position:   strings.Join(position, ","),
+		Month:      strings.Join(months, ","),
+		Day:        strings.Join(days, ","),
+		Hour:       r.Start.String() + "-" + r.End.String(),
+		Rangedescr: r.Descr,
+	})
+}
+
+// UnmarshalJSON decodes a time range from the way pfSense stores it.
+func (r *ScheduleTimeRange) UnmarshalJSON(data []byte) error {
+	var raw scheduleTimeRangeJSON
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	decoded := ScheduleTimeRange{Descr: raw.Rangedescr}
+	positions, err := splitInts(raw.Position)
+	if err != nil {
+		return fmt.Errorf("invalid schedule weekdays %q: %w", raw.Position, err)
+	}
+	for _, n := range positions {
+		decoded.Weekdays = append(decoded.Weekdays, time.Weekday(n%7))
+	}
+
+	months, err := splitInts(raw.Month)
+	if err != nil {
+		return fmt.Errorf("invalid schedule months %q: %w", raw.Month, err)
+	}
+	days, err := splitInts(raw.Day)
+	if err != nil {
+		return fmt.Errorf("invalid schedule days %q: %w", raw.Day, err)
+	}
+	if len(months) != len(days) {
+		return fmt.Errorf("schedule lists %d months but %d days", len(months), len(days))
+	}
+	for i := range months {
+		decoded.Dates = append(decoded.Dates, ScheduleDate{Month: time.Month(months[i]), Day: days[i]})
+	}
+
+	if raw.Hour != "" {
+		start, end, ok := strings.Cut(raw.Hour, "-")
+		if !ok {
+			return fmt.Errorf("invalid schedule hours %q", raw.Hour)
+		}
+		if decoded.Start, err = ParseClockTime(start); err != nil {
+			return err
+		}
+		if decoded.End, err = ParseClockTime(end); err != nil {
+			return err
+		}
+	}
+
+	*r = decoded
+	return nil
+}
+
+func splitInts(s string) ([]int, error) {
+	if s == "" {
+		return nil, nil
+	}
+	parts := strings.Split(s, ",")
+	ints := make([]int, len(parts))
+	for i, part := range parts {
+		n, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil {
+			return nil, err
+		}
+		ints[i] = n
+	}
+	return ints, nil
+}
+
+// Schedule represents a single firewall schedule. Active is set by pfSense
+// to whether the schedule is in effect at the time it was read.
+type Schedule struct {
+	ScheduleRequest
+	Id     int  `json:"id"`
+	Active bool `json:"active,omitempty"`
+}
+
+// ActiveAt reports whether the schedule is in effect at t. Pass times in the
+// firewall's time zone.
+func (r ScheduleRequest) ActiveAt(t time.Time) bool {
+	for _, timeRange := range r.Timerange {
+		if timeRange.Active(t) {
+			return true
+		}
+	}
+	return false
+}
+
+type scheduleListResponse struct {
+	apiResponse
+	Data flexList[*Schedule] `json:"data"`
+}
+
+// ListSchedules returns the firewall schedules.
+func (s FirewallService) ListSchedules(ctx context.Context) ([]*Schedule, error) {
+	response, err := s.client.get(ctx, firewallSchedulesEndpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := new(scheduleListResponse)
+	if err = s.client.decode(response, resp); err != nil {
+		return nil, fmt.Errorf("error unmarshalling response: %w", err)
+	}
+	return resp.Data, nil
+}
+
+// GetSchedule returns the schedule with the given ID.
+func (s FirewallService) GetSchedule(ctx context.Context, id int) (*Schedule, error) {
+	response, err := s.client.get(
+		ctx,
+		firewallScheduleEndpoint,
+		map[string]string{
+			"id": strconv.Itoa(id),
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := new(scheduleResponse)
+	if err = s.client.decode(response, resp); err != nil {
+		return nil, fmt.Errorf("error unmarshalling response: %w", err)
+	}
+	return resp.Data, nil
+}
+
+// DeleteSchedule deletes a schedule. pfSense refuses to delete a schedule
+// that rules still refer to.
+func (s FirewallService) DeleteSchedule(ctx context.Context, idToDelete int) (*Schedule, error) {
+	response, err := s.client.delete(
+		ctx,
+		firewallScheduleEndpoint,
+		map[string]string{
+			"id": strconv.Itoa(idToDelete),
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := new(scheduleResponse)
+	if err = s.client.decode(response, resp); err != nil {
+		return nil, fmt.Errorf("error unmarshalling response: %w", err)
+	}
+	return resp.Data, nil
+}
+
+// ScheduleRequest is a schedule as it is created or updated. Rules refer to
+// it by Name in their Sched field.
+type ScheduleRequest struct {
+	Name      string              `json:"name"`
+	Descr     *optional.String    `json:"descr,omitempty"`
+	Timerange []ScheduleTimeRange `json:"timerange"`
+
+	// Extra holds the fields this library does not model.
+	Extra map[string]json.RawMessage `json:"-"`
+}
+
+type scheduleResponse struct {
+	apiResponse
+	Data *Schedule `json:"data"`
+}
+
+// CreateSchedule creates a new schedule.
+func (s FirewallService) CreateSchedule(
+	ctx context.Context,
+	newSchedule ScheduleRequest,
+) (*Schedule, error) {
+	jsonData, err := json.Marshal(newSchedule)
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling request payload into json: %w", err)
+	}
+
+	response, err := s.client.post(ctx, firewallScheduleEndpoint, nil, jsonData)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := new(scheduleResponse)
+	if err = s.client.decode(response, resp); err != nil {
+		return nil, fmt.Errorf("error unmarshalling response: %w", err)
+	}
+	return resp.Data, nil
+}
+
+// UpdateSchedule modifies an existing schedule, replacing all of its time
+// ranges.
+func (s FirewallService) UpdateSchedule(
+	ctx context.Context,
+	idToUpdate int,
+	scheduleData ScheduleRequest,
+) (*Schedule, error) {
+	requestData := Schedule{
+		ScheduleRequest: scheduleData,
+		Id:              idToUpdate,
+	}
+
+	jsonData, err := json.Marshal(requestData)
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling request payload into json: %w", err)
+	}
+
+	response, err := s.client.patch(ctx, firewallScheduleEndpoint, nil, jsonData)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := new(scheduleResponse)
+	if err = s.client.decode(response, resp); err != nil {
+		return nil, fmt.Errorf("error unmarshalling response: %w", err)
+	}
+	return resp.Data, nil
+}
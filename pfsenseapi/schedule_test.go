@@ -0,0 +1,191 @@
+package pfsenseapi
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFirewallService_ListSchedules(t *testing.T) {
+	data := mustReadFileString(t, "testdata/multipleschedule.json")
+	server := setupTestServer(t, data)
+	defer server.Close()
+
+	newClient := NewClientWithNoAuth(server.URL)
+	response, err := newClient.Firewall.ListSchedules(context.Background())
+	require.NoError(t, err)
+	require.Len(t, response, 2)
+	require.True(t, response[0].Active)
+	require.Equal(t, []ScheduleDate{
+		{Month: time.December, Day: 24},
+		{Month: time.December, Day: 25},
+		{Month: time.January, Day: 1},
+	}, response[1].Timerange[0].Dates)
+	require.Equal(t, EndOfDay, response[1].Timerange[0].End)
+
+	response, err = newClient.Firewall.ListSchedules(context.Background())
+	require.Error(t, err)
+	require.Nil(t, response)
+
+	response, err = newClient.Firewall.ListSchedules(context.Background())
+	require.Error(t, err)
+	require.Nil(t, response)
+}
+
+func TestFirewallService_GetSchedule(t *testing.T) {
+	data := mustReadFileString(t, "testdata/singleschedule.json")
+	server := setupTestServer(t, data)
+	defer server.Close()
+
+	newClient := NewClientWithNoAuth(server.URL)
+	response, err := newClient.Firewall.GetSchedule(context.Background(), 0)
+	require.NoError(t, err)
+	require.Equal(t, "office_hours", response.Name)
+	require.Len(t, response.Timerange, 2)
+	require.Equal(t, ScheduleTimeRange{
+		Weekdays: []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday},
+		Start:    Clock(8, 30),
+		End:      Clock(18, 0),
+		Descr:    "Weekdays",
+	}, response.Timerange[0])
+	require.Contains(t, response.Extra, "schedlabel")
+
+	response, err = newClient.Firewall.GetSchedule(context.Background(), 0)
+	require.Error(t, err)
+	require.Nil(t, response)
+
+	response, err = newClient.Firewall.GetSchedule(context.Background(), 0)
+	require.Error(t, err)
+	require.Nil(t, response)
+}
+
+func TestFirewallService_DeleteSchedule(t *testing.T) {
+	data := mustReadFileString(t, "testdata/singleschedule.json")
+	server := setupTestServer(t, data)
+	defer server.Close()
+
+	newClient := NewClientWithNoAuth(server.URL)
+	response, err := newClient.Firewall.DeleteSchedule(context.Background(), 0)
+	require.NoError(t, err)
+	require.NotNil(t, response)
+
+	response, err = newClient.Firewall.DeleteSchedule(context.Background(), 0)
+	require.Error(t, err)
+	require.Nil(t, response)
+
+	response, err = newClient.Firewall.DeleteSchedule(context.Background(), 0)
+	require.Error(t, err)
+	require.Nil(t, response)
+}
+
+func TestFirewallService_CreateSchedule(t *testing.T) {
+	data := mustReadFileString(t, "testdata/singleschedule.json")
+	server := setupTestServer(t, data)
+	defer server.Close()
+
+	newClient := NewClientWithNoAuth(server.URL)
+	newSchedule := ScheduleRequest{
+		Name:      "office_hours",
+		Timerange: []ScheduleTimeRange{Weekdays(Clock(8, 30), Clock(18, 0))},
+	}
+	response, err := newClient.Firewall.CreateSchedule(context.Background(), newSchedule)
+	require.NoError(t, err)
+	require.NotNil(t, response)
+
+	response, err = newClient.Firewall.CreateSchedule(context.Background(), newSchedule)
+	require.Error(t, err)
+	require.Nil(t, response)
+
+	response, err = newClient.Firewall.CreateSchedule(context.Background(), newSchedule)
+	require.Error(t, err)
+	require.Nil(t, response)
+}
+
+func TestFirewallService_UpdateSchedule(t *testing.T) {
+	data := mustReadFileString(t, "testdata/singleschedule.json")
+	server := setupTestServer(t, data)
+	defer server.Close()
+
+	newClient := NewClientWithNoAuth(server.URL)
+	schedule := ScheduleRequest{
+		Name:      "office_hours",
+		Timerange: []ScheduleTimeRange{Weekends(Clock(10, 0), Clock(14, 0))},
+	}
+	response, err := newClient.Firewall.UpdateSchedule(context.Background(), 0, schedule)
+	require.NoError(t, err)
+	require.NotNil(t, response)
+
+	response, err = newClient.Firewall.UpdateSchedule(context.Background(), 0, schedule)
+	require.Error(t, err)
+	require.Nil(t, response)
+
+	response, err = newClient.Firewall.UpdateSchedule(context.Background(), 0, schedule)
+	require.Error(t, err)
+	require.Nil(t, response)
+}
+
+func TestScheduleTimeRange_JSON(t *testing.T) {
+	tests := []struct {
+		timeRange ScheduleTimeRange
+		json      string
+	}{
+		{
+			timeRange: Weekdays(Clock(9, 0), Clock(17, 0)),
+			json:      `{"position": "1,2,3,4,5", "hour": "9:00-17:00", "rangedescr": ""}`,
+		},
+		{
+			timeRange: OnWeekdays(Clock(0, 0), EndOfDay, time.Sunday),
+			json:      `{"position": "7", "hour": "0:00-23:59", "rangedescr": ""}`,
+		},
+		{
+			timeRange: ScheduleTimeRange{
+				Dates: []ScheduleDate{{Month: time.December, Day: 24}, {Month: time.December, Day: 31}},
+				Start: Clock(12, 15),
+				End:   EndOfDay,
+				Descr: "Early close",
+			},
+			json: `{"month": "12,12", "day": "24,31", "hour": "12:15-23:59", "rangedescr": "Early close"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		data, err := json.Marshal(tt.timeRange)
+		require.NoError(t, err)
+		require.JSONEq(t, tt.json, string(data))
+
+		var decoded ScheduleTimeRange
+		require.NoError(t, json.Unmarshal([]byte(tt.json), &decoded))
+		require.Equal(t, tt.timeRange, decoded)
+	}
+
+	var decoded ScheduleTimeRange
+	require.Error(t, json.Unmarshal([]byte(`{"month": "12,12", "day": "24", "hour": "0:00-23:59"}`), &decoded))
+	require.Error(t, json.Unmarshal([]byte(`{"position": "1", "hour": "9"}`), &decoded))
+}
+
+func TestSchedule_ActiveAt(t *testing.T) {
+	schedule := ScheduleRequest{
+		Name: "office_hours",
+		Timerange: []ScheduleTimeRange{
+			Weekdays(Clock(8, 30), Clock(18, 0)),
+			OnDates(Clock(0, 0), EndOfDay, ScheduleDate{Month: time.December, Day: 31}),
+		},
+	}
+
+	at := func(s string) time.Time {
+		parsed, err := time.Parse("2006-01-02 15:04", s)
+		require.NoError(t, err)
+		return parsed
+	}
+
+	require.True(t, schedule.ActiveAt(at("2024-03-04 08:30")))  // Monday
+	require.True(t, schedule.ActiveAt(at("2024-03-08 17:59")))  // Friday
+	require.False(t, schedule.ActiveAt(at("2024-03-08 18:00"))) // end is exclusive
+	require.False(t, schedule.ActiveAt(at("2024-03-04 08:29")))
+	require.False(t, schedule.ActiveAt(at("2024-03-09 12:00"))) // Saturday
+	require.True(t, schedule.ActiveAt(at("2023-12-31 23:59")))  // Sunday, but a listed date
+	require.False(t, schedule.ActiveAt(at("2024-01-01 00:00")))
+}
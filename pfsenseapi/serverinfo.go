@@ -0,0 +1,322 @@
+package pfsenseapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	restAPIVersionEndpoint = "api/v2/system/restapi/version"
+	systemVersionEndpoint  = "api/v2/system/version"
+)
+
+// ErrUnsupported is returned when the REST API package installed on the
+// firewall predates the endpoint a method needs.
+var ErrUnsupported = errors.New("not supported by the installed REST API package")
+
+// Capability is a group of endpoints that became available in a particular
+// REST API package version.
+type Capability string
+
+// Capabilities that can be checked with Supports.
+const (
+	CapabilityInterfaces        Capability = "interfaces"
+	CapabilityVLANs             Capability = "vlans"
+	CapabilityInterfaceGroups   Capability = "interface_groups"
+	CapabilityInterfaceBridges  Capability = "interface_bridges"
+	CapabilityUsers             Capability = "users"
+	CapabilityUserGroups        Capability = "user_groups"
+	CapabilityCARPStatus        Capability = "carp_status"
+	CapabilityGraphQL           Capability = "graphql"
+	CapabilityFirewallRules     Capability = "firewall_rules"
+	CapabilityFirewallAliases   Capability = "firewall_aliases"
+	CapabilityFirewallSchedules Capability = "firewall_schedules"
+	CapabilityNATPortForwards   Capability = "nat_port_forwards"
+	CapabilityNATOutbound       Capability = "nat_outbound"
+	CapabilityNATOneToOne       Capability = "nat_one_to_one"
+	CapabilityFirewallStates    Capability = "firewall_states"
+	CapabilityVirtualIPs        Capability = "virtual_ips"
+)
+
+// baseVersion is the first release of the v2 REST API package. Every
+// capability without an entry in capabilityVersions shipped in it.
+const baseVersion = "v2.0.0"
+
+// capabilityVersions is the REST API package version each capability added
+// after baseVersion first shipped in.
+var capabilityVersions = map[Capability]string{
+	CapabilityGraphQL: "v2.3.0",
+}
+
+// requiredVersion returns the REST API package version capability needs, or
+// false if the capability is not one this client knows.
+func requiredVersion(capability Capability) (string, bool) {
+	if version, ok := capabilityVersions[capability]; ok {
+		return version, true
+	}
+	for _, known := range endpointCapabilities {
+		if known == capability {
+			return baseVersion, true
+		}
+	}
+	return "", false
+}
+
+// endpointCapabilities maps each endpoint onto the capability it belongs to.
+var endpointCapabilities = map[string]Capability{
+	interfaceEndpoint:           CapabilityInterfaces,
+	interfacesEndpoint:          CapabilityInterfaces,
+	interfaceApplyEndpoint:      CapabilityInterfaces,
+	interfaceVLANEndpoint:       CapabilityVLANs,
+	interfaceVLANsEndpoint:      CapabilityVLANs,
+	interfaceGroupEndpoint:      CapabilityInterfaceGroups,
+	interfaceGroupsEndpoint:     CapabilityInterfaceGroups,
+	interfaceBridgeEndpoint:     CapabilityInterfaceBridges,
+	interfaceBridgesEndpoint:    CapabilityInterfaceBridges,
+	userEndpoint:                CapabilityUsers,
+	usersEndpoint:               CapabilityUsers,
+	groupEndpoint:               CapabilityUserGroups,
+	groupsEndpoint:              CapabilityUserGroups,
+	statusCARPEndpoint:          CapabilityCARPStatus,
+	graphQLEndpoint:             CapabilityGraphQL,
+	firewallRuleEndpoint:        CapabilityFirewallRules,
+	firewallRulesEndpoint:       CapabilityFirewallRules,
+	firewallApplyEndpoint:       CapabilityFirewallRules,
+	firewallAliasEndpoint:       CapabilityFirewallAliases,
+	firewallAliasesEndpoint:     CapabilityFirewallAliases,
+	firewallScheduleEndpoint:    CapabilityFirewallSchedules,
+	firewallSchedulesEndpoint:   CapabilityFirewallSchedules,
+	natPortForwardEndpoint:      CapabilityNATPortForwards,
+	natPortForwardsEndpoint:     CapabilityNATPortForwards,
+	natOutboundModeEndpoint:     CapabilityNATOutbound,
+	natOutboundMappingEndpoint:  CapabilityNATOutbound,
+	natOutboundMappingsEndpoint: CapabilityNATOutbound,
+	natOneToOneMappingEndpoint:  CapabilityNATOneToOne,
+	natOneToOneMappingsEndpoint: CapabilityNATOneToOne,
+	firewallStatesEndpoint:      CapabilityFirewallStates,
+	firewallStatesSizeEndpoint:  CapabilityFirewallStates,
+	virtualIPEndpoint:           CapabilityVirtualIPs,
+	virtualIPsEndpoint:          CapabilityVirtualIPs,
+	virtualIPApplyEndpoint:      CapabilityVirtualIPs,
+}
+
+// ServerInfo describes the software running on a firewall.
+type ServerInfo struct {
+	// RESTAPIVersion is the installed REST API package version, e.g.
+	// "v2.0.1". It is empty when the package predates the version endpoint.
+	RESTAPIVersion         string
+	LatestRESTAPIVersion   string
+	RESTAPIUpdateAvailable bool
+
+	PfSenseVersion   string
+	PfSenseBase      string
+	PfSensePatch     string
+	PfSenseBuildTime string
+}
+
+// Supports returns true if the installed REST API package provides the
+// capability.
+func (i *ServerInfo) Supports(capability Capability) bool {
+	required, ok := requiredVersion(capability)
+	if !ok || i.RESTAPIVersion == "" {
+		return false
+	}
+	return compareVersions(i.RESTAPIVersion, required) >= 0
+}
+
+type restAPIVersionResponse struct {
+	apiResponse
+	Data *struct {
+		CurrentVersion  string `json:"current_version"`
+		LatestVersion   string `json:"latest_version"`
+		UpdateAvailable bool   `json:"update_available"`
+	} `json:"data"`
+}
+
+type systemVersionResponse struct {
+	apiResponse
+	Data *struct {
+		Version   string `json:"version"`
+		Base      string `json:"base"`
+		Patch     string `json:"patch"`
+		BuildTime string `json:"buildtime"`
+	} `json:"data"`
+}
+
+// serverInfoRetryInterval is how long a failed ServerInfo lookup is
+// remembered before the firewall is asked again.
+const serverInfoRetryInterval = time.Minute
+
+// ServerInfo returns the REST API package and pfSense versions of the
+// firewall. The result is fetched once and cached on the Client. A failed
+// lookup is remembered for a minute, during which the same error is returned
+// without asking the firewall again. Callers arriving while a lookup is in
+// flight wait for its result, or until their own ctx is done.
+func (c *Client) ServerInfo(ctx context.Context) (*ServerInfo, error) {
+	for {
+		c.serverInfoMu.Lock()
+		info, err := c.serverInfo, c.serverInfoErr
+		if info != nil || err != nil && time.Since(c.serverInfoErrAt) < serverInfoRetryInterval {
+			c.serverInfoMu.Unlock()
+			return info, err
+		}
+
+		wait := c.serverInfoWait
+		if wait == nil {
+			c.serverInfoWait = make(chan struct{})
+			c.serverInfoMu.Unlock()
+			return c.lookupServerInfo(ctx)
+		}
+		c.serverInfoMu.Unlock()
+
+		select {
+		case <-wait:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// lookupServerInfo fetches the server info for ServerInfo, stores the result
+// and lets the callers waiting for it go.
+func (c *Client) lookupServerInfo(ctx context.Context) (*ServerInfo, error) {
+	info, err := c.fetchServerInfo(ctx)
+
+	c.serverInfoMu.Lock()
+	defer c.serverInfoMu.Unlock()
+	close(c.serverInfoWait)
+	c.serverInfoWait = nil
+
+	if err != nil {
+		// a lookup cut short by the caller says nothing about the firewall,
+		// so a waiting caller starts a new one
+		if ctx.Err() == nil {
+			c.serverInfoErr = err
+			c.serverInfoErrAt = time.Now()
+		}
+		return nil, err
+	}
+
+	c.serverInfo = info
+	c.serverInfoErr = nil
+	return info, nil
+}
+
+func (c *Client) fetchServerInfo(ctx context.Context) (*ServerInfo, error) {
+	info := new(ServerInfo)
+
+	response, err := c.get(ctx, restAPIVersionEndpoint, nil)
+	switch {
+	case errors.Is(err, ErrNotFound):
+		// the package predates the version endpoint, so it supports nothing
+		// this client knows about
+		return info, nil
+	case err != nil:
+		return nil, err
+	}
+
+	apiResp := new(restAPIVersionResponse)
+	if err = c.decode(response, apiResp); err != nil {
+		return nil, fmt.Errorf("error unmarshalling response: %w", err)
+	}
+	if apiResp.Data != nil {
+		info.RESTAPIVersion = apiResp.Data.CurrentVersion
+		info.LatestRESTAPIVersion = apiResp.Data.LatestVersion
+		info.RESTAPIUpdateAvailable = apiResp.Data.UpdateAvailable
+	}
+
+	response, err = c.get(ctx, systemVersionEndpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	sysResp := new(systemVersionResponse)
+	if err = c.decode(response, sysResp); err != nil {
+		return nil, fmt.Errorf("error unmarshalling response: %w", err)
+	}
+	if sysResp.Data != nil {
+		info.PfSenseVersion = sysResp.Data.Version
+		info.PfSenseBase = sysResp.Data.Base
+		info.PfSensePatch = sysResp.Data.Patch
+		info.PfSenseBuildTime = sysResp.Data.BuildTime
+	}
+	return info, nil
+}
+
+// Supports returns true if the firewall's REST API package provides the
+// capability.
+func (c *Client) Supports(ctx context.Context, capability Capability) (bool, error) {
+	info, err := c.ServerInfo(ctx)
+	if err != nil {
+		return false, err
+	}
+	return info.Supports(capability), nil
+}
+
+// unsupportedError is called when an endpoint returns 404. It returns an
+// ErrUnsupported error if the installed REST API package predates the
+// endpoint, or nil if the 404 has some other cause or the version cannot be
+// determined.
+func (c *Client) unsupportedError(ctx context.Context, endpoint string) error {
+	capability, ok := endpointCapabilities[strings.Trim(endpoint, "/")]
+	if !ok {
+		return nil
+	}
+
+	info, err := c.ServerInfo(ctx)
+	if err != nil || info.Supports(capability) {
+		return nil
+	}
+
+	installed := info.RESTAPIVersion
+	if installed == "" {
+		installed = "an unknown version"
+	}
+	required, _ := requiredVersion(capability)
+	return fmt.Errorf(
+		"%w: %s requires REST API %s or later, firewall runs %s: %w",
+		ErrUnsupported, endpoint, required, installed, ErrNotFound,
+	)
+}
+
+// compareVersions compares two versions of the form v1.2.3 and returns -1, 0
+// or 1. Missing or non-numeric parts compare as 0.
+func compareVersions(a, b string) int {
+	pa := versionParts(a)
+	pb := versionParts(b)
+	for i := 0; i < len(pa) || i < len(pb); i++ {
+		var x, y int
+		if i < len(pa) {
+			x = pa[i]
+		}
+		if i < len(pb) {
+			y = pb[i]
+		}
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+	}
+	return 0
+}
+
+func versionParts(v string) []int {
+	v = strings.TrimPrefix(strings.TrimSpace(v), "v")
+	if i := strings.IndexAny(v, "-+_ "); i >= 0 {
+		v = v[:i]
+	}
+
+	fields := strings.Split(v, ".")
+	parts := make([]int, 0, len(fields))
+	for _, field := range fields {
+		n, _ := strconv.Atoi(field)
+		parts = append(parts, n)
+	}
+	return parts
+}
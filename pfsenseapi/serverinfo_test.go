@@ -0,0 +1,167 @@
+package pfsenseapi
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// setupRoutedServer returns a server that answers each path with the file
+// given for it, and every other path with a 404.
+func setupRoutedServer(t *testing.T, routes map[string]string) *httptest.Server {
+	responses := make(map[string]string, len(routes))
+	for path, file := range routes {
+		responses[path] = mustReadFileString(t, file)
+	}
+	notFound := mustReadFileString(t, "testdata/error.json")
+
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		response, ok := responses[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			response = notFound
+		}
+		_, err := io.WriteString(w, response)
+		require.NoError(t, err)
+	}
+
+	return httptest.NewServer(http.HandlerFunc(handler))
+}
+
+func TestClient_ServerInfo(t *testing.T) {
+	server := setupRoutedServer(t, map[string]string{
+		"/" + restAPIVersionEndpoint: "testdata/restapiversion.json",
+		"/" + systemVersionEndpoint:  "testdata/systemversion.json",
+	})
+	defer server.Close()
+
+	newClient := NewClientWithNoAuth(server.URL)
+	info, err := newClient.ServerInfo(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "v2.0.1", info.RESTAPIVersion)
+	require.Equal(t, "2.7.2-RELEASE", info.PfSenseVersion)
+	require.True(t, info.Supports(CapabilityVLANs))
+	require.False(t, info.Supports(CapabilityGraphQL))
+	require.False(t, info.Supports(Capability("nonexistent")))
+
+	// endpoints the package supports keep returning plain 404s
+	vlan, err := newClient.Interface.GetVLAN(context.Background(), 99)
+	require.ErrorIs(t, err, ErrNotFound)
+	require.NotErrorIs(t, err, ErrUnsupported)
+	require.Nil(t, vlan)
+
+	// GraphQL came later than the installed package
+	err = newClient.GraphQL.Query(context.Background(), "{ ping }", nil, nil)
+	require.ErrorIs(t, err, ErrUnsupported)
+	require.ErrorContains(t, err, "requires REST API v2.3.0 or later, firewall runs v2.0.1")
+}
+
+func TestClient_ServerInfoUnsupported(t *testing.T) {
+	server := setupRoutedServer(t, nil)
+	defer server.Close()
+
+	newClient := NewClientWithNoAuth(server.URL)
+	supported, err := newClient.Supports(context.Background(), CapabilityVLANs)
+	require.NoError(t, err)
+	require.False(t, supported)
+
+	vlans, err := newClient.Interface.ListVLANs(context.Background())
+	require.ErrorIs(t, err, ErrUnsupported)
+	require.ErrorIs(t, err, ErrNotFound)
+	require.Nil(t, vlans)
+}
+
+func TestClient_ServerInfoFailureRemembered(t *testing.T) {
+	notFound := mustReadFileString(t, "testdata/error.json")
+
+	var lookups int
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/"+restAPIVersionEndpoint {
+			lookups++
+			w.WriteHeader(http.StatusInternalServerError)
+		} else {
+			w.WriteHeader(http.StatusNotFound)
+		}
+		_, err := io.WriteString(w, notFound)
+		require.NoError(t, err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(handler))
+	defer server.Close()
+
+	newClient := NewClientWithNoAuth(server.URL)
+	for i := 0; i < 3; i++ {
+		vlans, err := newClient.Interface.ListVLANs(context.Background())
+		require.ErrorIs(t, err, ErrNotFound)
+		require.NotErrorIs(t, err, ErrUnsupported)
+		require.Nil(t, vlans)
+	}
+	require.Equal(t, 1, lookups)
+
+	_, err := newClient.ServerInfo(context.Background())
+	require.Error(t, err)
+	require.Equal(t, 1, lookups)
+
+	// once the failure has aged out the firewall is asked again
+	newClient.serverInfoErrAt = newClient.serverInfoErrAt.Add(-serverInfoRetryInterval)
+	_, err = newClient.ServerInfo(context.Background())
+	require.Error(t, err)
+	require.Equal(t, 2, lookups)
+}
+
+func TestCompareVersions(t *testing.T) {
+	require.Equal(t, 0, compareVersions("v2.0.0", "2.0"))
+	require.Equal(t, -1, compareVersions("v1.7.6", "v2.0.0"))
+	require.Equal(t, 1, compareVersions("v2.1.0-rc1", "v2.0.9"))
+	require.Equal(t, 1, compareVersions("v2.0.10", "v2.0.9"))
+}
+
+func TestClient_ServerInfoWaitHonorsContext(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{}, 1)
+	var lookups atomic.Int32
+	restAPIVersion := mustReadFileString(t, "testdata/restapiversion.json")
+	systemVersion := mustReadFileString(t, "testdata/systemversion.json")
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		response := systemVersion
+		if r.URL.Path == "/"+restAPIVersionEndpoint {
+			lookups.Add(1)
+			started <- struct{}{}
+			<-release
+			response = restAPIVersion
+		}
+		_, err := io.WriteString(w, response)
+		require.NoError(t, err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(handler))
+	defer server.Close()
+
+	newClient := NewClientWithNoAuth(server.URL)
+	done := make(chan error, 1)
+	go func() {
+		_, err := newClient.ServerInfo(context.Background())
+		done <- err
+	}()
+	<-started
+
+	// a caller waiting on the lookup in flight can give up
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := newClient.ServerInfo(ctx)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	close(release)
+	require.NoError(t, <-done)
+	info, err := newClient.ServerInfo(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "v2.0.1", info.RESTAPIVersion)
+	require.Equal(t, int32(1), lookups.Load())
+}
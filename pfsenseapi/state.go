@@ -0,0 +1,170 @@
+package pfsenseapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/netip"
+)
+
+const (
+	firewallStatesEndpoint     = "api/v2/firewall/states"
+	firewallStatesSizeEndpoint = "api/v2/firewall/states/size"
+)
+
+// ErrEmptyStateFilter is returned by KillStates when the filter would match
+// every state. Flushing the whole state table also drops the connection the
+// request came in on.
+var ErrEmptyStateFilter = errors.New("refusing to kill states without a filter")
+
+// FirewallState is an entry of the firewall's state table. Source and
+// Destination hold the address and port the way pfctl prints them, e.g.
+// "192.0.2.10:51234" or "2001:db8::10[51234]".
+type FirewallState struct {
+	Id           int    `json:"id"`
+	Interface    string `json:"interface"`
+	Protocol     string `json:"protocol"`
+	Direction    string `json:"direction"`
+	Source       string `json:"source"`
+	Destination  string `json:"destination"`
+	State        string `json:"state"`
+	Age          string `json:"age"`
+	ExpiresIn    string `json:"expires_in"`
+	PacketsTotal int    `json:"packets_total"`
+	PacketsIn    int    `json:"packets_in"`
+	PacketsOut   int    `json:"packets_out"`
+	BytesTotal   int    `json:"bytes_total"`
+	BytesIn      int    `json:"bytes_in"`
+	BytesOut     int    `json:"bytes_out"`
+}
+
+// StateFilter selects states by the fields that are set; a state has to
+// match all of them. Source and Destination are addresses and match states
+// with that address on any port. Anything that does not parse as an address
+// is compared with the state's field as is.
+type StateFilter struct {
+	Interface   string
+	Protocol    string
+	Source      string
+	Destination string
+}
+
+func (f StateFilter) queryMap() map[string]string {
+	queryMap := make(map[string]string, 4)
+	if f.Interface != "" {
+		queryMap["interface"] = f.Interface
+	}
+	if f.Protocol != "" {
+		queryMap["protocol"] = f.Protocol
+	}
+	for field, value := range map[string]string{"source": f.Source, "destination": f.Destination} {
+		if value == "" {
+			continue
+		}
+		addr, err := netip.ParseAddr(value)
+		if err != nil {
+			queryMap[field] = value
+			continue
+		}
+		// pfctl separates the port with a colon for IPv4 and puts it in
+		// brackets for IPv6
+		addr = addr.Unmap()
+		if addr.Is4() {
+			queryMap[field+"__startswith"] = addr.String() + ":"
+		} else {
+			queryMap[field+"__startswith"] = addr.String() + "["
+		}
+	}
+	if len(queryMap) == 0 {
+		return nil
+	}
+	return queryMap
+}
+
+type firewallStateListResponse struct {
+	apiResponse
+	Data flexList[*FirewallState] `json:"data"`
+}
+
+// ListStates returns the states matching filter. The zero filter returns the
+// whole state table, which can be large; see IterStates.
+func (s FirewallService) ListStates(ctx context.Context, filter StateFilter) ([]*FirewallState, error) {
+	response, err := s.client.get(ctx, firewallStatesEndpoint, filter.queryMap())
+	if err != nil {
+		return nil, err
+	}
+
+	resp := new(firewallStateListResponse)
+	if err = s.client.decode(response, resp); err != nil {
+		return nil, fmt.Errorf("error unmarshalling response: %w", err)
+	}
+	return resp.Data, nil
+}
+
+// KillStates drops the states matching filter and returns them. The filter
+// must not be empty.
+func (s FirewallService) KillStates(ctx context.Context, filter StateFilter) ([]*FirewallState, error) {
+	queryMap := filter.queryMap()
+	if queryMap == nil {
+		return nil, ErrEmptyStateFilter
+	}
+	return deleteMany[FirewallState](ctx, s.client, firewallStatesEndpoint, DeleteQuery{Filters: queryMap})
+}
+
+// KillHostStates drops every state from or to addr. If killing the states
+// to addr fails, the states already killed from it are returned with the
+// error.
+func (s FirewallService) KillHostStates(ctx context.Context, addr netip.Addr) ([]*FirewallState, error) {
+	if !addr.IsValid() {
+		return nil, ErrEmptyStateFilter
+	}
+
+	killed, err := s.KillStates(ctx, StateFilter{Source: addr.String()})
+	if err != nil {
+		return nil, err
+	}
+
+	inbound, err := s.KillStates(ctx, StateFilter{Destination: addr.String()})
+	if err != nil {
+		return killed, err
+	}
+	return append(killed, inbound...), nil
+}
+
+// StateTableSize is the number of states in the state table and the most it
+// can hold.
+type StateTableSize struct {
+	Current int `json:"currentstates"`
+	Maximum int `json:"maximumstates"`
+	// DefaultMaximum is the limit pfSense uses when none is configured,
+	// which it derives from the amount of memory.
+	DefaultMaximum int `json:"defaultmaximumstates"`
+}
+
+// Usage returns the fraction of the state table in use.
+func (s *StateTableSize) Usage() float64 {
+	if s.Maximum == 0 {
+		return 0
+	}
+	return float64(s.Current) / float64(s.Maximum)
+}
+
+type stateTableSizeResponse struct {
+	apiResponse
+	Data *StateTableSize `json:"data"`
+}
+
+// GetStateTableSize returns the current size and the limit of the state
+// table.
+func (s FirewallService) GetStateTableSize(ctx context.Context) (*StateTableSize, error) {
+	response, err := s.client.get(ctx, firewallStatesSizeEndpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := new(stateTableSizeResponse)
+	if err = s.client.decode(response, resp); err != nil {
+		return nil, fmt.Errorf("error unmarshalling response: %w", err)
+	}
+	return resp.Data, nil
+}
@@ -0,0 +1,125 @@
+package pfsenseapi
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFirewallService_ListStates(t *testing.T) {
+	data := mustReadFileString(t, "testdata/multiplestate.json")
+	server := setupTestServer(t, data)
+	defer server.Close()
+
+	newClient := NewClientWithNoAuth(server.URL)
+	response, err := newClient.Firewall.ListStates(context.Background(), StateFilter{})
+	require.NoError(t, err)
+	require.Len(t, response, 2)
+	require.Equal(t, "192.168.1.50:51234", response[0].Source)
+	require.Equal(t, 1843022, response[0].BytesTotal)
+
+	response, err = newClient.Firewall.ListStates(context.Background(), StateFilter{})
+	require.Error(t, err)
+	require.Nil(t, response)
+
+	response, err = newClient.Firewall.ListStates(context.Background(), StateFilter{})
+	require.Error(t, err)
+	require.Nil(t, response)
+}
+
+func TestFirewallService_KillStates(t *testing.T) {
+	data := mustReadFileString(t, "testdata/multiplestate.json")
+	server := setupTestServer(t, data)
+	defer server.Close()
+
+	newClient := NewClientWithNoAuth(server.URL)
+	filter := StateFilter{Source: "192.168.1.50"}
+	response, err := newClient.Firewall.KillStates(context.Background(), filter)
+	require.NoError(t, err)
+	require.Len(t, response, 2)
+
+	response, err = newClient.Firewall.KillStates(context.Background(), filter)
+	require.Error(t, err)
+	require.Nil(t, response)
+
+	response, err = newClient.Firewall.KillStates(context.Background(), filter)
+	require.Error(t, err)
+	require.Nil(t, response)
+}
+
+func TestFirewallService_GetStateTableSize(t *testing.T) {
+	data := mustReadFileString(t, "testdata/statetablesize.json")
+	server := setupTestServer(t, data)
+	defer server.Close()
+
+	newClient := NewClientWithNoAuth(server.URL)
+	response, err := newClient.Firewall.GetStateTableSize(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, &StateTableSize{Current: 100000, Maximum: 400000, DefaultMaximum: 796000}, response)
+	require.Equal(t, 0.25, response.Usage())
+
+	response, err = newClient.Firewall.GetStateTableSize(context.Background())
+	require.Error(t, err)
+	require.Nil(t, response)
+
+	response, err = newClient.Firewall.GetStateTableSize(context.Background())
+	require.Error(t, err)
+	require.Nil(t, response)
+}
+
+func TestStateFilter_queryMap(t *testing.T) {
+	require.Nil(t, StateFilter{}.queryMap())
+
+	require.Equal(t, map[string]string{
+		"interface":               "igb1",
+		"protocol":                "tcp",
+		"source__startswith":      "192.168.1.50:",
+		"destination__startswith": "2001:db8::53[",
+	}, StateFilter{
+		Interface:   "igb1",
+		Protocol:    "tcp",
+		Source:      "::ffff:192.168.1.50",
+		Destination: "2001:db8::53",
+	}.queryMap())
+
+	require.Equal(t, map[string]string{
+		"destination": "203.0.113.7:443",
+	}, StateFilter{Destination: "203.0.113.7:443"}.queryMap())
+}
+
+func TestFirewallService_KillHostStatesRequests(t *testing.T) {
+	data := mustReadFileString(t, "testdata/multiplestate.json")
+
+	var queries []url.Values
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodDelete, r.Method)
+		queries = append(queries, r.URL.Query())
+		w.Header().Set("Content-Type", "application/json")
+		_, err := io.WriteString(w, data)
+		require.NoError(t, err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(handler))
+	defer server.Close()
+
+	newClient := NewClientWithNoAuth(server.URL)
+
+	_, err := newClient.Firewall.KillStates(context.Background(), StateFilter{})
+	require.ErrorIs(t, err, ErrEmptyStateFilter)
+	_, err = newClient.Firewall.KillHostStates(context.Background(), netip.Addr{})
+	require.ErrorIs(t, err, ErrEmptyStateFilter)
+	require.Empty(t, queries)
+
+	killed, err := newClient.Firewall.KillHostStates(context.Background(), netip.MustParseAddr("192.168.1.50"))
+	require.NoError(t, err)
+	require.Len(t, killed, 4)
+	require.Equal(t, []url.Values{
+		{"source__startswith": {"192.168.1.50:"}},
+		{"destination__startswith": {"192.168.1.50:"}},
+	}, queries)
+}
@@ -0,0 +1,78 @@
+package pfsenseapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+const (
+	statusCARPEndpoint = "api/v2/status/carp"
+)
+
+// StatusService provides status API methods
+type StatusService service
+
+// CARPStatus represents the CARP status of a firewall.
+type CARPStatus struct {
+	Enable          FlexBool         `json:"enable"`
+	MaintenanceMode FlexBool         `json:"maintenance_mode"`
+	VIPs            []*CARPVIPStatus `json:"vips"`
+}
+
+// CARPVIPStatus represents the CARP state of a single virtual IP.
+type CARPVIPStatus struct {
+	Interface  string  `json:"interface"`
+	VHID       FlexInt `json:"vhid"`
+	Subnet     string  `json:"subnet"`
+	SubnetBits FlexInt `json:"subnet_bits"`
+	Mode       string  `json:"mode"`
+	Status     string  `json:"status"`
+}
+
+// UnmarshalJSON decodes the status, taking the [] pfSense sends when CARP
+// has never been configured as an empty status.
+func (s *CARPStatus) UnmarshalJSON(data []byte) error {
+	if isEmptyArray(data) {
+		*s = CARPStatus{}
+		return nil
+	}
+	type carpStatusFields CARPStatus
+	return json.Unmarshal(data, (*carpStatusFields)(s))
+}
+
+// IsPrimary returns true if CARP is enabled, the firewall is not in
+// maintenance mode and it is master for every CARP virtual IP.
+func (s *CARPStatus) IsPrimary() bool {
+	if !s.Enable || s.MaintenanceMode || len(s.VIPs) == 0 {
+		return false
+	}
+
+	for _, vip := range s.VIPs {
+		if !strings.EqualFold(vip.Status, "master") {
+			return false
+		}
+	}
+	return true
+}
+
+type carpStatusResponse struct {
+	apiResponse
+	Data *CARPStatus `json:"data"`
+}
+
+// GetCARPStatus returns the CARP status of the firewall.
+func (s StatusService) GetCARPStatus(ctx context.Context) (*CARPStatus, error) {
+	response, err := s.client.get(ctx, statusCARPEndpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := new(carpStatusResponse)
+	if err = s.client.decode(response, resp); err != nil {
+		return nil, fmt.Errorf("error unmarshalling response: %w", err)
+	}
+
+	return resp.Data, nil
+}
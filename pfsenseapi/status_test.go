@@ -0,0 +1,29 @@
+package pfsenseapi
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatusService_GetCARPStatus(t *testing.T) {
+	data := mustReadFileString(t, "testdata/carpstatusmaster.json")
+	server := setupTestServer(t, data)
+	defer server.Close()
+
+	newClient := NewClientWithNoAuth(server.URL)
+	response, err := newClient.Status.GetCARPStatus(context.Background())
+	require.NoError(t, err)
+	require.NotNil(t, response)
+	require.Len(t, response.VIPs, 2)
+	require.True(t, response.IsPrimary())
+
+	response, err = newClient.Status.GetCARPStatus(context.Background())
+	require.Error(t, err)
+	require.Nil(t, response)
+
+	response, err = newClient.Status.GetCARPStatus(context.Background())
+	require.Error(t, err)
+	require.Nil(t, response)
+}
@@ -0,0 +1,315 @@
+package pfsenseapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// ListIterator yields the objects of a list response one at a time as they
+// are decoded from the response body, so that the whole list never has to be
+// held in memory. It is used like bufio.Scanner:
+//
+//	it, err := client.Interface.IterVLANs(ctx)
+//	if err != nil {
+//		return err
+//	}
+//	defer it.Close()
+//	for it.Next() {
+//		vlan := it.Value()
+//		...
+//	}
+//	return it.Err()
+//
+// Iterators read straight from the firewall and bypass the response cache.
+type ListIterator[T any] struct {
+	client *Client
+	body   io.ReadCloser
+	dec    *json.Decoder
+
+	// single holds the one object of a list collapsed into that object
+	single json.RawMessage
+
+	value *T
+	err   error
+	done  bool
+}
+
+// streamList makes a GET request to a list endpoint and returns an iterator
+// positioned at the first element of its data array.
+func streamList[T any](ctx context.Context, c *Client, endpoint string, queryMap map[string]string) (*ListIterator[T], error) {
+	res, err := c.do(ctx, http.MethodGet, endpoint, queryMap, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		defer func() {
+			_, _ = io.Copy(io.Discard, res.Body)
+			_ = res.Body.Close()
+		}()
+
+		respbody, err := io.ReadAll(res.Body)
+		if err != nil {
+			return nil, err
+		}
+		return nil, statusError(res.StatusCode, respbody)
+	}
+
+	it := &ListIterator[T]{
+		client: c,
+		body:   res.Body,
+		dec:    json.NewDecoder(res.Body),
+	}
+	if err = it.seekData(); err != nil {
+		_ = it.Close()
+		return nil, fmt.Errorf("error unmarshalling response: %w", err)
+	}
+	return it, nil
+}
+
+// seekData scans the response envelope up to the opening bracket of the data
+// array, skipping any other members on the way. Unless strict decoding is
+// set, data that is {}, "" or a single object is accepted the same way the
+// List methods accept it.
+func (it *ListIterator[T]) seekData() error {
+	if err := expectDelim(it.dec, '{'); err != nil {
+		return err
+	}
+
+	for it.dec.More() {
+		tok, err := it.dec.Token()
+		if err != nil {
+			return err
+		}
+		if key, _ := tok.(string); key != "data" {
+			var skip json.RawMessage
+			if err = it.dec.Decode(&skip); err != nil {
+				return err
+			}
+			continue
+		}
+
+		tok, err = it.dec.Token()
+		if err != nil {
+			return err
+		}
+		switch {
+		case tok == json.Delim('['):
+			return nil
+		case tok == nil:
+			it.done = true
+			return nil
+		case it.client.Cfg.StrictDecoding:
+		case tok == json.Delim('{'):
+			return it.readSingle()
+		case tok == "":
+			it.done = true
+			return nil
+		}
+		return fmt.Errorf("expected data to be an array, got %v", tok)
+	}
+
+	// no data member at all means an empty list
+	it.done = true
+	return nil
+}
+
+// readSingle reads the rest of a data object, after its opening brace, for
+// Next to return as the only item. An empty object is an empty list.
+func (it *ListIterator[T]) readSingle() error {
+	members := make(map[string]json.RawMessage)
+	for it.dec.More() {
+		tok, err := it.dec.Token()
+		if err != nil {
+			return err
+		}
+		key, _ := tok.(string)
+
+		var value json.RawMessage
+		if err = it.dec.Decode(&value); err != nil {
+			return err
+		}
+		members[key] = value
+	}
+	if err := expectDelim(it.dec, '}'); err != nil {
+		return err
+	}
+
+	if len(members) == 0 {
+		it.done = true
+		return nil
+	}
+	single, err := json.Marshal(members)
+	if err != nil {
+		return err
+	}
+	it.single = single
+	return nil
+}
+
+func expectDelim(dec *json.Decoder, delim json.Delim) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if tok != delim {
+		return fmt.Errorf("expected %v, got %v", delim, tok)
+	}
+	return nil
+}
+
+// Next decodes the next object and reports whether there was one. It returns
+// false at the end of the list or on an error, which Err then returns.
+func (it *ListIterator[T]) Next() bool {
+	if it.done || it.err != nil {
+		_ = it.Close()
+		return false
+	}
+
+	var raw json.RawMessage
+	switch {
+	case it.single != nil:
+		raw, it.single = it.single, nil
+		it.done = true
+	case !it.dec.More():
+		if err := it.finish(); err != nil {
+			it.err = fmt.Errorf("error unmarshalling response: %w", err)
+		}
+		_ = it.Close()
+		return false
+	default:
+		if err := it.dec.Decode(&raw); err != nil {
+			it.err = fmt.Errorf("error unmarshalling response: %w", err)
+			_ = it.Close()
+			return false
+		}
+	}
+
+	value := new(T)
+	if err := it.client.decode(raw, value); err != nil {
+		it.err = fmt.Errorf("error unmarshalling response: %w", err)
+		_ = it.Close()
+		return false
+	}
+	it.value = value
+	return true
+}
+
+// finish reads the rest of the response after the data array, so that a
+// truncated body is reported rather than silently ending the list.
+func (it *ListIterator[T]) finish() error {
+	it.done = true
+	if err := expectDelim(it.dec, ']'); err != nil {
+		return err
+	}
+
+	for it.dec.More() {
+		if _, err := it.dec.Token(); err != nil {
+			return err
+		}
+		var skip json.RawMessage
+		if err := it.dec.Decode(&skip); err != nil {
+			return err
+		}
+	}
+	return expectDelim(it.dec, '}')
+}
+
+// Value returns the object decoded by the last call to Next.
+func (it *ListIterator[T]) Value() *T {
+	return it.value
+}
+
+// Err returns the first error hit while iterating, if any.
+func (it *ListIterator[T]) Err() error {
+	return it.err
+}
+
+// Close releases the response body. It is called automatically once Next
+// returns false, and is safe to call more than once. Closing an iterator
+// before the end of the list drops the connection rather than reading the
+// rest of the body.
+func (it *ListIterator[T]) Close() error {
+	if it.body == nil {
+		return nil
+	}
+
+	body := it.body
+	it.body = nil
+	it.done = true
+	return body.Close()
+}
+
+// IterInterfaces returns an iterator over the interfaces.
+func (s InterfaceService) IterInterfaces(ctx context.Context) (*ListIterator[Interface], error) {
+	return streamList[Interface](ctx, s.client, interfacesEndpoint, nil)
+}
+
+// IterVLANs returns an iterator over the VLANs.
+func (s InterfaceService) IterVLANs(ctx context.Context) (*ListIterator[VLAN], error) {
+	return streamList[VLAN](ctx, s.client, interfaceVLANsEndpoint, nil)
+}
+
+// IterInterfaceGroups returns an iterator over the interface groups.
+func (s InterfaceService) IterInterfaceGroups(ctx context.Context) (*ListIterator[InterfaceGroup], error) {
+	return streamList[InterfaceGroup](ctx, s.client, interfaceGroupsEndpoint, nil)
+}
+
+// IterInterfaceBridges returns an iterator over the bridges.
+func (s InterfaceService) IterInterfaceBridges(ctx context.Context) (*ListIterator[InterfaceBridge], error) {
+	return streamList[InterfaceBridge](ctx, s.client, interfaceBridgesEndpoint, nil)
+}
+
+// IterUsers returns an iterator over the users.
+func (s *UserService) IterUsers(ctx context.Context) (*ListIterator[User], error) {
+	return streamList[User](ctx, s.client, usersEndpoint, nil)
+}
+
+// IterUserGroups returns an iterator over the user groups.
+func (s *UserService) IterUserGroups(ctx context.Context) (*ListIterator[UserGroup], error) {
+	return streamList[UserGroup](ctx, s.client, groupsEndpoint, nil)
+}
+
+// IterRules returns an iterator over the firewall rules.
+func (s FirewallService) IterRules(ctx context.Context) (*ListIterator[FirewallRule], error) {
+	return streamList[FirewallRule](ctx, s.client, firewallRulesEndpoint, nil)
+}
+
+// IterAliases returns an iterator over the firewall aliases.
+func (s FirewallService) IterAliases(ctx context.Context) (*ListIterator[Alias], error) {
+	return streamList[Alias](ctx, s.client, firewallAliasesEndpoint, nil)
+}
+
+// IterSchedules returns an iterator over the firewall schedules.
+func (s FirewallService) IterSchedules(ctx context.Context) (*ListIterator[Schedule], error) {
+	return streamList[Schedule](ctx, s.client, firewallSchedulesEndpoint, nil)
+}
+
+// IterStates returns an iterator over the states matching filter, decoding
+// them one at a time rather than holding the whole table in memory.
+func (s FirewallService) IterStates(ctx context.Context, filter StateFilter) (*ListIterator[FirewallState], error) {
+	return streamList[FirewallState](ctx, s.client, firewallStatesEndpoint, filter.queryMap())
+}
+
+// IterPortForwards returns an iterator over the NAT port forwards.
+func (s NATService) IterPortForwards(ctx context.Context) (*ListIterator[PortForward], error) {
+	return streamList[PortForward](ctx, s.client, natPortForwardsEndpoint, nil)
+}
+
+// IterOutboundMappings returns an iterator over the outbound NAT mappings.
+func (s NATService) IterOutboundMappings(ctx context.Context) (*ListIterator[OutboundMapping], error) {
+	return streamList[OutboundMapping](ctx, s.client, natOutboundMappingsEndpoint, nil)
+}
+
+// IterOneToOneMappings returns an iterator over the 1:1 NAT mappings.
+func (s NATService) IterOneToOneMappings(ctx context.Context) (*ListIterator[OneToOneMapping], error) {
+	return streamList[OneToOneMapping](ctx, s.client, natOneToOneMappingsEndpoint, nil)
+}
+
+// IterVirtualIPs returns an iterator over the virtual IPs.
+func (s VirtualIPService) IterVirtualIPs(ctx context.Context) (*ListIterator[VirtualIP], error) {
+	return streamList[VirtualIP](ctx, s.client, virtualIPsEndpoint, nil)
+}
@@ -0,0 +1,260 @@
+package pfsenseapi
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestInterfaceService_IterVLANs(t *testing.T) {
+	data := mustReadFileString(t, "testdata/multiplevlan.json")
+	server := setupTestServer(t, data)
+	defer server.Close()
+
+	newClient := NewClientWithNoAuth(server.URL)
+	it, err := newClient.Interface.IterVLANs(context.Background())
+	require.NoError(t, err)
+
+	var tags []int
+	for it.Next() {
+		tags = append(tags, it.Value().Tag)
+	}
+	require.NoError(t, it.Err())
+	require.NoError(t, it.Close())
+	require.Equal(t, []int{100, 200}, tags)
+
+	it, err = newClient.Interface.IterVLANs(context.Background())
+	require.ErrorIs(t, err, ErrBadRequest)
+	require.Nil(t, it)
+
+	// the body is cut off after the data array, which only shows at the end
+	it, err = newClient.Interface.IterVLANs(context.Background())
+	require.NoError(t, err)
+	for it.Next() {
+	}
+	require.Error(t, it.Err())
+}
+
+func TestUserService_IterUsers(t *testing.T) {
+	data := mustReadFileString(t, "testdata/multipleuser.json")
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		_, err := io.WriteString(w, data)
+		require.NoError(t, err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(handler))
+	defer server.Close()
+
+	newClient := NewClientWithNoAuth(server.URL)
+	users, err := newClient.User.ListUsers(context.Background())
+	require.NoError(t, err)
+
+	it, err := newClient.User.IterUsers(context.Background())
+	require.NoError(t, err)
+	defer it.Close()
+
+	var streamed []*User
+	for it.Next() {
+		streamed = append(streamed, it.Value())
+	}
+	require.NoError(t, it.Err())
+	require.Equal(t, users, streamed)
+}
+
+func TestListIterator_Envelope(t *testing.T) {
+	tests := []struct {
+		name  string
+		body  string
+		count int
+		err   bool
+	}{
+		{name: "data first", body: `{"data": [{"id": 0}, {"id": 1}], "code": 200}`, count: 2},
+		{name: "null data", body: `{"code": 200, "data": null}`},
+		{name: "no data", body: `{"code": 200}`},
+		{name: "empty array element", body: `{"data": [{"id": 0}, []]}`, count: 2},
+		{name: "bad element", body: `{"data": [{"id": 0}, {"id": "zero"}]}`, count: 1, err: true},
+		{name: "single object data", body: `{"data": {"id": 0}}`, count: 1},
+		{name: "empty object data", body: `{"data": {}}`},
+		{name: "empty string data", body: `{"data": ""}`},
+		{name: "scalar data", body: `{"data": 4}`, err: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := func(w http.ResponseWriter, r *http.Request) {
+				_, err := io.WriteString(w, tt.body)
+				require.NoError(t, err)
+			}
+			server := httptest.NewServer(http.HandlerFunc(handler))
+			defer server.Close()
+
+			newClient := NewClientWithNoAuth(server.URL)
+			it, err := newClient.Interface.IterVLANs(context.Background())
+			if err != nil {
+				require.True(t, tt.err, err)
+				return
+			}
+
+			count := 0
+			for it.Next() {
+				count++
+			}
+			require.Equal(t, tt.count, count)
+			require.Equal(t, tt.err, it.Err() != nil)
+		})
+	}
+}
+
+func TestListIterator_MatchesList(t *testing.T) {
+	bodies := []string{
+		`{"data": [{"id": 0, "tag": 10}, {"id": 1, "tag": "20"}]}`,
+		`{"data": {"id": 0, "tag": "10"}}`,
+		`{"data": {}}`,
+		`{"data": []}`,
+		`{"data": ""}`,
+		`{"data": null}`,
+	}
+
+	for _, strict := range []bool{false, true} {
+		for _, body := range bodies {
+			handler := func(w http.ResponseWriter, r *http.Request) {
+				_, err := io.WriteString(w, body)
+				require.NoError(t, err)
+			}
+			server := httptest.NewServer(http.HandlerFunc(handler))
+
+			newClient := NewClient(Config{Host: server.URL, StrictDecoding: strict})
+			listed, listErr := newClient.Interface.ListVLANs(context.Background())
+
+			var streamed []*VLAN
+			it, err := newClient.Interface.IterVLANs(context.Background())
+			if err == nil {
+				for it.Next() {
+					streamed = append(streamed, it.Value())
+				}
+				err = it.Err()
+			}
+			server.Close()
+
+			require.Equal(t, listErr != nil, err != nil, "strict %v, body %s", strict, body)
+			if listErr == nil {
+				require.Equal(t, len(listed), len(streamed), "strict %v, body %s", strict, body)
+			}
+		}
+	}
+}
+
+// largeVLANList returns a list response of n VLANs.
+func largeVLANList(n int) string {
+	var sb strings.Builder
+	sb.WriteString(`{"status": "ok", "code": 200, "return": 0, "message": "Success", "data": [`)
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		fmt.Fprintf(&sb,
+			`{"id": %d, "if": "igb%d", "tag": %d, "vlanif": "igb%d.%d", "pcp": %d, "descr": "VLAN %d for tenant network segment %d"}`,
+			i, i%8, i%4094+1, i%8, i%4094+1, i%8, i, i,
+		)
+	}
+	sb.WriteString(`]}`)
+	return sb.String()
+}
+
+// benchmarkVLANServer serves a list of 50000 VLANs, several megabytes of JSON.
+func benchmarkVLANServer(b *testing.B) *httptest.Server {
+	body := largeVLANList(50000)
+	b.SetBytes(int64(len(body)))
+
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, body)
+	}
+	return httptest.NewServer(http.HandlerFunc(handler))
+}
+
+// reportPeakHeap runs fn b.N times while sampling the heap, and reports the
+// highest heap growth seen above the level before the run.
+func reportPeakHeap(b *testing.B, fn func()) {
+	var stats runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&stats)
+	base := stats.HeapAlloc
+
+	var peak uint64
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				var stats runtime.MemStats
+				runtime.ReadMemStats(&stats)
+				if stats.HeapAlloc > peak {
+					peak = stats.HeapAlloc
+				}
+			}
+		}
+	}()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		fn()
+	}
+	b.StopTimer()
+	close(done)
+	wg.Wait()
+
+	if peak > base {
+		b.ReportMetric(float64(peak-base)/(1<<20), "peak-heap-MiB")
+	}
+}
+
+func BenchmarkListVLANs(b *testing.B) {
+	server := benchmarkVLANServer(b)
+	defer server.Close()
+	newClient := NewClientWithNoAuth(server.URL)
+
+	reportPeakHeap(b, func() {
+		vlans, err := newClient.Interface.ListVLANs(context.Background())
+		if err != nil {
+			b.Fatal(err)
+		}
+		for _, vlan := range vlans {
+			_ = vlan.Tag
+		}
+	})
+}
+
+func BenchmarkIterVLANs(b *testing.B) {
+	server := benchmarkVLANServer(b)
+	defer server.Close()
+	newClient := NewClientWithNoAuth(server.URL)
+
+	reportPeakHeap(b, func() {
+		it, err := newClient.Interface.IterVLANs(context.Background())
+		if err != nil {
+			b.Fatal(err)
+		}
+		for it.Next() {
+			_ = it.Value().Tag
+		}
+		if err = it.Err(); err != nil {
+			b.Fatal(err)
+		}
+	})
+}
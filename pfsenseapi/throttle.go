@@ -0,0 +1,172 @@
+package pfsenseapi
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// throttle holds back requests so that a small firewall is not overrun. Each
+// limit is optional and nil when it is not configured.
+type throttle struct {
+	limiter   *rateLimiter
+	inFlight  chan struct{}
+	mutations chan struct{}
+}
+
+// newThrottle returns the throttle for the limits in config, or nil when
+// none are set.
+func newThrottle(config Config) *throttle {
+	t := new(throttle)
+	if config.RateLimit > 0 {
+		t.limiter = newRateLimiter(config.RateLimit, config.RateBurst)
+	}
+	if config.MaxConcurrentRequests > 0 {
+		t.inFlight = make(chan struct{}, config.MaxConcurrentRequests)
+	}
+	if config.SerializeMutations {
+		t.mutations = make(chan struct{}, 1)
+	}
+
+	if t.limiter == nil && t.inFlight == nil && t.mutations == nil {
+		return nil
+	}
+	return t
+}
+
+// acquire waits until a request may be sent and returns the func that frees
+// the slots it holds. Mutations queue for their own lock before taking a
+// request slot, so a backlog of changes does not starve reads.
+func (t *throttle) acquire(ctx context.Context, mutation bool) (func(), error) {
+	var held []chan struct{}
+	release := func() {
+		for i := len(held) - 1; i >= 0; i-- {
+			<-held[i]
+		}
+	}
+
+	slots := []chan struct{}{t.inFlight}
+	if mutation {
+		slots = []chan struct{}{t.mutations, t.inFlight}
+	}
+	for _, slot := range slots {
+		if slot == nil {
+			continue
+		}
+		select {
+		case slot <- struct{}{}:
+			held = append(held, slot)
+		case <-ctx.Done():
+			release()
+			return nil, ctx.Err()
+		}
+	}
+
+	if t.limiter != nil {
+		if err := t.limiter.wait(ctx); err != nil {
+			release()
+			return nil, err
+		}
+	}
+	return release, nil
+}
+
+// rateLimiter is a token bucket. Callers that find it empty reserve a token
+// ahead of time and sleep until it is due, so they are served in the order
+// they arrived.
+type rateLimiter struct {
+	mu     sync.Mutex
+	rate   float64 // tokens per second
+	burst  float64
+	tokens float64 // negative while tokens are reserved
+	last   time.Time
+}
+
+func newRateLimiter(rate float64, burst int) *rateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &rateLimiter{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// wait takes a token, blocking until one is available or ctx is done.
+func (l *rateLimiter) wait(ctx context.Context) error {
+	l.mu.Lock()
+	now := time.Now()
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+	l.last = now
+
+	l.tokens--
+	if l.tokens >= 0 {
+		l.mu.Unlock()
+		return nil
+	}
+	delay := time.Duration(-l.tokens / l.rate * float64(time.Second))
+	l.mu.Unlock()
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		// hand the reserved token back to the callers behind us
+		l.mu.Lock()
+		l.tokens++
+		l.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+type throttleHeldKey struct{}
+
+// throttled sends a request through fn once the throttle lets it go. The
+// slots stay held until the response body is closed, since the firewall is
+// busy with the request until the response has been read. Requests made
+// while handling the response, such as the version lookup behind a 404,
+// run in the slot already held rather than waiting on it.
+func (c *Client) throttled(ctx context.Context, mutation bool, fn func(context.Context) (*http.Response, error)) (*http.Response, error) {
+	if c.throttle == nil {
+		return fn(ctx)
+	}
+	if held, _ := ctx.Value(throttleHeldKey{}).(bool); held {
+		return fn(ctx)
+	}
+
+	release, err := c.throttle.acquire(ctx, mutation)
+	if err != nil {
+		return nil, fmt.Errorf("error waiting to send request: %w", err)
+	}
+
+	res, err := fn(context.WithValue(ctx, throttleHeldKey{}, true))
+	if err != nil {
+		release()
+		return nil, err
+	}
+	res.Body = &releasingBody{ReadCloser: res.Body, release: release}
+	return res, nil
+}
+
+// releasingBody calls release the first time it is closed.
+type releasingBody struct {
+	io.ReadCloser
+	once    sync.Once
+	release func()
+}
+
+func (b *releasingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.release)
+	return err
+}
@@ -0,0 +1,190 @@
+package pfsenseapi
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// setupConcurrencyServer serves response after a short delay and tracks the
+// highest number of requests it handled at once, overall and per method.
+func setupConcurrencyServer(t *testing.T, response string) (*httptest.Server, func(method string) int32) {
+	var mu sync.Mutex
+	current := make(map[string]int32)
+	peak := make(map[string]int32)
+
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		for _, key := range []string{"", r.Method} {
+			current[key]++
+			if current[key] > peak[key] {
+				peak[key] = current[key]
+			}
+		}
+		mu.Unlock()
+
+		time.Sleep(20 * time.Millisecond)
+
+		mu.Lock()
+		current[""]--
+		current[r.Method]--
+		mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		_, err := io.WriteString(w, response)
+		require.NoError(t, err)
+	}
+
+	peakOf := func(method string) int32 {
+		mu.Lock()
+		defer mu.Unlock()
+		return peak[method]
+	}
+	return httptest.NewServer(http.HandlerFunc(handler)), peakOf
+}
+
+func TestThrottle_MaxConcurrentRequests(t *testing.T) {
+	data := mustReadFileString(t, "testdata/multiplevlan.json")
+	server, peak := setupConcurrencyServer(t, data)
+	defer server.Close()
+
+	newClient := NewClient(Config{Host: server.URL, MaxConcurrentRequests: 2})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := newClient.Interface.ListVLANs(context.Background())
+			require.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+	require.Equal(t, int32(2), peak(""))
+}
+
+func TestThrottle_SerializeMutations(t *testing.T) {
+	data := mustReadFileString(t, "testdata/singlevlan.json")
+	server, peak := setupConcurrencyServer(t, data)
+	defer server.Close()
+
+	newClient := NewClient(Config{Host: server.URL, SerializeMutations: true})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func(id int) {
+			defer wg.Done()
+			_, err := newClient.Interface.DeleteVLAN(context.Background(), id)
+			require.NoError(t, err)
+		}(i)
+		go func(id int) {
+			defer wg.Done()
+			_, err := newClient.Interface.GetVLAN(context.Background(), id)
+			require.NoError(t, err)
+		}(i)
+	}
+	wg.Wait()
+	require.Equal(t, int32(1), peak(http.MethodDelete))
+	require.Greater(t, peak(http.MethodGet), int32(1))
+}
+
+func TestThrottle_RateLimit(t *testing.T) {
+	data := mustReadFileString(t, "testdata/singlevlan.json")
+	var requests atomic.Int32
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		_, err := io.WriteString(w, data)
+		require.NoError(t, err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(handler))
+	defer server.Close()
+
+	newClient := NewClient(Config{Host: server.URL, RateLimit: 20, RateBurst: 2})
+
+	start := time.Now()
+	for i := 0; i < 6; i++ {
+		_, err := newClient.Interface.GetVLAN(context.Background(), i)
+		require.NoError(t, err)
+	}
+	// two requests go out at once, the other four 50ms apart
+	require.GreaterOrEqual(t, time.Since(start), 180*time.Millisecond)
+
+	// the bucket is empty, and the next token is not due before the deadline
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := newClient.Interface.GetVLAN(ctx, 1)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.Equal(t, int32(6), requests.Load())
+}
+
+func TestThrottle_SlotHeldUntilBodyClosed(t *testing.T) {
+	data := mustReadFileString(t, "testdata/multiplevlan.json")
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		_, err := io.WriteString(w, data)
+		require.NoError(t, err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(handler))
+	defer server.Close()
+
+	newClient := NewClient(Config{Host: server.URL, MaxConcurrentRequests: 1})
+	it, err := newClient.Interface.IterVLANs(context.Background())
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err = newClient.Interface.ListVLANs(ctx)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	require.NoError(t, it.Close())
+	_, err = newClient.Interface.ListVLANs(context.Background())
+	require.NoError(t, err)
+}
+
+func TestThrottle_NestedRequestReusesSlot(t *testing.T) {
+	server := setupRoutedServer(t, map[string]string{
+		"/" + restAPIVersionEndpoint: "testdata/restapiversion.json",
+		"/" + systemVersionEndpoint:  "testdata/systemversion.json",
+	})
+	defer server.Close()
+
+	// the 404 from an endpoint the package lacks looks up the version while
+	// the first request still holds the only slot
+	newClient := NewClient(Config{Host: server.URL, MaxConcurrentRequests: 1})
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	err := newClient.GraphQL.Query(ctx, "{ interfaces { id } }", nil, nil)
+	require.ErrorIs(t, err, ErrUnsupported)
+}
+
+func TestThrottle_PatchReleasesSlot(t *testing.T) {
+	data := mustReadFileString(t, "testdata/singlevlan.json")
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		_, err := io.WriteString(w, data)
+		require.NoError(t, err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(handler))
+	defer server.Close()
+
+	// every update is a PATCH, which must give its slot back like any other
+	// request or the next one waits forever
+	for _, config := range []Config{
+		{Host: server.URL, SerializeMutations: true},
+		{Host: server.URL, MaxConcurrentRequests: 1},
+	} {
+		newClient := NewClient(config)
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		for i := 0; i < 3; i++ {
+			_, err := newClient.Interface.UpdateVLAN(ctx, i, VLANRequest{If: "em1", Tag: 10 + i})
+			require.NoError(t, err)
+		}
+		cancel()
+	}
+}
@@ -21,27 +21,39 @@ type UserService service
 
 type User struct {
 	UserRequest
-	Id  int `json:"id"`
-	UID int `json:"uid"`
+	Id  int     `json:"id"`
+	UID FlexInt `json:"uid"`
 }
 
+// UserScope is the scope of a user. It is an alias of string, so
+// UserRequest.Scope takes both the constants below and plain strings.
+type UserScope = string
+
+const (
+	ScopeUser   UserScope = "user"
+	ScopeSystem UserScope = "system"
+)
+
 type UserRequest struct {
 	Name           string          `json:"name"`
 	Password       string          `json:"password"`
-	Scope          string          `json:"scope"`
-	Priv           []string        `json:"priv"`
-	Disabled       bool            `json:"disabled"`
+	Scope          UserScope       `json:"scope"`
+	Priv           FlexStringList  `json:"priv"`
+	Disabled       FlexBool        `json:"disabled"`
 	Descr          string          `json:"descr"`
 	Expires        optional.String `json:"expires"`
-	Cert           []string        `json:"cert"`
+	Cert           FlexStringList  `json:"cert"`
 	AuthorizedKeys optional.String `json:"authorizedkeys"`
 	IPSecPSK       optional.String `json:"ipsecpsk"`
+
+	// Extra holds the fields this library does not model.
+	Extra map[string]json.RawMessage `json:"-"`
 }
 
 // userListResponse is the response that contains multiple users.
 type userListResponse struct {
 	apiResponse
-	Data []*User `json:"data"`
+	Data flexList[*User] `json:"data"`
 }
 
 // ListUsers returns a list of users.
@@ -52,7 +64,7 @@ func (s *UserService) ListUsers(ctx context.Context) ([]*User, error) {
 	}
 
 	resp := new(userListResponse)
-	if err = json.Unmarshal(response, resp); err != nil {
+	if err = s.client.decode(response, resp); err != nil {
 		return nil, fmt.Errorf("error unmarshalling response: %w", err)
 	}
 
@@ -79,7 +91,7 @@ func (s *UserService) GetUser(ctx context.Context, id int) (*User, error) {
 	}
 
 	resp := new(userGetResponse)
-	if err = json.Unmarshal(response, resp); err != nil {
+	if err = s.client.decode(response, resp); err != nil {
 		return nil, fmt.Errorf("error unmarshalling response: %w", err)
 	}
 
@@ -99,7 +111,7 @@ func (s *UserService) CreateUser(ctx context.Context, newUser UserRequest) (*Use
 	}
 
 	resp := new(userGetResponse)
-	if err = json.Unmarshal(response, resp); err != nil {
+	if err = s.client.decode(response, resp); err != nil {
 		return nil, fmt.Errorf("error unmarshalling response: %w", err)
 	}
 
@@ -124,7 +136,7 @@ func (s *UserService) UpdateUser(ctx context.Context, id int, updatedUser UserRe
 	}
 
 	resp := new(userGetResponse)
-	if err = json.Unmarshal(response, resp); err != nil {
+	if err = s.client.decode(response, resp); err != nil {
 		return nil, fmt.Errorf("error unmarshalling response: %w", err)
 	}
 
@@ -145,7 +157,7 @@ func (s *UserService) DeleteUser(ctx context.Context, id int) (*User, error) {
 	}
 
 	resp := new(userGetResponse)
-	if err = json.Unmarshal(response, resp); err != nil {
+	if err = s.client.decode(response, resp); err != nil {
 		return nil, fmt.Errorf("error unmarshalling response: %w", err)
 	}
 
@@ -154,14 +166,14 @@ func (s *UserService) DeleteUser(ctx context.Context, id int) (*User, error) {
 
 type UserGroup struct {
 	UserGroupRequest
-	Id  int `json:"id"`
-	GID int `json:"gid"`
+	Id  int     `json:"id"`
+	GID FlexInt `json:"gid"`
 }
 
 // userGroupListResponse is the response that contains multiple user groups.
 type userGroupListResponse struct {
 	apiResponse
-	Data []*UserGroup `json:"data"`
+	Data flexList[*UserGroup] `json:"data"`
 }
 
 // userGroupGetResponse is the response that contains a single user group.
@@ -178,7 +190,7 @@ func (s *UserService) ListUserGroups(ctx context.Context) ([]*UserGroup, error)
 	}
 
 	resp := new(userGroupListResponse)
-	if err = json.Unmarshal(response, resp); err != nil {
+	if err = s.client.decode(response, resp); err != nil {
 		return nil, fmt.Errorf("error unmarshalling response: %w", err)
 	}
 
@@ -199,19 +211,32 @@ func (s *UserService) GetUserGroup(ctx context.Context, id int) (*UserGroup, err
 	}
 
 	resp := new(userGroupGetResponse)
-	if err = json.Unmarshal(response, resp); err != nil {
+	if err = s.client.decode(response, resp); err != nil {
 		return nil, fmt.Errorf("error unmarshalling response: %w", err)
 	}
 
 	return resp.Data, nil
 }
 
+// UserGroupScope is the scope of a user group, an alias of string like
+// UserScope.
+type UserGroupScope = string
+
+const (
+	GroupScopeLocal  UserGroupScope = "local"
+	GroupScopeRemote UserGroupScope = "remote"
+	GroupScopeSystem UserGroupScope = "system"
+)
+
 type UserGroupRequest struct {
-	Name        string   `json:"name"`
-	Scope       string   `json:"scope"`
-	Description string   `json:"description"`
-	Member      []string `json:"member"`
-	Priv        []string `json:"priv"`
+	Name        string         `json:"name"`
+	Scope       UserGroupScope `json:"scope"`
+	Description string         `json:"description"`
+	Member      FlexStringList `json:"member"`
+	Priv        FlexStringList `json:"priv"`
+
+	// Extra holds the fields this library does not model.
+	Extra map[string]json.RawMessage `json:"-"`
 }
 
 // CreateUserGroup creates a new user group.
@@ -227,7 +252,7 @@ func (s *UserService) CreateUserGroup(ctx context.Context, newUserGroup UserGrou
 	}
 
 	resp := new(userGroupGetResponse)
-	if err = json.Unmarshal(response, resp); err != nil {
+	if err = s.client.decode(response, resp); err != nil {
 		return nil, fmt.Errorf("error unmarshalling response: %w", err)
 	}
 
@@ -252,7 +277,7 @@ func (s *UserService) UpdateUserGroup(ctx context.Context, id int, updatedUserGr
 	}
 
 	resp := new(userGroupGetResponse)
-	if err = json.Unmarshal(response, resp); err != nil {
+	if err = s.client.decode(response, resp); err != nil {
 		return nil, fmt.Errorf("error unmarshalling response: %w", err)
 	}
 
@@ -273,29 +298,15 @@ func (s *UserService) DeleteUserGroup(ctx context.Context, id int) (*UserGroup,
 	}
 
 	resp := new(userGroupGetResponse)
-	if err = json.Unmarshal(response, resp); err != nil {
+	if err = s.client.decode(response, resp); err != nil {
 		return nil, fmt.Errorf("error unmarshalling response: %w", err)
 	}
 
 	return resp.Data, nil
 }
 
-// PutUserGroups replaces all user groups with the provided list.
+// PutUserGroups replaces all user groups with the provided list. It is
+// equivalent to ReplaceAllUserGroups.
 func (s *UserService) PutUserGroups(ctx context.Context, userGroups []*UserGroupRequest) ([]*UserGroup, error) {
-	jsonData, err := json.Marshal(userGroups)
-	if err != nil {
-		return nil, fmt.Errorf("error marshalling request payload into json: %w", err)
-	}
-
-	response, err := s.client.put(ctx, groupsEndpoint, nil, jsonData)
-	if err != nil {
-		return nil, err
-	}
-
-	resp := new(userGroupListResponse)
-	if err = json.Unmarshal(response, resp); err != nil {
-		return nil, fmt.Errorf("error unmarshalling response: %w", err)
-	}
-
-	return resp.Data, nil
+	return s.ReplaceAllUserGroups(ctx, userGroups)
 }
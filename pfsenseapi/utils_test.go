@@ -3,8 +3,15 @@ package pfsenseapi
 import (
 	"net/http"
 	"testing"
+
+	"github.com/markphelps/optional"
 )
 
+func optionalInt(v int) *optional.Int {
+	o := optional.NewInt(v)
+	return &o
+}
+
 func popSlice[K comparable](slice []K, s int) []K {
 	return append(slice[:s], slice[s+1:]...)
 }
@@ -0,0 +1,693 @@
+package pfsenseapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// APIVersion is the major version of the REST API package a firewall runs.
+type APIVersion string
+
+const (
+	// APIVersionV2 speaks the api/v2 endpoints of pfSense-pkg-RESTAPI 2.x.
+	APIVersionV2 APIVersion = "v2"
+	// APIVersionV1 maps InterfaceService and UserService calls onto the
+	// api/v1 endpoints of pfSense-API 1.x. Interface groups, bridges, user
+	// groups and the plural PUT endpoints have no v1 equivalent and return
+	// ErrUnsupported.
+	APIVersionV1 APIVersion = "v1"
+)
+
+const (
+	v1InterfaceEndpoint      = "api/v1/interface"
+	v1InterfaceApplyEndpoint = "api/v1/interface/apply"
+	v1InterfaceVLANEndpoint  = "api/v1/interface/vlan"
+	v1UserEndpoint           = "api/v1/user"
+)
+
+// v1Handler performs a v2 call against the v1 API and returns a response
+// shaped like the v2 one, so the services can decode it unchanged.
+type v1Handler func(ctx context.Context, c *Client, queryMap map[string]string, body []byte) (*http.Response, error)
+
+// v1HandlerFor returns the v1 handler of a v2 call, keyed "METHOD endpoint".
+func v1HandlerFor(call string) (v1Handler, bool) {
+	switch call {
+	case http.MethodGet + " " + interfacesEndpoint:
+		return v1ListInterfaces, true
+	case http.MethodGet + " " + interfaceEndpoint:
+		return v1GetInterface, true
+	case http.MethodPost + " " + interfaceEndpoint:
+		return v1CreateInterface, true
+	case http.MethodPatch + " " + interfaceEndpoint:
+		return v1UpdateInterface, true
+	case http.MethodDelete + " " + interfaceEndpoint:
+		return v1DeleteInterface, true
+	case http.MethodPost + " " + interfaceApplyEndpoint:
+		return v1ApplyInterfaces, true
+	case http.MethodGet + " " + interfaceVLANsEndpoint:
+		return v1ListVLANs, true
+	case http.MethodGet + " " + interfaceVLANEndpoint:
+		return v1GetVLAN, true
+	case http.MethodPost + " " + interfaceVLANEndpoint:
+		return v1CreateVLAN, true
+	case http.MethodPatch + " " + interfaceVLANEndpoint:
+		return v1UpdateVLAN, true
+	case http.MethodDelete + " " + interfaceVLANEndpoint:
+		return v1DeleteVLAN, true
+	case http.MethodGet + " " + usersEndpoint:
+		return v1ListUsers, true
+	case http.MethodGet + " " + userEndpoint:
+		return v1GetUser, true
+	case http.MethodPost + " " + userEndpoint:
+		return v1CreateUser, true
+	case http.MethodPatch + " " + userEndpoint:
+		return v1UpdateUser, true
+	case http.MethodDelete + " " + userEndpoint:
+		return v1DeleteUser, true
+	}
+	return nil, false
+}
+
+// doV1 performs a v2 call against a firewall running the v1 API.
+func (c *Client) doV1(ctx context.Context, method, endpoint string, queryMap map[string]string, body []byte) (*http.Response, error) {
+	handler, ok := v1HandlerFor(method + " " + strings.Trim(endpoint, "/"))
+	if !ok {
+		return nil, fmt.Errorf("%w: %s %s has no v1 equivalent", ErrUnsupported, method, endpoint)
+	}
+	return handler(ctx, c, queryMap, body)
+}
+
+// v1Envelope is a v1 response with its data left undecoded.
+type v1Envelope struct {
+	apiResponse
+	Data json.RawMessage `json:"data"`
+}
+
+// v1Call makes a v1 request. If the firewall returns an error the response is
+// returned as is, with a nil envelope, for the caller to pass on.
+func (c *Client) v1Call(ctx context.Context, method, endpoint string, queryMap map[string]string, body any) (*http.Response, *v1Envelope, error) {
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return nil, nil, fmt.Errorf("error marshalling request payload into json: %w", err)
+		}
+	}
+
+	res, err := c.send(ctx, method, endpoint, queryMap, payload)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() {
+		_, _ = io.Copy(io.Discard, res.Body)
+		_ = res.Body.Close()
+	}()
+
+	respbody, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return inMemoryResponse(res.StatusCode, respbody), nil, nil
+	}
+
+	env := new(v1Envelope)
+	if err = json.Unmarshal(respbody, env); err != nil {
+		return nil, nil, fmt.Errorf("error unmarshalling response: %w", err)
+	}
+	return nil, env, nil
+}
+
+// inMemoryResponse builds a response for the services to decode without a
+// round trip to the firewall.
+func inMemoryResponse(status int, body []byte) *http.Response {
+	return &http.Response{
+		StatusCode: status,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(bytes.NewReader(body)),
+	}
+}
+
+// v1Reply returns env with its data replaced by data.
+func v1Reply(env *v1Envelope, data any) (*http.Response, error) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+
+	env.Data = raw
+	out, err := json.Marshal(env)
+	if err != nil {
+		return nil, err
+	}
+	return inMemoryResponse(http.StatusOK, out), nil
+}
+
+// v1NotFound returns a 404 response, for lookups that v1 has to emulate.
+func v1NotFound(message string) (*http.Response, error) {
+	out, err := json.Marshal(apiResponse{
+		Status:  "not found",
+		Code:    http.StatusNotFound,
+		Message: message,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return inMemoryResponse(http.StatusNotFound, out), nil
+}
+
+// v1List fetches a v1 list endpoint whose data is either an array or an
+// object keyed by id. Objects keep the order the firewall sent them in, and
+// their keys are returned in keys.
+func (c *Client) v1List(ctx context.Context, endpoint string) (*http.Response, *v1Envelope, []string, []map[string]any, error) {
+	res, env, err := c.v1Call(ctx, http.MethodGet, endpoint, nil, nil)
+	if res != nil || err != nil {
+		return res, nil, nil, nil, err
+	}
+
+	keys, items, err := decodeV1Items(env.Data)
+	if err != nil {
+		return nil, nil, nil, nil, fmt.Errorf("error unmarshalling response: %w", err)
+	}
+	return nil, env, keys, items, nil
+}
+
+func decodeV1Items(data json.RawMessage) ([]string, []map[string]any, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 || bytes.Equal(data, []byte("null")) {
+		return nil, nil, nil
+	}
+
+	if data[0] == '[' {
+		var items []map[string]any
+		if err := json.Unmarshal(data, &items); err != nil {
+			return nil, nil, err
+		}
+		return nil, items, nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if _, err := dec.Token(); err != nil {
+		return nil, nil, err
+	}
+
+	var keys []string
+	var items []map[string]any
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, nil, err
+		}
+		key, _ := tok.(string)
+
+		item := make(map[string]any)
+		if err = dec.Decode(&item); err != nil {
+			return nil, nil, err
+		}
+		keys = append(keys, key)
+		items = append(items, item)
+	}
+	return keys, items, nil
+}
+
+// v1Body decodes a v2 request body into a map for translation.
+func v1Body(body []byte) (map[string]any, error) {
+	fields := make(map[string]any)
+	if len(body) == 0 {
+		return fields, nil
+	}
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return nil, fmt.Errorf("error unmarshalling request payload: %w", err)
+	}
+	return fields, nil
+}
+
+// v1Index parses the array index that v2 uses as the id of most objects.
+func v1Index(value any) (int, error) {
+	switch v := value.(type) {
+	case float64:
+		return int(v), nil
+	case string:
+		return strconv.Atoi(v)
+	default:
+		return 0, fmt.Errorf("invalid id %v", value)
+	}
+}
+
+// v1Number converts a numeric string from the v1 config into a number. Empty
+// strings are removed.
+func v1Number(fields map[string]any, key string) {
+	s, ok := fields[key].(string)
+	if !ok {
+		return
+	}
+	if s == "" {
+		delete(fields, key)
+		return
+	}
+	if n, err := strconv.ParseFloat(s, 64); err == nil {
+		fields[key] = n
+	}
+}
+
+// v1Flag converts a v1 config flag, which is true when present, into a
+// boolean.
+func v1Flag(fields map[string]any, key string) {
+	switch v := fields[key].(type) {
+	case nil:
+		fields[key] = false
+	case bool:
+	case string:
+		fields[key] = v != "false" && v != "0"
+	default:
+		fields[key] = true
+	}
+}
+
+var (
+	v4TypesToV1 = map[string]string{"static": "staticv4"}
+	v4TypesToV2 = map[string]string{"staticv4": "static"}
+)
+
+func interfaceFromV1(id string, fields map[string]any) map[string]any {
+	fields["id"] = id
+
+	v1Flag(fields, "enable")
+	for _, key := range []string{"subnet", "subnetv6", "mtu", "mss", "prefix_6rd_v4plen"} {
+		v1Number(fields, key)
+	}
+
+	// v1 create and update responses carry the type fields, while the stored
+	// config encodes the type in the address field
+	if t, ok := fields["type"].(string); ok {
+		if mapped, ok := v4TypesToV2[t]; ok {
+			t = mapped
+		}
+		fields["typev4"] = t
+	} else {
+		switch ipaddr, _ := fields["ipaddr"].(string); ipaddr {
+		case "":
+			fields["typev4"] = "none"
+		case "dhcp", "pppoe", "pptp", "l2tp":
+			fields["typev4"] = ipaddr
+			fields["ipaddr"] = ""
+		default:
+			fields["typev4"] = "static"
+		}
+	}
+
+	if t, ok := fields["type6"].(string); ok {
+		fields["typev6"] = t
+	} else {
+		switch ipaddr, _ := fields["ipaddrv6"].(string); ipaddr {
+		case "":
+			fields["typev6"] = "none"
+		case "dhcp6", "slaac", "6rd", "6to4", "track6":
+			fields["typev6"] = ipaddr
+			fields["ipaddrv6"] = ""
+		default:
+			fields["typev6"] = "staticv6"
+		}
+	}
+
+	delete(fields, "type")
+	delete(fields, "type6")
+	return fields
+}
+
+func interfaceToV1(fields map[string]any) map[string]any {
+	if t, ok := fields["typev4"].(string); ok {
+		if mapped, ok := v4TypesToV1[t]; ok {
+			t = mapped
+		}
+		fields["type"] = t
+	}
+	if t, ok := fields["typev6"]; ok {
+		fields["type6"] = t
+	}
+
+	delete(fields, "typev4")
+	delete(fields, "typev6")
+	return fields
+}
+
+func vlanFromV1(index int, fields map[string]any) map[string]any {
+	fields["id"] = index
+	v1Number(fields, "tag")
+	v1Number(fields, "pcp")
+	return fields
+}
+
+func userFromV1(index int, fields map[string]any) map[string]any {
+	fields["id"] = index
+	v1Number(fields, "uid")
+	v1Flag(fields, "disabled")
+
+	if hash, ok := fields["bcrypt-hash"]; ok {
+		fields["password"] = hash
+		delete(fields, "bcrypt-hash")
+	}
+	if _, ok := fields["name"]; !ok {
+		fields["name"] = fields["username"]
+	}
+	delete(fields, "username")
+	return fields
+}
+
+func userToV1(fields map[string]any) map[string]any {
+	fields["username"] = fields["name"]
+	delete(fields, "name")
+	return fields
+}
+
+// v1Single translates the single object in a v1 create or update response.
+func v1Single(env *v1Envelope, translate func(map[string]any) map[string]any) (*http.Response, error) {
+	fields, err := v1Object(env)
+	if err != nil {
+		return nil, err
+	}
+	return v1Reply(env, translate(fields))
+}
+
+// v1Object decodes the single object in a v1 create or update response.
+func v1Object(env *v1Envelope) (map[string]any, error) {
+	fields := make(map[string]any)
+	if len(env.Data) > 0 && env.Data[0] == '{' {
+		if err := json.Unmarshal(env.Data, &fields); err != nil {
+			return nil, fmt.Errorf("error unmarshalling response: %w", err)
+		}
+	}
+	return fields, nil
+}
+
+// v1IndexOf returns the v2 id of the object in a v1 list whose key field is
+// value. v1 create responses carry no id, so a new object is looked up by
+// its unique key once it has been created.
+func (c *Client) v1IndexOf(ctx context.Context, endpoint, key, value, kind string) (*http.Response, int, error) {
+	res, _, _, items, err := c.v1List(ctx, endpoint)
+	if res != nil || err != nil {
+		return res, 0, err
+	}
+
+	for i := len(items) - 1; i >= 0; i-- {
+		if v, _ := items[i][key].(string); v == value {
+			return nil, i, nil
+		}
+	}
+	return nil, 0, fmt.Errorf("created %s %q not found in the %s list", kind, value, endpoint)
+}
+
+func v1ListInterfaces(ctx context.Context, c *Client, _ map[string]string, _ []byte) (*http.Response, error) {
+	res, env, keys, items, err := c.v1List(ctx, v1InterfaceEndpoint)
+	if res != nil || err != nil {
+		return res, err
+	}
+
+	out := make([]map[string]any, 0, len(items))
+	for i, item := range items {
+		var id string
+		if i < len(keys) {
+			id = keys[i]
+		}
+		out = append(out, interfaceFromV1(id, item))
+	}
+	return v1Reply(env, out)
+}
+
+// v1FindInterface finds an interface by its pfSense ID, physical interface or
+// description, as the v2 API does.
+func v1FindInterface(ctx context.Context, c *Client, interfaceID string) (*http.Response, *v1Envelope, map[string]any, error) {
+	res, env, keys, items, err := c.v1List(ctx, v1InterfaceEndpoint)
+	if res != nil || err != nil {
+		return res, nil, nil, err
+	}
+
+	for i, item := range items {
+		if i < len(keys) && (keys[i] == interfaceID || item["if"] == interfaceID || item["descr"] == interfaceID) {
+			return nil, env, interfaceFromV1(keys[i], item), nil
+		}
+	}
+
+	res, err = v1NotFound(fmt.Sprintf("interface %q not found", interfaceID))
+	return res, nil, nil, err
+}
+
+func v1GetInterface(ctx context.Context, c *Client, queryMap map[string]string, _ []byte) (*http.Response, error) {
+	res, env, iface, err := v1FindInterface(ctx, c, queryMap["if"])
+	if res != nil || err != nil {
+		return res, err
+	}
+	return v1Reply(env, iface)
+}
+
+func v1CreateInterface(ctx context.Context, c *Client, _ map[string]string, body []byte) (*http.Response, error) {
+	fields, err := v1Body(body)
+	if err != nil {
+		return nil, err
+	}
+
+	res, env, err := c.v1Call(ctx, http.MethodPost, v1InterfaceEndpoint, nil, interfaceToV1(fields))
+	if res != nil || err != nil {
+		return res, err
+	}
+	return v1Single(env, func(fields map[string]any) map[string]any {
+		id, _ := fields["id"].(string)
+		return interfaceFromV1(id, fields)
+	})
+}
+
+func v1UpdateInterface(ctx context.Context, c *Client, _ map[string]string, body []byte) (*http.Response, error) {
+	fields, err := v1Body(body)
+	if err != nil {
+		return nil, err
+	}
+	id, _ := fields["id"].(string)
+
+	res, env, err := c.v1Call(ctx, http.MethodPut, v1InterfaceEndpoint, nil, interfaceToV1(fields))
+	if res != nil || err != nil {
+		return res, err
+	}
+	return v1Single(env, func(fields map[string]any) map[string]any {
+		return interfaceFromV1(id, fields)
+	})
+}
+
+func v1DeleteInterface(ctx context.Context, c *Client, queryMap map[string]string, _ []byte) (*http.Response, error) {
+	res, env, iface, err := v1FindInterface(ctx, c, queryMap["if"])
+	if res != nil || err != nil {
+		return res, err
+	}
+
+	res, env, err = c.v1Call(ctx, http.MethodDelete, v1InterfaceEndpoint, map[string]string{"if": iface["id"].(string)}, nil)
+	if res != nil || err != nil {
+		return res, err
+	}
+	return v1Reply(env, iface)
+}
+
+func v1ApplyInterfaces(ctx context.Context, c *Client, _ map[string]string, _ []byte) (*http.Response, error) {
+	res, env, err := c.v1Call(ctx, http.MethodPost, v1InterfaceApplyEndpoint, nil, nil)
+	if res != nil || err != nil {
+		return res, err
+	}
+	return v1Reply(env, env.Data)
+}
+
+func v1ListVLANs(ctx context.Context, c *Client, _ map[string]string, _ []byte) (*http.Response, error) {
+	res, env, _, items, err := c.v1List(ctx, v1InterfaceVLANEndpoint)
+	if res != nil || err != nil {
+		return res, err
+	}
+
+	out := make([]map[string]any, 0, len(items))
+	for i, item := range items {
+		out = append(out, vlanFromV1(i, item))
+	}
+	return v1Reply(env, out)
+}
+
+// v1FindByIndex looks up the object at the given v2 id in a v1 list.
+func v1FindByIndex(ctx context.Context, c *Client, endpoint string, id any, kind string) (*http.Response, *v1Envelope, int, map[string]any, error) {
+	index, err := v1Index(id)
+	if err != nil {
+		return nil, nil, 0, nil, err
+	}
+
+	res, env, _, items, err := c.v1List(ctx, endpoint)
+	if res != nil || err != nil {
+		return res, nil, 0, nil, err
+	}
+
+	if index < 0 || index >= len(items) {
+		res, err = v1NotFound(fmt.Sprintf("%s with id %d not found", kind, index))
+		return res, nil, 0, nil, err
+	}
+	return nil, env, index, items[index], nil
+}
+
+func v1GetVLAN(ctx context.Context, c *Client, queryMap map[string]string, _ []byte) (*http.Response, error) {
+	res, env, index, vlan, err := v1FindByIndex(ctx, c, v1InterfaceVLANEndpoint, queryMap["id"], "VLAN")
+	if res != nil || err != nil {
+		return res, err
+	}
+	return v1Reply(env, vlanFromV1(index, vlan))
+}
+
+func v1CreateVLAN(ctx context.Context, c *Client, _ map[string]string, body []byte) (*http.Response, error) {
+	fields, err := v1Body(body)
+	if err != nil {
+		return nil, err
+	}
+
+	res, env, err := c.v1Call(ctx, http.MethodPost, v1InterfaceVLANEndpoint, nil, fields)
+	if res != nil || err != nil {
+		return res, err
+	}
+
+	created, err := v1Object(env)
+	if err != nil {
+		return nil, err
+	}
+	vlanif, _ := created["vlanif"].(string)
+	if vlanif == "" {
+		vlanif = fmt.Sprintf("%v.%v", fields["if"], fields["tag"])
+	}
+	res, index, err := c.v1IndexOf(ctx, v1InterfaceVLANEndpoint, "vlanif", vlanif, "VLAN")
+	if res != nil || err != nil {
+		return res, err
+	}
+	return v1Reply(env, vlanFromV1(index, created))
+}
+
+func v1UpdateVLAN(ctx context.Context, c *Client, _ map[string]string, body []byte) (*http.Response, error) {
+	fields, err := v1Body(body)
+	if err != nil {
+		return nil, err
+	}
+
+	res, _, index, current, err := v1FindByIndex(ctx, c, v1InterfaceVLANEndpoint, fields["id"], "VLAN")
+	if res != nil || err != nil {
+		return res, err
+	}
+
+	delete(fields, "id")
+	fields["vlanif"] = current["vlanif"]
+
+	res, env, err := c.v1Call(ctx, http.MethodPut, v1InterfaceVLANEndpoint, nil, fields)
+	if res != nil || err != nil {
+		return res, err
+	}
+	return v1Single(env, func(fields map[string]any) map[string]any {
+		return vlanFromV1(index, fields)
+	})
+}
+
+func v1DeleteVLAN(ctx context.Context, c *Client, queryMap map[string]string, _ []byte) (*http.Response, error) {
+	res, env, index, current, err := v1FindByIndex(ctx, c, v1InterfaceVLANEndpoint, queryMap["id"], "VLAN")
+	if res != nil || err != nil {
+		return res, err
+	}
+
+	vlanif, _ := current["vlanif"].(string)
+	res, _, err = c.v1Call(ctx, http.MethodDelete, v1InterfaceVLANEndpoint, map[string]string{"vlanif": vlanif}, nil)
+	if res != nil || err != nil {
+		return res, err
+	}
+	return v1Reply(env, vlanFromV1(index, current))
+}
+
+func v1ListUsers(ctx context.Context, c *Client, _ map[string]string, _ []byte) (*http.Response, error) {
+	res, env, _, items, err := c.v1List(ctx, v1UserEndpoint)
+	if res != nil || err != nil {
+		return res, err
+	}
+
+	out := make([]map[string]any, 0, len(items))
+	for i, item := range items {
+		out = append(out, userFromV1(i, item))
+	}
+	return v1Reply(env, out)
+}
+
+func v1GetUser(ctx context.Context, c *Client, queryMap map[string]string, _ []byte) (*http.Response, error) {
+	res, env, index, user, err := v1FindByIndex(ctx, c, v1UserEndpoint, queryMap["id"], "user")
+	if res != nil || err != nil {
+		return res, err
+	}
+	return v1Reply(env, userFromV1(index, user))
+}
+
+func v1CreateUser(ctx context.Context, c *Client, _ map[string]string, body []byte) (*http.Response, error) {
+	fields, err := v1Body(body)
+	if err != nil {
+		return nil, err
+	}
+
+	name, _ := fields["name"].(string)
+	res, env, err := c.v1Call(ctx, http.MethodPost, v1UserEndpoint, nil, userToV1(fields))
+	if res != nil || err != nil {
+		return res, err
+	}
+
+	created, err := v1Object(env)
+	if err != nil {
+		return nil, err
+	}
+	if v, _ := created["name"].(string); v != "" {
+		name = v
+	}
+	res, index, err := c.v1IndexOf(ctx, v1UserEndpoint, "name", name, "user")
+	if res != nil || err != nil {
+		return res, err
+	}
+	return v1Reply(env, userFromV1(index, created))
+}
+
+func v1UpdateUser(ctx context.Context, c *Client, _ map[string]string, body []byte) (*http.Response, error) {
+	fields, err := v1Body(body)
+	if err != nil {
+		return nil, err
+	}
+
+	res, _, index, current, err := v1FindByIndex(ctx, c, v1UserEndpoint, fields["id"], "user")
+	if res != nil || err != nil {
+		return res, err
+	}
+
+	// v1 identifies the user to update by name, so it cannot rename one
+	if name, _ := fields["name"].(string); name != "" && name != current["name"] {
+		return nil, errors.New("renaming a user is not supported by the v1 API")
+	}
+	delete(fields, "id")
+	fields["name"] = current["name"]
+
+	res, env, err := c.v1Call(ctx, http.MethodPut, v1UserEndpoint, nil, userToV1(fields))
+	if res != nil || err != nil {
+		return res, err
+	}
+	return v1Single(env, func(fields map[string]any) map[string]any {
+		return userFromV1(index, fields)
+	})
+}
+
+func v1DeleteUser(ctx context.Context, c *Client, queryMap map[string]string, _ []byte) (*http.Response, error) {
+	res, env, index, current, err := v1FindByIndex(ctx, c, v1UserEndpoint, queryMap["id"], "user")
+	if res != nil || err != nil {
+		return res, err
+	}
+
+	name, _ := current["name"].(string)
+	res, _, err = c.v1Call(ctx, http.MethodDelete, v1UserEndpoint, map[string]string{"username": name}, nil)
+	if res != nil || err != nil {
+		return res, err
+	}
+	return v1Reply(env, userFromV1(index, current))
+}
@@ -0,0 +1,285 @@
+package pfsenseapi
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/markphelps/optional"
+	"github.com/stretchr/testify/require"
+)
+
+// v1Request is a request received by the server of setupV1Server.
+type v1Request struct {
+	Method string
+	Path   string
+	Query  map[string]string
+	Body   map[string]any
+}
+
+// setupV1Server returns a server that answers each "METHOD path" with the
+// file given for it, and every other request with a 404. The requests it
+// received are returned by the second return value.
+func setupV1Server(t *testing.T, routes map[string]string) (*httptest.Server, func() []v1Request) {
+	responses := make(map[string]string, len(routes))
+	for route, file := range routes {
+		responses[route] = mustReadFileString(t, file)
+	}
+	notFound := mustReadFileString(t, "testdata/error.json")
+
+	var mu sync.Mutex
+	var requests []v1Request
+
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		req := v1Request{Method: r.Method, Path: r.URL.Path, Query: map[string]string{}}
+		for key := range r.URL.Query() {
+			req.Query[key] = r.URL.Query().Get(key)
+		}
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		if len(body) > 0 {
+			require.NoError(t, json.Unmarshal(body, &req.Body))
+		}
+
+		mu.Lock()
+		requests = append(requests, req)
+		mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		response, ok := responses[r.Method+" "+r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			response = notFound
+		}
+		_, err = io.WriteString(w, response)
+		require.NoError(t, err)
+	}
+
+	received := func() []v1Request {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]v1Request(nil), requests...)
+	}
+	return httptest.NewServer(http.HandlerFunc(handler)), received
+}
+
+func newV1Client(host string) *Client {
+	return NewClient(Config{Host: host, APIVersion: APIVersionV1})
+}
+
+func TestV1_Interfaces(t *testing.T) {
+	ctx := context.Background()
+	server, received := setupV1Server(t, map[string]string{
+		"GET /" + v1InterfaceEndpoint:    "testdata/v1interfaces.json",
+		"POST /" + v1InterfaceEndpoint:   "testdata/v1interface.json",
+		"PUT /" + v1InterfaceEndpoint:    "testdata/v1interface.json",
+		"DELETE /" + v1InterfaceEndpoint: "testdata/v1interface.json",
+	})
+	defer server.Close()
+	newClient := newV1Client(server.URL)
+
+	interfaces, err := newClient.Interface.ListInterfaces(ctx)
+	require.NoError(t, err)
+	require.Len(t, interfaces, 3)
+	require.Equal(t, "wan", interfaces[0].Id)
+	require.Equal(t, Typev4DHCP, interfaces[0].Typev4)
+	require.Equal(t, Typev6DHCP6, interfaces[0].Typev6.MustGet())
+	require.True(t, interfaces[0].Enable.MustGet())
+	require.Nil(t, interfaces[0].Mtu)
+	require.Equal(t, "lan", interfaces[1].Id)
+	require.Equal(t, Typev4Static, interfaces[1].Typev4)
+	require.Equal(t, FlexInt32(24), interfaces[1].Subnet)
+	require.Equal(t, int32(1500), interfaces[1].Mtu.MustGet())
+	require.Equal(t, Typev4None, interfaces[2].Typev4)
+	require.False(t, interfaces[2].Enable.MustGet())
+
+	iface, err := newClient.Interface.GetInterface(ctx, "em1")
+	require.NoError(t, err)
+	require.Equal(t, "lan", iface.Id)
+
+	_, err = newClient.Interface.GetInterface(ctx, "em9")
+	require.ErrorIs(t, err, ErrNotFound)
+
+	created, err := newClient.Interface.CreateInterface(ctx, InterfaceRequest{
+		If:     "em3",
+		Descr:  "Lab",
+		Typev4: Typev4Static,
+		Ipaddr: "10.0.0.1",
+		Subnet: 24,
+	})
+	require.NoError(t, err)
+	require.Equal(t, Typev4Static, created.Typev4)
+	requests := received()
+	last := requests[len(requests)-1]
+	require.Equal(t, http.MethodPost, last.Method)
+	require.Equal(t, "staticv4", last.Body["type"])
+	require.NotContains(t, last.Body, "typev4")
+
+	updated, err := newClient.Interface.UpdateInterface(ctx, "opt2", InterfaceRequest{If: "em3", Typev4: Typev4DHCP})
+	require.NoError(t, err)
+	require.Equal(t, "opt2", updated.Id)
+	requests = received()
+	last = requests[len(requests)-1]
+	require.Equal(t, http.MethodPut, last.Method)
+	require.Equal(t, "opt2", last.Body["id"])
+	require.Equal(t, "dhcp", last.Body["type"])
+
+	deleted, err := newClient.Interface.DeleteInterface(ctx, "DMZ")
+	require.NoError(t, err)
+	require.Equal(t, "opt1", deleted.Id)
+	requests = received()
+	last = requests[len(requests)-1]
+	require.Equal(t, http.MethodDelete, last.Method)
+	require.Equal(t, "opt1", last.Query["if"])
+}
+
+func TestV1_VLANs(t *testing.T) {
+	ctx := context.Background()
+	server, received := setupV1Server(t, map[string]string{
+		"GET /" + v1InterfaceVLANEndpoint:    "testdata/v1vlans.json",
+		"POST /" + v1InterfaceVLANEndpoint:   "testdata/v1vlan.json",
+		"PUT /" + v1InterfaceVLANEndpoint:    "testdata/v1vlan.json",
+		"DELETE /" + v1InterfaceVLANEndpoint: "testdata/v1vlan.json",
+	})
+	defer server.Close()
+	newClient := newV1Client(server.URL)
+
+	vlans, err := newClient.Interface.ListVLANs(ctx)
+	require.NoError(t, err)
+	require.Len(t, vlans, 2)
+	require.Equal(t, 1, vlans[1].Id)
+	require.Equal(t, 20, vlans[1].Tag)
+
+	vlan, err := newClient.Interface.GetVLAN(ctx, 0)
+	require.NoError(t, err)
+	require.Equal(t, "Servers", vlan.Descr.MustGet())
+
+	_, err = newClient.Interface.GetVLAN(ctx, 5)
+	require.ErrorIs(t, err, ErrNotFound)
+
+	desc := optional.NewString("Phones")
+	_, err = newClient.Interface.UpdateVLAN(ctx, 1, VLANRequest{If: "em1", Tag: 20, Descr: &desc})
+	require.NoError(t, err)
+	requests := received()
+	last := requests[len(requests)-1]
+	require.Equal(t, http.MethodPut, last.Method)
+	require.Equal(t, "em1.20", last.Body["vlanif"])
+	require.NotContains(t, last.Body, "id")
+
+	deleted, err := newClient.Interface.DeleteVLAN(ctx, 0)
+	require.NoError(t, err)
+	require.Equal(t, "Servers", deleted.Descr.MustGet())
+	requests = received()
+	last = requests[len(requests)-1]
+	require.Equal(t, http.MethodDelete, last.Method)
+	require.Equal(t, "em1.10", last.Query["vlanif"])
+}
+
+func TestV1_Users(t *testing.T) {
+	ctx := context.Background()
+	server, received := setupV1Server(t, map[string]string{
+		"GET /" + v1UserEndpoint:    "testdata/v1users.json",
+		"POST /" + v1UserEndpoint:   "testdata/v1user.json",
+		"PUT /" + v1UserEndpoint:    "testdata/v1user.json",
+		"DELETE /" + v1UserEndpoint: "testdata/v1user.json",
+	})
+	defer server.Close()
+	newClient := newV1Client(server.URL)
+
+	users, err := newClient.User.ListUsers(ctx)
+	require.NoError(t, err)
+	require.Len(t, users, 2)
+	require.Equal(t, "admin", users[0].Name)
+	require.Equal(t, FlexInt(0), users[0].UID)
+	require.False(t, bool(users[0].Disabled))
+	require.Equal(t, 1, users[1].Id)
+	require.Equal(t, FlexInt(2000), users[1].UID)
+	require.True(t, bool(users[1].Disabled))
+	require.Equal(t, "$2y$10$other", users[1].Password)
+
+	_, err = newClient.User.CreateUser(ctx, UserRequest{Name: "guest", Password: "secret"})
+	require.NoError(t, err)
+	// the create is followed by a list to find the id of the new user
+	requests := received()
+	last := requests[len(requests)-2]
+	require.Equal(t, http.MethodPost, last.Method)
+	require.Equal(t, "guest", last.Body["username"])
+	require.NotContains(t, last.Body, "name")
+
+	updated, err := newClient.User.UpdateUser(ctx, 1, UserRequest{Descr: "Night operator"})
+	require.NoError(t, err)
+	require.Equal(t, 1, updated.Id)
+	require.Equal(t, "Night operator", updated.Descr)
+	requests = received()
+	last = requests[len(requests)-1]
+	require.Equal(t, http.MethodPut, last.Method)
+	require.Equal(t, "operator", last.Body["username"])
+
+	_, err = newClient.User.UpdateUser(ctx, 1, UserRequest{Name: "renamed"})
+	require.Error(t, err)
+
+	_, err = newClient.User.DeleteUser(ctx, 1)
+	require.NoError(t, err)
+	requests = received()
+	last = requests[len(requests)-1]
+	require.Equal(t, http.MethodDelete, last.Method)
+	require.Equal(t, "operator", last.Query["username"])
+}
+
+func TestV1_CreateReturnsIndex(t *testing.T) {
+	ctx := context.Background()
+	server, _ := setupV1Server(t, map[string]string{
+		"GET /" + v1InterfaceVLANEndpoint:  "testdata/v1vlanscreated.json",
+		"POST /" + v1InterfaceVLANEndpoint: "testdata/v1vlan.json",
+		"GET /" + v1UserEndpoint:           "testdata/v1userscreated.json",
+		"POST /" + v1UserEndpoint:          "testdata/v1usercreated.json",
+	})
+	defer server.Close()
+	newClient := newV1Client(server.URL)
+
+	// v1 returns no id for new objects; it is their index in the list
+	vlan, err := newClient.Interface.CreateVLAN(ctx, VLANRequest{If: "em1", Tag: 30})
+	require.NoError(t, err)
+	require.Equal(t, 2, vlan.Id)
+	require.Equal(t, 30, vlan.Tag)
+
+	user, err := newClient.User.CreateUser(ctx, UserRequest{Name: "guest", Password: "secret"})
+	require.NoError(t, err)
+	require.Equal(t, 2, user.Id)
+	require.Equal(t, "guest", user.Name)
+
+	// an object that cannot be found again is an error, not id 0
+	server, _ = setupV1Server(t, map[string]string{
+		"GET /" + v1InterfaceVLANEndpoint:  "testdata/v1vlans.json",
+		"POST /" + v1InterfaceVLANEndpoint: "testdata/v1vlan.json",
+	})
+	defer server.Close()
+	_, err = newV1Client(server.URL).Interface.CreateVLAN(ctx, VLANRequest{If: "em1", Tag: 30})
+	require.ErrorContains(t, err, `"em1.30" not found`)
+}
+
+func TestV1_Unsupported(t *testing.T) {
+	server, received := setupV1Server(t, nil)
+	defer server.Close()
+	newClient := newV1Client(server.URL)
+
+	_, err := newClient.Interface.ListInterfaceGroups(context.Background())
+	require.ErrorIs(t, err, ErrUnsupported)
+
+	_, err = newClient.User.ListUserGroups(context.Background())
+	require.ErrorIs(t, err, ErrUnsupported)
+	require.Empty(t, received())
+}
+
+func TestV1_Error(t *testing.T) {
+	server, _ := setupV1Server(t, nil)
+	defer server.Close()
+	newClient := newV1Client(server.URL)
+
+	_, err := newClient.User.ListUsers(context.Background())
+	require.ErrorIs(t, err, ErrNotFound)
+}
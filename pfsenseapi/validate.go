@@ -0,0 +1,516 @@
+package pfsenseapi
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// The request types have a Validate method that checks the request before it
+// is sent. The service methods do not call it: updates may send a request
+// with only some fields set, which Validate would reject. Callers that want
+// the check must call Validate themselves.
+
+// ValidationError is a single problem with a field of a request.
+type ValidationError struct {
+	Field   string
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Field, e.Message)
+}
+
+// ValidationErrors lists every problem Validate found in a request.
+type ValidationErrors []*ValidationError
+
+func (e ValidationErrors) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, err := range e {
+		msgs = append(msgs, err.Error())
+	}
+	return "invalid request: " + strings.Join(msgs, "; ")
+}
+
+// Unwrap returns the individual errors so errors.As can find them.
+func (e ValidationErrors) Unwrap() []error {
+	errs := make([]error, 0, len(e))
+	for _, err := range e {
+		errs = append(errs, err)
+	}
+	return errs
+}
+
+// validator collects the problems of a request.
+type validator struct {
+	errs ValidationErrors
+}
+
+func (v *validator) addf(field, format string, args ...any) {
+	v.errs = append(v.errs, &ValidationError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *validator) err() error {
+	if len(v.errs) == 0 {
+		return nil
+	}
+	return v.errs
+}
+
+func (v *validator) required(field, value string) {
+	if value == "" {
+		v.addf(field, "is required")
+	}
+}
+
+func (v *validator) between(field string, value, low, high int) {
+	if value < low || value > high {
+		v.addf(field, "must be between %d and %d, got %d", low, high, value)
+	}
+}
+
+func (v *validator) oneOf(field, value string, allowed ...string) {
+	for _, a := range allowed {
+		if value == a {
+			return
+		}
+	}
+	v.addf(field, "must be one of %s, got %q", strings.Join(allowed, ", "), value)
+}
+
+func (v *validator) ipv4(field, value string) {
+	if ip := net.ParseIP(value); ip == nil || ip.To4() == nil {
+		v.addf(field, "must be an IPv4 address, got %q", value)
+	}
+}
+
+func (v *validator) ipv6(field, value string) {
+	if ip := net.ParseIP(value); ip == nil || ip.To4() != nil {
+		v.addf(field, "must be an IPv6 address, got %q", value)
+	}
+}
+
+func (v *validator) mac(field, value string) {
+	if hw, err := net.ParseMAC(value); err != nil || len(hw) != 6 {
+		v.addf(field, "must be a MAC address like 00:11:22:33:44:55, got %q", value)
+	}
+}
+
+// Validate checks the request for problems the firewall would reject it for,
+// such as a malformed address or an out of range prefix length. It returns a
+// ValidationErrors listing every problem found, or nil.
+func (r InterfaceRequest) Validate() error {
+	v := new(validator)
+	v.required("if", r.If)
+
+	if r.Spoofmac != nil && r.Spoofmac.OrElse("") != "" {
+		v.mac("spoofmac", r.Spoofmac.MustGet())
+	}
+	if r.Mtu != nil {
+		v.between("mtu", int(r.Mtu.OrElse(0)), 1280, 8192)
+	}
+	if r.Mss != nil {
+		v.between("mss", int(r.Mss.OrElse(0)), 576, 65535)
+	}
+
+	if r.Typev4 != "" {
+		v.oneOf("typev4", r.Typev4,
+			Typev4Static, Typev4DHCP, Typev4PPPoE, Typev4PPTP, Typev4L2TP, Typev4None)
+	}
+	if r.Typev4 == Typev4Static {
+		v.ipv4("ipaddr", r.Ipaddr)
+		v.between("subnet", int(r.Subnet), 1, 32)
+	} else {
+		if r.Ipaddr != "" {
+			v.ipv4("ipaddr", r.Ipaddr)
+		}
+		v.between("subnet", int(r.Subnet), 0, 32)
+	}
+	if r.AliasSubnet != nil {
+		v.between("alias_subnet", int(r.AliasSubnet.OrElse(0)), 0, 32)
+	}
+
+	var typev6 Typev6
+	if r.Typev6 != nil {
+		typev6 = r.Typev6.OrElse("")
+	}
+	if typev6 != "" {
+		v.oneOf("typev6", typev6,
+			Typev6Static, Typev6DHCP6, Typev6SLAAC, Typev66RD, Typev66To4, Typev6Track6, Typev6None)
+	}
+	if typev6 == Typev6Static {
+		v.ipv6("ipaddrv6", r.Ipaddrv6)
+		v.between("subnetv6", int(r.Subnetv6), 1, 128)
+	} else {
+		if r.Ipaddrv6 != "" {
+			v.ipv6("ipaddrv6", r.Ipaddrv6)
+		}
+		v.between("subnetv6", int(r.Subnetv6), 0, 128)
+	}
+	if typev6 == Typev66RD {
+		v.between("prefix_6rd_v4plen", int(r.Prefix6RdV4Plen), 0, 32)
+		if r.Gateway6Rd != "" {
+			v.ipv4("gateway_6rd", r.Gateway6Rd)
+		}
+	}
+	if typev6 == Typev6Track6 {
+		v.required("track6_interface", r.Track6Interface)
+	}
+
+	return v.err()
+}
+
+// Validate checks that the tag is within 1-4094 and the PCP within 0-7.
+func (r VLANRequest) Validate() error {
+	v := new(validator)
+	v.required("if", r.If)
+	v.between("tag", r.Tag, 1, 4094)
+	if r.Pcp != nil {
+		v.between("pcp", r.Pcp.OrElse(0), 0, 7)
+	}
+	return v.err()
+}
+
+// Validate checks the group name against the rules pfSense applies to
+// interface group names.
+func (r InterfaceGroupRequest) Validate() error {
+	v := new(validator)
+	v.required("ifname", r.Ifname)
+	if len(r.Ifname) > 15 {
+		v.addf("ifname", "must be at most 15 characters, got %d", len(r.Ifname))
+	}
+	if r.Ifname != "" && unicode.IsDigit(rune(r.Ifname[len(r.Ifname)-1])) {
+		v.addf("ifname", "must not end in a digit")
+	}
+	return v.err()
+}
+
+// Validate checks that the bridge has members.
+func (r InterfaceBridgeRequest) Validate() error {
+	v := new(validator)
+	if len(r.Members) == 0 {
+		v.addf("members", "must list at least one interface")
+	}
+	return v.err()
+}
+
+// Validate checks that the user has a name and a known scope.
+func (r UserRequest) Validate() error {
+	v := new(validator)
+	v.required("name", r.Name)
+	if r.Scope != "" {
+		v.oneOf("scope", r.Scope, ScopeUser, ScopeSystem)
+	}
+	return v.err()
+}
+
+// Validate checks that the group has a name and a known scope.
+func (r UserGroupRequest) Validate() error {
+	v := new(validator)
+	v.required("name", r.Name)
+	if r.Scope != "" {
+		v.oneOf("scope", r.Scope, GroupScopeLocal, GroupScopeRemote, GroupScopeSystem)
+	}
+	return v.err()
+}
+
+// Validate checks the rule's action, address family, protocol and
+// direction, and that ports are only given for TCP and UDP.
+func (r FirewallRuleRequest) Validate() error {
+	v := new(validator)
+	v.oneOf("type", string(r.Type), string(RuleTypePass), string(RuleTypeBlock), string(RuleTypeReject))
+
+	switch {
+	case len(r.Interface) == 0:
+		v.addf("interface", "must list at least one interface")
+	case len(r.Interface) > 1 && !r.Floating:
+		v.addf("interface", "only floating rules may apply to more than one interface")
+	}
+
+	if r.Ipprotocol != "" {
+		v.oneOf("ipprotocol", string(r.Ipprotocol), string(IPProtocolInet), string(IPProtocolInet6), string(IPProtocolInet46))
+	}
+	if r.Protocol != ProtocolAny {
+		v.oneOf("protocol", string(r.Protocol),
+			string(ProtocolTCP), string(ProtocolUDP), string(ProtocolTCPUDP), string(ProtocolICMP),
+			string(ProtocolESP), string(ProtocolAH), string(ProtocolGRE), string(ProtocolIPv6),
+			string(ProtocolIGMP), string(ProtocolPIM), string(ProtocolOSPF), string(ProtocolCARP),
+			string(ProtocolPFSync),
+		)
+	}
+	if len(r.Icmptype) > 0 && r.Protocol != ProtocolICMP {
+		v.addf("icmptype", "is only used with protocol icmp")
+	}
+
+	v.required("source", r.Source.Address)
+	v.required("destination", r.Destination.Address)
+	if r.SourcePort != nil && r.SourcePort.OrElse("") != "" && !r.Protocol.hasPorts() {
+		v.addf("source_port", "is only used with protocol tcp, udp or tcp/udp")
+	}
+	if r.DestinationPort != nil && r.DestinationPort.OrElse("") != "" && !r.Protocol.hasPorts() {
+		v.addf("destination_port", "is only used with protocol tcp, udp or tcp/udp")
+	}
+
+	if r.Direction != "" {
+		v.oneOf("direction", string(r.Direction), string(DirectionAny), string(DirectionIn), string(DirectionOut))
+	}
+	return v.err()
+}
+
+// Validate checks the alias name against the rules pfSense applies to it,
+// the alias type, and that URL aliases hold URLs.
+func (r AliasRequest) Validate() error {
+	v := new(validator)
+	v.required("name", r.Name)
+	if len(r.Name) > 31 {
+		v.addf("name", "must be at most 31 characters, got %d", len(r.Name))
+	}
+	for _, c := range r.Name {
+		if c != '_' && (c > unicode.MaxASCII || !unicode.IsLetter(c) && !unicode.IsDigit(c)) {
+			v.addf("name", "may only contain letters, digits and underscores, got %q", r.Name)
+			break
+		}
+	}
+
+	v.oneOf("type", string(r.Type),
+		string(AliasTypeHost), string(AliasTypeNetwork), string(AliasTypePort), string(AliasTypeURL),
+		string(AliasTypeURLPorts), string(AliasTypeURLTable), string(AliasTypeURLTablePorts),
+	)
+	switch r.Type {
+	case AliasTypeURL, AliasTypeURLPorts, AliasTypeURLTable, AliasTypeURLTablePorts:
+		for i, entry := range r.Entries {
+			if !strings.HasPrefix(entry.Address, "http://") && !strings.HasPrefix(entry.Address, "https://") {
+				v.addf(fmt.Sprintf("address[%d]", i), "must be an http or https URL, got %q", entry.Address)
+			}
+		}
+	}
+	return v.err()
+}
+
+// Validate checks the schedule name, and that each time range has either
+// weekdays or dates, valid ones, and hours that end after they start.
+func (r ScheduleRequest) Validate() error {
+	v := new(validator)
+	v.required("name", r.Name)
+	for _, c := range r.Name {
+		if c != '_' && (c > unicode.MaxASCII || !unicode.IsLetter(c) && !unicode.IsDigit(c)) {
+			v.addf("name", "may only contain letters, digits and underscores, got %q", r.Name)
+			break
+		}
+	}
+	if len(r.Timerange) == 0 {
+		v.addf("timerange", "must have at least one time range")
+	}
+
+	for i, timeRange := range r.Timerange {
+		field := fmt.Sprintf("timerange[%d]", i)
+		switch {
+		case len(timeRange.Weekdays) == 0 && len(timeRange.Dates) == 0:
+			v.addf(field, "must list weekdays or dates")
+		case len(timeRange.Weekdays) > 0 && len(timeRange.Dates) > 0:
+			v.addf(field, "cannot list both weekdays and dates")
+		}
+		for _, day := range timeRange.Weekdays {
+			if day < time.Sunday || day > time.Saturday {
+				v.addf(field, "invalid weekday %d", day)
+			}
+		}
+		for _, date := range timeRange.Dates {
+			// a leap year, so that February 29 is allowed
+			if date.Month < time.January || date.Month > time.December ||
+				date.Day < 1 || date.Day > time.Date(2024, date.Month+1, 0, 0, 0, 0, 0, time.UTC).Day() {
+				v.addf(field, "invalid date %d/%d", date.Month, date.Day)
+			}
+		}
+		if timeRange.Start < 0 || timeRange.End > EndOfDay || timeRange.Start >= timeRange.End {
+			v.addf(field, "hours must run forwards within the day, got %s-%s", timeRange.Start, timeRange.End)
+		}
+	}
+	return v.err()
+}
+
+// natProtocols are the protocols NAT rules can match, which unlike filter
+// rules leave out CARP and pfsync.
+var natProtocols = []string{
+	string(ProtocolTCP), string(ProtocolUDP), string(ProtocolTCPUDP), string(ProtocolICMP),
+	string(ProtocolESP), string(ProtocolAH), string(ProtocolGRE), string(ProtocolIPv6),
+	string(ProtocolIGMP), string(ProtocolPIM), string(ProtocolOSPF),
+}
+
+// Validate checks the port forward's address family, protocol and NAT
+// reflection mode, that ports are only given for TCP and UDP, and that it
+// has a target unless it is a "no redirect" exception.
+func (r PortForwardRequest) Validate() error {
+	v := new(validator)
+	v.required("interface", r.Interface)
+	if r.Ipprotocol != "" {
+		v.oneOf("ipprotocol", string(r.Ipprotocol), string(IPProtocolInet), string(IPProtocolInet6), string(IPProtocolInet46))
+	}
+	v.oneOf("protocol", string(r.Protocol), natProtocols...)
+
+	v.required("source", r.Source.Address)
+	v.required("destination", r.Destination.Address)
+	if !r.Protocol.hasPorts() {
+		if optString(r.SourcePort) != "" {
+			v.addf("source_port", "is only used with protocol tcp, udp or tcp/udp")
+		}
+		if optString(r.DestinationPort) != "" {
+			v.addf("destination_port", "is only used with protocol tcp, udp or tcp/udp")
+		}
+		if optString(r.LocalPort) != "" {
+			v.addf("local_port", "is only used with protocol tcp, udp or tcp/udp")
+		}
+	}
+	if !r.Nordr {
+		v.required("target", r.Target)
+	}
+
+	if r.Natreflection != NATReflectionDefault {
+		v.oneOf("natreflection", string(r.Natreflection),
+			string(NATReflectionEnable), string(NATReflectionDisable), string(NATReflectionPureNAT))
+	}
+	return v.err()
+}
+
+// Validate checks the mapping's protocol and pool options, that ports are
+// only given for TCP and UDP, and that a static port is not combined with a
+// translation port.
+func (r OutboundMappingRequest) Validate() error {
+	v := new(validator)
+	v.required("interface", r.Interface)
+	if r.Protocol != ProtocolAny {
+		v.oneOf("protocol", string(r.Protocol), natProtocols...)
+	}
+
+	v.required("source", r.Source.Address)
+	v.required("destination", r.Destination.Address)
+	if !r.Protocol.hasPorts() {
+		if optString(r.SourcePort) != "" {
+			v.addf("source_port", "is only used with protocol tcp, udp or tcp/udp")
+		}
+		if optString(r.DestinationPort) != "" {
+			v.addf("destination_port", "is only used with protocol tcp, udp or tcp/udp")
+		}
+	}
+	if optString(r.NatPort) != "" && r.StaticNatPort {
+		v.addf("nat_port", "cannot be combined with static_nat_port")
+	}
+	if r.TargetSubnet != nil {
+		v.between("target_subnet", r.TargetSubnet.OrElse(0), 0, 128)
+	}
+
+	if r.Poolopts != PoolOptionDefault {
+		v.oneOf("poolopts", string(r.Poolopts),
+			string(PoolOptionRoundRobin), string(PoolOptionRoundRobinStickyAddress), string(PoolOptionRandom),
+			string(PoolOptionRandomStickyAddress), string(PoolOptionSourceHash), string(PoolOptionBitmask),
+		)
+	}
+	if optString(r.SourceHashKey) != "" && r.Poolopts != PoolOptionSourceHash {
+		v.addf("source_hash_key", "is only used with poolopts source-hash")
+	}
+	return v.err()
+}
+
+// Validate checks the address family and NAT reflection mode, and that the
+// external block matches the internal one. pfSense translates External
+// using the prefix length of Source, so a CIDR given as External must have
+// the same length and its address must start a block of that size.
+func (r OneToOneMappingRequest) Validate() error {
+	v := new(validator)
+	v.required("interface", r.Interface)
+	if r.Ipprotocol != "" {
+		v.oneOf("ipprotocol", string(r.Ipprotocol), string(IPProtocolInet), string(IPProtocolInet6))
+	}
+	v.required("source", r.Source.Address)
+	v.required("destination", r.Destination.Address)
+	if r.Natreflection != NATReflectionDefault {
+		v.oneOf("natreflection", string(r.Natreflection), string(NATReflectionEnable), string(NATReflectionDisable))
+	}
+
+	external, ok := parseAddrOrPrefix(r.External)
+	if !ok {
+		v.addf("external", "must be an address or CIDR, got %q", r.External)
+		return v.err()
+	}
+	switch {
+	case r.Ipprotocol == IPProtocolInet && !external.Addr().Is4():
+		v.addf("external", "must be an IPv4 address for ipprotocol inet, got %q", r.External)
+	case r.Ipprotocol == IPProtocolInet6 && !external.Addr().Is6():
+		v.addf("external", "must be an IPv6 address for ipprotocol inet6, got %q", r.External)
+	}
+
+	// aliases and "any" have no size to compare against
+	source, ok := parseAddrOrPrefix(r.Source.Address)
+	if !ok || r.Source.Not {
+		return v.err()
+	}
+	block := netip.PrefixFrom(external.Addr(), source.Bits()).Masked()
+	switch {
+	case source.Addr().Is4() != external.Addr().Is4():
+		v.addf("external", "must be of the same address family as source %s", r.Source.Address)
+	case strings.Contains(r.External, "/") && external.Bits() != source.Bits():
+		v.addf("external", "is a /%d but source is a /%d; both blocks must be the same size", external.Bits(), source.Bits())
+	case block.Addr() != external.Addr():
+		v.addf("external", "must be the first address of a /%d block to match source %s, e.g. %s", source.Bits(), r.Source.Address, block.Addr())
+	}
+	return v.err()
+}
+
+// Validate checks the mode and type, that Subnet is an address and
+// SubnetBits fits its family, and for CARP the VHID, advertisement timing and
+// password. Whether the VHID is free on the interface depends on the other
+// virtual IPs; CreateVirtualIP and UpdateVirtualIP check that.
+func (r VirtualIPRequest) Validate() error {
+	v := new(validator)
+	v.oneOf("mode", string(r.Mode),
+		string(VirtualIPModeIPAlias), string(VirtualIPModeCARP), string(VirtualIPModeProxyARP), string(VirtualIPModeOther))
+	v.required("interface", r.Interface)
+	switch r.Type {
+	case "", VirtualIPTypeSingle:
+	case VirtualIPTypeNetwork:
+		if r.Mode == VirtualIPModeCARP || r.Mode == VirtualIPModeIPAlias {
+			v.addf("type", "must be single for %s virtual IPs", r.Mode)
+		}
+	default:
+		v.oneOf("type", string(r.Type), string(VirtualIPTypeSingle), string(VirtualIPTypeNetwork))
+	}
+
+	if addr, err := netip.ParseAddr(r.Subnet); err != nil {
+		v.addf("subnet", "must be an IP address, got %q", r.Subnet)
+	} else {
+		v.between("subnet_bits", r.SubnetBits, 1, addr.BitLen())
+	}
+
+	if r.Mode == VirtualIPModeCARP {
+		if r.Vhid == nil {
+			v.addf("vhid", "is required for CARP virtual IPs")
+		} else {
+			v.between("vhid", r.Vhid.OrElse(0), 1, 255)
+		}
+		if r.Advbase != nil {
+			v.between("advbase", r.Advbase.OrElse(0), 1, 254)
+		}
+		if r.Advskew != nil {
+			v.between("advskew", r.Advskew.OrElse(0), 0, 254)
+		}
+		v.required("password", r.Password)
+	}
+	return v.err()
+}
+
+// parseAddrOrPrefix reads a CIDR, or an address as a prefix covering only
+// itself.
+func parseAddrOrPrefix(s string) (netip.Prefix, bool) {
+	if strings.Contains(s, "/") {
+		prefix, err := netip.ParsePrefix(s)
+		return prefix, err == nil
+	}
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Prefix{}, false
+	}
+	return netip.PrefixFrom(addr, addr.BitLen()), true
+}
@@ -0,0 +1,310 @@
+package pfsenseapi
+
+import (
+	"testing"
+	"time"
+
+	"github.com/markphelps/optional"
+	"github.com/stretchr/testify/require"
+)
+
+// validationFields returns the fields named by the ValidationErrors in err.
+func validationFields(t *testing.T, err error) []string {
+	var errs ValidationErrors
+	require.ErrorAs(t, err, &errs)
+
+	fields := make([]string, 0, len(errs))
+	for _, e := range errs {
+		fields = append(fields, e.Field)
+	}
+	return fields
+}
+
+func TestInterfaceRequest_Validate(t *testing.T) {
+	staticv6 := optional.NewString(Typev6Static)
+	valid := InterfaceRequest{
+		If:       "em1",
+		Typev4:   Typev4Static,
+		Ipaddr:   "192.168.1.1",
+		Subnet:   24,
+		Typev6:   &staticv6,
+		Ipaddrv6: "fd00::1",
+		Subnetv6: 64,
+	}
+	require.NoError(t, valid.Validate())
+	dhcp6 := optional.NewString(Typev6DHCP6)
+	require.NoError(t, InterfaceRequest{If: "em0", Typev4: Typev4DHCP, Typev6: &dhcp6}.Validate())
+	for _, typev4 := range []Typev4{Typev4PPPoE, Typev4PPTP, Typev4L2TP, Typev4None} {
+		require.NoError(t, InterfaceRequest{If: "em0", Typev4: typev4}.Validate())
+	}
+
+	mac := optional.NewString("00:11:22:33:44")
+	mtu := NewFlexOptionalInt32(100)
+	static := optional.NewString("static")
+	invalid := InterfaceRequest{
+		Spoofmac: &mac,
+		Mtu:      &mtu,
+		Typev4:   Typev4Static,
+		Ipaddr:   "fd00::1",
+		Subnet:   33,
+		Typev6:   &static,
+	}
+	err := invalid.Validate()
+	require.Equal(t, []string{"if", "spoofmac", "mtu", "ipaddr", "subnet", "typev6"}, validationFields(t, err))
+	require.Contains(t, err.Error(), "subnet: must be between 1 and 32, got 33")
+
+	var fieldErr *ValidationError
+	require.ErrorAs(t, err, &fieldErr)
+	require.Equal(t, "if", fieldErr.Field)
+}
+
+func TestVLANRequest_Validate(t *testing.T) {
+	pcp := optional.NewInt(3)
+	require.NoError(t, VLANRequest{If: "em1", Tag: 4094, Pcp: &pcp}.Validate())
+
+	pcp = optional.NewInt(8)
+	err := VLANRequest{Tag: 4095, Pcp: &pcp}.Validate()
+	require.Equal(t, []string{"if", "tag", "pcp"}, validationFields(t, err))
+
+	err = VLANRequest{If: "em1"}.Validate()
+	require.Equal(t, []string{"tag"}, validationFields(t, err))
+}
+
+func TestInterfaceGroupRequest_Validate(t *testing.T) {
+	require.NoError(t, InterfaceGroupRequest{Ifname: "servers"}.Validate())
+
+	err := InterfaceGroupRequest{Ifname: "averylonggroupname1"}.Validate()
+	require.Equal(t, []string{"ifname", "ifname"}, validationFields(t, err))
+}
+
+func TestInterfaceBridgeRequest_Validate(t *testing.T) {
+	require.NoError(t, InterfaceBridgeRequest{Members: []string{"lan"}}.Validate())
+	require.Equal(t, []string{"members"}, validationFields(t, InterfaceBridgeRequest{}.Validate()))
+}
+
+func TestUserRequest_Validate(t *testing.T) {
+	require.NoError(t, UserRequest{Name: "user1", Scope: ScopeUser}.Validate())
+	require.Equal(t, []string{"name", "scope"}, validationFields(t, UserRequest{Scope: "users"}.Validate()))
+}
+
+func TestUserGroupRequest_Validate(t *testing.T) {
+	require.NoError(t, UserGroupRequest{Name: "admins", Scope: GroupScopeLocal}.Validate())
+	require.Equal(t, []string{"scope"}, validationFields(t, UserGroupRequest{Name: "admins", Scope: "group"}.Validate()))
+}
+
+func TestFirewallRuleRequest_Validate(t *testing.T) {
+	port := optional.NewString("443")
+	valid := FirewallRuleRequest{
+		Type:            RuleTypePass,
+		Interface:       []string{"lan"},
+		Ipprotocol:      IPProtocolInet,
+		Protocol:        ProtocolTCP,
+		Source:          RuleAddress{Address: "lan"},
+		Destination:     AnyAddress,
+		DestinationPort: &port,
+	}
+	require.NoError(t, valid.Validate())
+
+	invalid := FirewallRuleRequest{
+		Type:            "allow",
+		Interface:       []string{"lan", "wan"},
+		Protocol:        ProtocolICMP,
+		Source:          AnyAddress,
+		DestinationPort: &port,
+		Direction:       "both",
+	}
+	err := invalid.Validate()
+	require.Equal(t, []string{"type", "interface", "destination", "destination_port", "direction"}, validationFields(t, err))
+
+	invalid.Floating = true
+	invalid.Type = RuleTypeBlock
+	invalid.Destination = RuleAddress{Address: "10.0.0.0/8", Not: true}
+	invalid.Direction = DirectionIn
+	require.Equal(t, []string{"destination_port"}, validationFields(t, invalid.Validate()))
+}
+
+func TestAliasRequest_Validate(t *testing.T) {
+	require.NoError(t, AliasRequest{Name: "web_servers", Type: AliasTypeHost, Entries: []AliasEntry{{Address: "10.0.0.5"}}}.Validate())
+
+	err := AliasRequest{Name: "bad-name", Type: "list"}.Validate()
+	require.Equal(t, []string{"name", "type"}, validationFields(t, err))
+
+	err = AliasRequest{
+		Name:    "blocklist",
+		Type:    AliasTypeURLTable,
+		Entries: []AliasEntry{{Address: "https://example.com/list.txt"}, {Address: "ftp://example.com/list.txt"}},
+	}.Validate()
+	require.Equal(t, []string{"address[1]"}, validationFields(t, err))
+}
+
+func TestPortForwardRequest_Validate(t *testing.T) {
+	port := optional.NewString("443")
+	valid := PortForwardRequest{
+		Interface:       "wan",
+		Protocol:        ProtocolTCP,
+		Source:          AnyAddress,
+		Destination:     RuleAddress{Address: "wan:ip"},
+		DestinationPort: &port,
+		Target:          "10.0.0.5",
+		LocalPort:       &port,
+	}
+	require.NoError(t, valid.Validate())
+
+	invalid := PortForwardRequest{
+		Protocol:      ProtocolICMP,
+		Source:        AnyAddress,
+		Destination:   AnyAddress,
+		LocalPort:     &port,
+		Natreflection: "on",
+	}
+	err := invalid.Validate()
+	require.Equal(t, []string{"interface", "local_port", "target", "natreflection"}, validationFields(t, err))
+
+	invalid.Interface = "wan"
+	invalid.Protocol = ProtocolUDP
+	invalid.Nordr = true
+	invalid.Natreflection = NATReflectionPureNAT
+	require.NoError(t, invalid.Validate())
+}
+
+func TestOutboundMappingRequest_Validate(t *testing.T) {
+	target := optional.NewString("wan2_pool")
+	valid := OutboundMappingRequest{
+		Interface:   "wan2",
+		Source:      RuleAddress{Address: "10.0.20.0/24"},
+		Destination: AnyAddress,
+		Target:      &target,
+		Poolopts:    PoolOptionRoundRobin,
+	}
+	require.NoError(t, valid.Validate())
+
+	port := optional.NewString("5060")
+	key := optional.NewString("0x01")
+	subnet := optional.NewInt(129)
+	invalid := OutboundMappingRequest{
+		Interface:       "wan",
+		Protocol:        ProtocolCARP,
+		Source:          AnyAddress,
+		DestinationPort: &port,
+		NatPort:         &port,
+		StaticNatPort:   true,
+		TargetSubnet:    &subnet,
+		Poolopts:        "sticky",
+		SourceHashKey:   &key,
+	}
+	err := invalid.Validate()
+	require.Equal(t, []string{
+		"protocol", "destination", "destination_port", "nat_port", "target_subnet", "poolopts", "source_hash_key",
+	}, validationFields(t, err))
+}
+
+func TestOneToOneMappingRequest_Validate(t *testing.T) {
+	valid := OneToOneMappingRequest{
+		Interface:   "wan",
+		Ipprotocol:  IPProtocolInet,
+		External:    "203.0.113.16",
+		Source:      RuleAddress{Address: "10.0.5.0/28"},
+		Destination: AnyAddress,
+	}
+	require.NoError(t, valid.Validate())
+
+	valid.External = "203.0.113.16/28"
+	require.NoError(t, valid.Validate())
+
+	valid.External, valid.Source = "203.0.113.9", RuleAddress{Address: "10.0.5.9"}
+	require.NoError(t, valid.Validate())
+
+	// an alias has no size to check
+	valid.Source = RuleAddress{Address: "dmz_hosts"}
+	require.NoError(t, valid.Validate())
+
+	tests := []struct {
+		external, source string
+		ipprotocol       IPProtocol
+	}{
+		{external: "203.0.113.16/29", source: "10.0.5.0/28"},
+		{external: "203.0.113.20", source: "10.0.5.0/28"},
+		{external: "2001:db8::10", source: "10.0.5.0/28"},
+		{external: "203.0.113.16", source: "10.0.5.0/28", ipprotocol: IPProtocolInet6},
+		{external: "dmz.example.com", source: "10.0.5.0/28"},
+	}
+	for _, tt := range tests {
+		err := OneToOneMappingRequest{
+			Interface:   "wan",
+			Ipprotocol:  tt.ipprotocol,
+			External:    tt.external,
+			Source:      RuleAddress{Address: tt.source},
+			Destination: AnyAddress,
+		}.Validate()
+		require.Equal(t, []string{"external"}, validationFields(t, err), tt.external)
+	}
+
+	err := OneToOneMappingRequest{External: "203.0.113.16", Ipprotocol: IPProtocolInet46, Natreflection: NATReflectionPureNAT}.Validate()
+	require.Equal(t, []string{"interface", "ipprotocol", "source", "destination", "natreflection"}, validationFields(t, err))
+}
+
+func TestScheduleRequest_Validate(t *testing.T) {
+	require.NoError(t, ScheduleRequest{
+		Name: "office_hours",
+		Timerange: []ScheduleTimeRange{
+			Weekdays(Clock(8, 30), Clock(18, 0)),
+			OnDates(Clock(0, 0), EndOfDay, ScheduleDate{Month: time.February, Day: 29}),
+		},
+	}.Validate())
+
+	err := ScheduleRequest{Name: "office hours"}.Validate()
+	require.Equal(t, []string{"name", "timerange"}, validationFields(t, err))
+
+	both := Weekdays(Clock(9, 0), Clock(17, 0))
+	both.Dates = []ScheduleDate{{Month: time.May, Day: 1}}
+	err = ScheduleRequest{
+		Name: "bad_ranges",
+		Timerange: []ScheduleTimeRange{
+			{Start: Clock(9, 0), End: Clock(17, 0)},
+			both,
+			OnDates(Clock(9, 0), Clock(17, 0), ScheduleDate{Month: time.April, Day: 31}),
+			Weekends(Clock(17, 0), Clock(9, 0)),
+			Weekdays(Clock(0, 0), Clock(24, 0)),
+		},
+	}.Validate()
+	require.Equal(t, []string{"timerange[0]", "timerange[1]", "timerange[2]", "timerange[3]", "timerange[4]"}, validationFields(t, err))
+}
+
+func TestVirtualIPRequest_Validate(t *testing.T) {
+	require.NoError(t, VirtualIPRequest{
+		Mode:       VirtualIPModeCARP,
+		Interface:  "lan",
+		Subnet:     "192.168.1.1",
+		SubnetBits: 24,
+		Vhid:       optionalInt(10),
+		Advskew:    optionalInt(100),
+		Password:   "secret",
+	}.Validate())
+	require.NoError(t, VirtualIPRequest{
+		Mode:       VirtualIPModeProxyARP,
+		Interface:  "wan",
+		Type:       VirtualIPTypeNetwork,
+		Subnet:     "2001:db8::",
+		SubnetBits: 64,
+	}.Validate())
+
+	err := VirtualIPRequest{
+		Mode:       "vrrp",
+		Type:       "range",
+		Subnet:     "192.168.1.0/24",
+		SubnetBits: 24,
+	}.Validate()
+	require.Equal(t, []string{"mode", "interface", "type", "subnet"}, validationFields(t, err))
+
+	err = VirtualIPRequest{
+		Mode:       VirtualIPModeCARP,
+		Interface:  "lan",
+		Type:       VirtualIPTypeNetwork,
+		Subnet:     "192.168.1.1",
+		SubnetBits: 33,
+		Vhid:       optionalInt(256),
+		Advbase:    optionalInt(255),
+		Advskew:    optionalInt(-1),
+	}.Validate()
+	require.Equal(t, []string{"type", "subnet_bits", "vhid", "advbase", "advskew", "password"}, validationFields(t, err))
+}
@@ -0,0 +1,272 @@
+package pfsenseapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/markphelps/optional"
+)
+
+const (
+	virtualIPEndpoint      = "api/v2/firewall/virtual_ip"
+	virtualIPsEndpoint     = "api/v2/firewall/virtual_ips"
+	virtualIPApplyEndpoint = "api/v2/firewall/virtual_ip/apply"
+)
+
+// ErrVHIDInUse is returned when a CARP virtual IP would share its VHID with
+// another CARP virtual IP on the same interface.
+var ErrVHIDInUse = errors.New("VHID already in use on the interface")
+
+// VirtualIPService provides virtual IP API methods. Changes take effect once
+// Apply is called.
+type VirtualIPService service
+
+// VirtualIPMode is the kind of a virtual IP.
+type VirtualIPMode string
+
+const (
+	VirtualIPModeIPAlias  VirtualIPMode = "ipalias"
+	VirtualIPModeCARP     VirtualIPMode = "carp"
+	VirtualIPModeProxyARP VirtualIPMode = "proxyarp"
+	VirtualIPModeOther    VirtualIPMode = "other"
+)
+
+// VirtualIPType is whether a virtual IP is a single address or a whole
+// network. Only Proxy ARP and Other virtual IPs can be networks.
+type VirtualIPType string
+
+const (
+	VirtualIPTypeSingle  VirtualIPType = "single"
+	VirtualIPTypeNetwork VirtualIPType = "network"
+)
+
+// VirtualIP represents a single virtual IP.
+type VirtualIP struct {
+	VirtualIPRequest
+	Id int `json:"id"`
+}
+
+// VirtualIPRequest is a virtual IP as it is created or updated. Vhid,
+// Advbase, Advskew and Password only apply to CARP virtual IPs; a nil
+// Advbase or Advskew leaves the firewall's default.
+type VirtualIPRequest struct {
+	Mode       VirtualIPMode    `json:"mode"`
+	Interface  string           `json:"interface"`
+	Type       VirtualIPType    `json:"type,omitempty"`
+	Subnet     string           `json:"subnet"`
+	SubnetBits int              `json:"subnet_bits"`
+	Descr      *optional.String `json:"descr,omitempty"`
+	Noexpand   bool             `json:"noexpand"`
+	Vhid       *optional.Int    `json:"vhid,omitempty"`
+	Advbase    *optional.Int    `json:"advbase,omitempty"`
+	Advskew    *optional.Int    `json:"advskew,omitempty"`
+	Password   string           `json:"password,omitempty"`
+
+	// Extra holds the fields this library does not model.
+	Extra map[string]json.RawMessage `json:"-"`
+}
+
+type virtualIPListResponse struct {
+	apiResponse
+	Data flexList[*VirtualIP] `json:"data"`
+}
+
+type virtualIPResponse struct {
+	apiResponse
+	Data *VirtualIP `json:"data"`
+}
+
+// ListVirtualIPs returns the virtual IPs.
+func (s VirtualIPService) ListVirtualIPs(ctx context.Context) ([]*VirtualIP, error) {
+	response, err := s.client.get(ctx, virtualIPsEndpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := new(virtualIPListResponse)
+	if err = s.client.decode(response, resp); err != nil {
+		return nil, fmt.Errorf("error unmarshalling response: %w", err)
+	}
+	return resp.Data, nil
+}
+
+// GetVirtualIP returns the virtual IP with the given ID.
+func (s VirtualIPService) GetVirtualIP(ctx context.Context, id int) (*VirtualIP, error) {
+	response, err := s.client.get(
+		ctx,
+		virtualIPEndpoint,
+		map[string]string{
+			"id": strconv.Itoa(id),
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := new(virtualIPResponse)
+	if err = s.client.decode(response, resp); err != nil {
+		return nil, fmt.Errorf("error unmarshalling response: %w", err)
+	}
+	return resp.Data, nil
+}
+
+// DeleteVirtualIP deletes a virtual IP.
+func (s VirtualIPService) DeleteVirtualIP(ctx context.Context, idToDelete int) (*VirtualIP, error) {
+	response, err := s.client.delete(
+		ctx,
+		virtualIPEndpoint,
+		map[string]string{
+			"id": strconv.Itoa(idToDelete),
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := new(virtualIPResponse)
+	if err = s.client.decode(response, resp); err != nil {
+		return nil, fmt.Errorf("error unmarshalling response: %w", err)
+	}
+	return resp.Data, nil
+}
+
+// CreateVirtualIP creates a new virtual IP. A CARP virtual IP is only
+// created if no other CARP virtual IP on its interface uses its VHID;
+// otherwise the error wraps ErrVHIDInUse. The check only keeps writes made
+// through this Client apart; another client creating a virtual IP with the
+// same VHID at the same time can still get in between.
+func (s VirtualIPService) CreateVirtualIP(
+	ctx context.Context,
+	newVirtualIP VirtualIPRequest,
+) (*VirtualIP, error) {
+	unlock, err := s.reserveVHID(ctx, -1, newVirtualIP)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	jsonData, err := json.Marshal(newVirtualIP)
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling request payload into json: %w", err)
+	}
+
+	response, err := s.client.post(ctx, virtualIPEndpoint, nil, jsonData)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := new(virtualIPResponse)
+	if err = s.client.decode(response, resp); err != nil {
+		return nil, fmt.Errorf("error unmarshalling response: %w", err)
+	}
+	return resp.Data, nil
+}
+
+// UpdateVirtualIP modifies an existing virtual IP. The VHID of a CARP
+// virtual IP is checked the same way as by CreateVirtualIP.
+func (s VirtualIPService) UpdateVirtualIP(
+	ctx context.Context,
+	idToUpdate int,
+	virtualIPData VirtualIPRequest,
+) (*VirtualIP, error) {
+	unlock, err := s.reserveVHID(ctx, idToUpdate, virtualIPData)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	requestData := VirtualIP{
+		VirtualIPRequest: virtualIPData,
+		Id:               idToUpdate,
+	}
+
+	jsonData, err := json.Marshal(requestData)
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling request payload into json: %w", err)
+	}
+
+	response, err := s.client.patch(ctx, virtualIPEndpoint, nil, jsonData)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := new(virtualIPResponse)
+	if err = s.client.decode(response, resp); err != nil {
+		return nil, fmt.Errorf("error unmarshalling response: %w", err)
+	}
+	return resp.Data, nil
+}
+
+// Apply applies pending virtual IP changes.
+func (s VirtualIPService) Apply(ctx context.Context) error {
+	response, err := s.client.post(ctx, virtualIPApplyEndpoint, nil, nil)
+	if err != nil {
+		return err
+	}
+
+	resp := new(apiResponse)
+	if err = s.client.decode(response, resp); err != nil {
+		return fmt.Errorf("error unmarshalling response: %w", err)
+	}
+
+	return nil
+}
+
+// reserveVHID checks that the VHID of a CARP virtual IP is free on its
+// interface, ignoring the virtual IP with the given id, which is -1 for a new
+// one. Writes of CARP virtual IPs to the same interface run one at a time on
+// a client, so the returned func must be called once the write is done. The
+// lock is held in this process only and does not stop other clients.
+func (s VirtualIPService) reserveVHID(ctx context.Context, id int, vip VirtualIPRequest) (func(), error) {
+	if vip.Mode != VirtualIPModeCARP {
+		return func() {}, nil
+	}
+
+	unlock, err := lockKey(ctx, &s.client.vhidLocks, vip.Interface)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := s.ListVirtualIPs(WithoutCache(ctx))
+	if err == nil {
+		for _, other := range existing {
+			if other.Id != id && vhidClash(&other.VirtualIPRequest, &vip) {
+				err = fmt.Errorf("%w: VHID %d on %s is taken by virtual IP %d (%s)", ErrVHIDInUse, vhidOf(&vip), vip.Interface, other.Id, other.Subnet)
+				break
+			}
+		}
+	}
+	if err != nil {
+		unlock()
+		return nil, err
+	}
+	return unlock, nil
+}
+
+// checkVHIDs returns an error wrapping ErrVHIDInUse if two CARP virtual IPs
+// in vips share a VHID on the same interface.
+func checkVHIDs(vips []*VirtualIPRequest) error {
+	for i, vip := range vips {
+		for j, other := range vips[:i] {
+			if vhidClash(other, vip) {
+				return fmt.Errorf("%w: VHID %d on %s is used by both item %d and item %d", ErrVHIDInUse, vhidOf(vip), vip.Interface, j, i)
+			}
+		}
+	}
+	return nil
+}
+
+func vhidClash(a, b *VirtualIPRequest) bool {
+	return a.Mode == VirtualIPModeCARP && b.Mode == VirtualIPModeCARP &&
+		a.Interface == b.Interface && vhidOf(a) == vhidOf(b)
+}
+
+func vhidOf(vip *VirtualIPRequest) int {
+	if vip.Vhid == nil {
+		return 0
+	}
+	return vip.Vhid.OrElse(0)
+}
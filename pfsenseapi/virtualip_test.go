@@ -0,0 +1,308 @@
+package pfsenseapi
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestVirtualIPService_ListVirtualIPs(t *testing.T) {
+	data := mustReadFileString(t, "testdata/multiplevirtualip.json")
+	server := setupTestServer(t, data)
+	defer server.Close()
+
+	newClient := NewClientWithNoAuth(server.URL)
+	response, err := newClient.VirtualIP.ListVirtualIPs(context.Background())
+	require.NoError(t, err)
+	require.Len(t, response, 3)
+	require.Equal(t, VirtualIPModeCARP, response[0].Mode)
+	require.Equal(t, 10, response[0].Vhid.MustGet())
+	require.Equal(t, VirtualIPTypeNetwork, response[2].Type)
+
+	response, err = newClient.VirtualIP.ListVirtualIPs(context.Background())
+	require.Error(t, err)
+	require.Nil(t, response)
+
+	response, err = newClient.VirtualIP.ListVirtualIPs(context.Background())
+	require.Error(t, err)
+	require.Nil(t, response)
+}
+
+func TestVirtualIPService_GetVirtualIP(t *testing.T) {
+	data := mustReadFileString(t, "testdata/singlevirtualip.json")
+	server := setupTestServer(t, data)
+	defer server.Close()
+
+	newClient := NewClientWithNoAuth(server.URL)
+	response, err := newClient.VirtualIP.GetVirtualIP(context.Background(), 1)
+	require.NoError(t, err)
+	require.Equal(t, "192.168.1.2", response.Subnet)
+	require.Equal(t, 24, response.SubnetBits)
+	require.Contains(t, response.Extra, "uniqid")
+
+	response, err = newClient.VirtualIP.GetVirtualIP(context.Background(), 1)
+	require.Error(t, err)
+	require.Nil(t, response)
+
+	response, err = newClient.VirtualIP.GetVirtualIP(context.Background(), 1)
+	require.Error(t, err)
+	require.Nil(t, response)
+}
+
+func TestVirtualIPService_DeleteVirtualIP(t *testing.T) {
+	data := mustReadFileString(t, "testdata/singlevirtualip.json")
+	server := setupTestServer(t, data)
+	defer server.Close()
+
+	newClient := NewClientWithNoAuth(server.URL)
+	response, err := newClient.VirtualIP.DeleteVirtualIP(context.Background(), 1)
+	require.NoError(t, err)
+	require.NotNil(t, response)
+
+	response, err = newClient.VirtualIP.DeleteVirtualIP(context.Background(), 1)
+	require.Error(t, err)
+	require.Nil(t, response)
+
+	response, err = newClient.VirtualIP.DeleteVirtualIP(context.Background(), 1)
+	require.Error(t, err)
+	require.Nil(t, response)
+}
+
+func TestVirtualIPService_CreateVirtualIP(t *testing.T) {
+	data := mustReadFileString(t, "testdata/singlevirtualip.json")
+	server := setupTestServer(t, data)
+	defer server.Close()
+
+	newClient := NewClientWithNoAuth(server.URL)
+	newVirtualIP := VirtualIPRequest{
+		Mode:       VirtualIPModeIPAlias,
+		Interface:  "lan",
+		Subnet:     "192.168.1.2",
+		SubnetBits: 24,
+	}
+	response, err := newClient.VirtualIP.CreateVirtualIP(context.Background(), newVirtualIP)
+	require.NoError(t, err)
+	require.NotNil(t, response)
+
+	response, err = newClient.VirtualIP.CreateVirtualIP(context.Background(), newVirtualIP)
+	require.Error(t, err)
+	require.Nil(t, response)
+
+	response, err = newClient.VirtualIP.CreateVirtualIP(context.Background(), newVirtualIP)
+	require.Error(t, err)
+	require.Nil(t, response)
+}
+
+func TestVirtualIPService_UpdateVirtualIP(t *testing.T) {
+	data := mustReadFileString(t, "testdata/singlevirtualip.json")
+	server := setupTestServer(t, data)
+	defer server.Close()
+
+	newClient := NewClientWithNoAuth(server.URL)
+	virtualIP := VirtualIPRequest{
+		Mode:       VirtualIPModeIPAlias,
+		Interface:  "lan",
+		Subnet:     "192.168.1.3",
+		SubnetBits: 24,
+	}
+	response, err := newClient.VirtualIP.UpdateVirtualIP(context.Background(), 1, virtualIP)
+	require.NoError(t, err)
+	require.NotNil(t, response)
+
+	response, err = newClient.VirtualIP.UpdateVirtualIP(context.Background(), 1, virtualIP)
+	require.Error(t, err)
+	require.Nil(t, response)
+
+	response, err = newClient.VirtualIP.UpdateVirtualIP(context.Background(), 1, virtualIP)
+	require.Error(t, err)
+	require.Nil(t, response)
+}
+
+func TestVirtualIPService_Apply(t *testing.T) {
+	server := setupTestServer(t, "{}")
+	defer server.Close()
+
+	newClient := NewClientWithNoAuth(server.URL)
+	err := newClient.VirtualIP.Apply(context.Background())
+	require.NoError(t, err)
+
+	err = newClient.VirtualIP.Apply(context.Background())
+	require.Error(t, err)
+
+	err = newClient.VirtualIP.Apply(context.Background())
+	require.Error(t, err)
+}
+
+// vipServer is a fake virtual IP endpoint that keeps the virtual IPs it is
+// sent, so that VHID checks see earlier writes.
+type vipServer struct {
+	t *testing.T
+
+	mu     sync.Mutex
+	vips   []*VirtualIP
+	writes int
+}
+
+func (s *vipServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var data any
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/"+virtualIPsEndpoint:
+		data = s.vips
+	case r.Method == http.MethodPost && r.URL.Path == "/"+virtualIPEndpoint:
+		vip := new(VirtualIP)
+		require.NoError(s.t, json.NewDecoder(r.Body).Decode(&vip.VirtualIPRequest))
+		vip.Id = len(s.vips)
+		s.vips = append(s.vips, vip)
+		s.writes++
+		data = vip
+	case r.Method == http.MethodPatch && r.URL.Path == "/"+virtualIPEndpoint:
+		vip := new(VirtualIP)
+		require.NoError(s.t, json.NewDecoder(r.Body).Decode(vip))
+		s.vips[vip.Id] = vip
+		s.writes++
+		data = vip
+	default:
+		s.t.Errorf("unexpected %s %s", r.Method, r.URL.Path)
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	body, err := json.Marshal(map[string]any{"code": 200, "status": "ok", "data": data})
+	require.NoError(s.t, err)
+	w.Header().Set("Content-Type", "application/json")
+	_, err = io.WriteString(w, string(body))
+	require.NoError(s.t, err)
+}
+
+func TestVirtualIPService_VHIDCheck(t *testing.T) {
+	fake := &vipServer{t: t}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	newClient := NewClientWithNoAuth(server.URL)
+	carp := func(iface string, vhid int, subnet string) VirtualIPRequest {
+		return VirtualIPRequest{
+			Mode:       VirtualIPModeCARP,
+			Interface:  iface,
+			Subnet:     subnet,
+			SubnetBits: 24,
+			Vhid:       optionalInt(vhid),
+			Password:   "secret",
+		}
+	}
+
+	first, err := newClient.VirtualIP.CreateVirtualIP(context.Background(), carp("lan", 10, "192.168.1.1"))
+	require.NoError(t, err)
+
+	_, err = newClient.VirtualIP.CreateVirtualIP(context.Background(), carp("lan", 10, "192.168.1.254"))
+	require.ErrorIs(t, err, ErrVHIDInUse)
+	require.ErrorContains(t, err, "virtual IP 0 (192.168.1.1)")
+	require.Equal(t, 1, fake.writes)
+
+	// the same VHID is fine on another interface, and non-CARP virtual IPs
+	// are not checked
+	_, err = newClient.VirtualIP.CreateVirtualIP(context.Background(), carp("opt1", 10, "10.0.10.1"))
+	require.NoError(t, err)
+	_, err = newClient.VirtualIP.CreateVirtualIP(context.Background(), VirtualIPRequest{
+		Mode: VirtualIPModeIPAlias, Interface: "lan", Subnet: "192.168.1.2", SubnetBits: 24, Vhid: optionalInt(10),
+	})
+	require.NoError(t, err)
+
+	// an update may keep its own VHID but not take another's
+	_, err = newClient.VirtualIP.UpdateVirtualIP(context.Background(), first.Id, carp("lan", 10, "192.168.1.1"))
+	require.NoError(t, err)
+	_, err = newClient.VirtualIP.UpdateVirtualIP(context.Background(), 1, carp("lan", 10, "10.0.10.1"))
+	require.ErrorIs(t, err, ErrVHIDInUse)
+	require.Equal(t, 4, fake.writes)
+}
+
+func TestVirtualIPService_UpdateVirtualIPZeroAdvskew(t *testing.T) {
+	fake := &vipServer{t: t}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	newClient := NewClientWithNoAuth(server.URL)
+	vip := VirtualIPRequest{
+		Mode:       VirtualIPModeCARP,
+		Interface:  "lan",
+		Subnet:     "192.168.1.1",
+		SubnetBits: 24,
+		Vhid:       optionalInt(10),
+		Advskew:    optionalInt(100),
+		Password:   "secret",
+	}
+	created, err := newClient.VirtualIP.CreateVirtualIP(context.Background(), vip)
+	require.NoError(t, err)
+
+	// promoting the node to CARP primary sends an explicit zero
+	vip.Advskew = optionalInt(0)
+	_, err = newClient.VirtualIP.UpdateVirtualIP(context.Background(), created.Id, vip)
+	require.NoError(t, err)
+	require.NotNil(t, fake.vips[0].Advskew)
+	require.Equal(t, 0, fake.vips[0].Advskew.MustGet())
+	require.Nil(t, fake.vips[0].Advbase)
+}
+
+func TestVirtualIPService_VHIDCheckConcurrent(t *testing.T) {
+	fake := &vipServer{t: t}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	newClient := NewClientWithNoAuth(server.URL)
+	vip := VirtualIPRequest{
+		Mode:       VirtualIPModeCARP,
+		Interface:  "lan",
+		Subnet:     "192.168.1.1",
+		SubnetBits: 24,
+		Vhid:       optionalInt(10),
+		Password:   "secret",
+	}
+
+	var wg sync.WaitGroup
+	errs := make([]error, 10)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, errs[i] = newClient.VirtualIP.CreateVirtualIP(context.Background(), vip)
+		}(i)
+	}
+	wg.Wait()
+
+	created := 0
+	for _, err := range errs {
+		if err == nil {
+			created++
+		} else {
+			require.ErrorIs(t, err, ErrVHIDInUse)
+		}
+	}
+	require.Equal(t, 1, created)
+	require.Len(t, fake.vips, 1)
+}
+
+func TestVirtualIPService_ReplaceAllVirtualIPs(t *testing.T) {
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected %s %s", r.Method, r.URL.Path)
+	}
+	server := httptest.NewServer(http.HandlerFunc(handler))
+	defer server.Close()
+
+	newClient := NewClientWithNoAuth(server.URL)
+	_, err := newClient.VirtualIP.ReplaceAllVirtualIPs(context.Background(), []*VirtualIPRequest{
+		{Mode: VirtualIPModeCARP, Interface: "lan", Subnet: "192.168.1.1", SubnetBits: 24, Vhid: optionalInt(10)},
+		{Mode: VirtualIPModeCARP, Interface: "opt1", Subnet: "10.0.10.1", SubnetBits: 24, Vhid: optionalInt(10)},
+		{Mode: VirtualIPModeCARP, Interface: "lan", Subnet: "192.168.1.254", SubnetBits: 24, Vhid: optionalInt(10)},
+	})
+	require.ErrorIs(t, err, ErrVHIDInUse)
+	require.ErrorContains(t, err, "item 0 and item 2")
+}
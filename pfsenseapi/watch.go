@@ -0,0 +1,221 @@
+package pfsenseapi
+
+import (
+	"context"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// WatchEventType is the kind of change reported by a WatchEvent.
+type WatchEventType string
+
+const (
+	// WatchAdded reports an object that was not present in the previous poll.
+	WatchAdded WatchEventType = "ADDED"
+	// WatchModified reports an object whose configuration changed.
+	WatchModified WatchEventType = "MODIFIED"
+	// WatchDeleted reports an object that is no longer present.
+	WatchDeleted WatchEventType = "DELETED"
+	// WatchError reports a failed poll. The watch keeps running and diffs the
+	// next successful poll against the last successful one.
+	WatchError WatchEventType = "ERROR"
+)
+
+// WatchEvent is a single change to an object of type T between two polls.
+type WatchEvent[T any] struct {
+	Type WatchEventType
+	// Object is the current state of the object, or its last known state for
+	// WatchDeleted events.
+	Object *T
+	// Previous is the state before the change for WatchModified events.
+	Previous *T
+	// Err is set for WatchError events.
+	Err error
+}
+
+type (
+	// InterfaceEvent is a change to an interface.
+	InterfaceEvent = WatchEvent[Interface]
+	// VLANEvent is a change to a VLAN.
+	VLANEvent = WatchEvent[VLAN]
+	// InterfaceGroupEvent is a change to an interface group.
+	InterfaceGroupEvent = WatchEvent[InterfaceGroup]
+	// InterfaceBridgeEvent is a change to a bridge.
+	InterfaceBridgeEvent = WatchEvent[InterfaceBridge]
+	// UserEvent is a change to a user.
+	UserEvent = WatchEvent[User]
+	// UserGroupEvent is a change to a user group.
+	UserGroupEvent = WatchEvent[UserGroup]
+)
+
+// watchList polls list every interval and sends the differences between
+// successive results on the returned channel. Objects are matched by key
+// rather than by id, since pfSense ids are array positions that shift when
+// an earlier object is deleted. The first poll reports every object as
+// added. The channel is closed once ctx is done.
+func watchList[T any](
+	ctx context.Context,
+	interval time.Duration,
+	list func(ctx context.Context) ([]*T, error),
+	key func(*T) string,
+	equal func(a, b *T) bool,
+) <-chan WatchEvent[T] {
+	events := make(chan WatchEvent[T])
+
+	go func() {
+		defer close(events)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		var previous []*T
+		for {
+			current, err := list(WithoutCache(ctx))
+			if ctx.Err() != nil {
+				return
+			}
+
+			if err != nil {
+				if !sendEvent(ctx, events, WatchEvent[T]{Type: WatchError, Err: err}) {
+					return
+				}
+			} else {
+				for _, event := range diffLists(previous, current, key, equal) {
+					if !sendEvent(ctx, events, event) {
+						return
+					}
+				}
+				previous = current
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	return events
+}
+
+func sendEvent[T any](ctx context.Context, events chan<- WatchEvent[T], event WatchEvent[T]) bool {
+	select {
+	case events <- event:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// diffLists returns the events that turn previous into current. Added and
+// modified events follow the order of current, deleted events the order of
+// previous.
+func diffLists[T any](previous, current []*T, key func(*T) string, equal func(a, b *T) bool) []WatchEvent[T] {
+	before := make(map[string]*T, len(previous))
+	for _, obj := range previous {
+		before[key(obj)] = obj
+	}
+
+	var events []WatchEvent[T]
+	seen := make(map[string]bool, len(current))
+	for _, obj := range current {
+		k := key(obj)
+		seen[k] = true
+
+		old, ok := before[k]
+		switch {
+		case !ok:
+			events = append(events, WatchEvent[T]{Type: WatchAdded, Object: obj})
+		case !equal(old, obj):
+			events = append(events, WatchEvent[T]{Type: WatchModified, Object: obj, Previous: old})
+		}
+	}
+
+	for _, obj := range previous {
+		if !seen[key(obj)] {
+			events = append(events, WatchEvent[T]{Type: WatchDeleted, Object: obj})
+		}
+	}
+
+	return events
+}
+
+// WatchInterfaces polls the interfaces every interval and reports changes,
+// matching interfaces on their pfSense ID (wan, lan, optx).
+func (s InterfaceService) WatchInterfaces(ctx context.Context, interval time.Duration) <-chan InterfaceEvent {
+	return watchList(
+		ctx,
+		interval,
+		s.ListInterfaces,
+		func(i *Interface) string { return i.Id },
+		func(a, b *Interface) bool { return reflect.DeepEqual(a, b) },
+	)
+}
+
+// WatchVLANs polls the VLANs every interval and reports changes, matching
+// VLANs on their parent interface and tag.
+func (s InterfaceService) WatchVLANs(ctx context.Context, interval time.Duration) <-chan VLANEvent {
+	return watchList(
+		ctx,
+		interval,
+		s.ListVLANs,
+		func(v *VLAN) string { return v.If + "." + strconv.Itoa(v.Tag) },
+		func(a, b *VLAN) bool { return reflect.DeepEqual(a.VLANRequest, b.VLANRequest) },
+	)
+}
+
+// WatchInterfaceGroups polls the interface groups every interval and reports
+// changes, matching groups on their name.
+func (s InterfaceService) WatchInterfaceGroups(ctx context.Context, interval time.Duration) <-chan InterfaceGroupEvent {
+	return watchList(
+		ctx,
+		interval,
+		s.ListInterfaceGroups,
+		func(g *InterfaceGroup) string { return g.Ifname },
+		func(a, b *InterfaceGroup) bool {
+			return reflect.DeepEqual(a.InterfaceGroupRequest, b.InterfaceGroupRequest)
+		},
+	)
+}
+
+// WatchInterfaceBridges polls the bridges every interval and reports changes,
+// matching bridges on their bridge interface name.
+func (s InterfaceService) WatchInterfaceBridges(ctx context.Context, interval time.Duration) <-chan InterfaceBridgeEvent {
+	return watchList(
+		ctx,
+		interval,
+		s.ListInterfaceBridges,
+		func(b *InterfaceBridge) string { return b.Bridgeif },
+		func(a, b *InterfaceBridge) bool {
+			return reflect.DeepEqual(a.InterfaceBridgeRequest, b.InterfaceBridgeRequest)
+		},
+	)
+}
+
+// WatchUsers polls the users every interval and reports changes, matching
+// users on their username.
+func (s *UserService) WatchUsers(ctx context.Context, interval time.Duration) <-chan UserEvent {
+	return watchList(
+		ctx,
+		interval,
+		s.ListUsers,
+		func(u *User) string { return u.Name },
+		func(a, b *User) bool { return a.UID == b.UID && reflect.DeepEqual(a.UserRequest, b.UserRequest) },
+	)
+}
+
+// WatchUserGroups polls the user groups every interval and reports changes,
+// matching groups on their name.
+func (s *UserService) WatchUserGroups(ctx context.Context, interval time.Duration) <-chan UserGroupEvent {
+	return watchList(
+		ctx,
+		interval,
+		s.ListUserGroups,
+		func(g *UserGroup) string { return g.Name },
+		func(a, b *UserGroup) bool {
+			return a.GID == b.GID && reflect.DeepEqual(a.UserGroupRequest, b.UserGroupRequest)
+		},
+	)
+}
@@ -0,0 +1,121 @@
+package pfsenseapi
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// setupSequenceServer returns a server that answers with each of the
+// responses in turn and then keeps repeating the last one.
+func setupSequenceServer(t *testing.T, statuses []int, responses []string) *httptest.Server {
+	var mu sync.Mutex
+	call := 0
+
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		i := call
+		if call < len(responses)-1 {
+			call++
+		}
+		mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(statuses[i])
+		_, err := io.WriteString(w, responses[i])
+		require.NoError(t, err)
+	}
+
+	return httptest.NewServer(http.HandlerFunc(handler))
+}
+
+func nextEvent[T any](t *testing.T, events <-chan WatchEvent[T]) WatchEvent[T] {
+	t.Helper()
+
+	select {
+	case event, ok := <-events:
+		require.True(t, ok, "watch channel closed")
+		return event
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "timed out waiting for watch event")
+	}
+	return WatchEvent[T]{}
+}
+
+func TestInterfaceService_WatchVLANs(t *testing.T) {
+	server := setupSequenceServer(
+		t,
+		[]int{http.StatusOK, http.StatusBadRequest, http.StatusOK},
+		[]string{
+			mustReadFileString(t, "testdata/multiplevlan.json"),
+			mustReadFileString(t, "testdata/error.json"),
+			mustReadFileString(t, "testdata/multiplevlanchanged.json"),
+		},
+	)
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	newClient := NewClientWithNoAuth(server.URL)
+	events := newClient.Interface.WatchVLANs(ctx, time.Millisecond)
+
+	event := nextEvent(t, events)
+	require.Equal(t, WatchAdded, event.Type)
+	require.Equal(t, "em1", event.Object.If)
+	event = nextEvent(t, events)
+	require.Equal(t, WatchAdded, event.Type)
+	require.Equal(t, "em2", event.Object.If)
+
+	event = nextEvent(t, events)
+	require.Equal(t, WatchError, event.Type)
+	require.ErrorIs(t, event.Err, ErrBadRequest)
+
+	event = nextEvent(t, events)
+	require.Equal(t, WatchModified, event.Type)
+	require.Equal(t, "Renamed VLAN", event.Object.Descr.MustGet())
+	require.Equal(t, "Test VLAN", event.Previous.Descr.MustGet())
+
+	event = nextEvent(t, events)
+	require.Equal(t, WatchAdded, event.Type)
+	require.Equal(t, 300, event.Object.Tag)
+
+	event = nextEvent(t, events)
+	require.Equal(t, WatchDeleted, event.Type)
+	require.Equal(t, 200, event.Object.Tag)
+
+	cancel()
+	for range events {
+	}
+}
+
+func TestUserService_WatchUsers(t *testing.T) {
+	server := setupSequenceServer(
+		t,
+		[]int{http.StatusOK},
+		[]string{mustReadFileString(t, "testdata/multipleuser.json")},
+	)
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	newClient := NewClientWithNoAuth(server.URL)
+	events := newClient.User.WatchUsers(ctx, time.Millisecond)
+
+	require.Equal(t, "user1", nextEvent(t, events).Object.Name)
+	require.Equal(t, "user2", nextEvent(t, events).Object.Name)
+
+	// unchanged polls produce no events, so the channel stays quiet until
+	// the watch is cancelled
+	cancel()
+	for event := range events {
+		require.Fail(t, "unexpected event", "%v", event.Type)
+	}
+}